@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
+	_ "time/tzdata" // tenant timezones must resolve on hosts without a zoneinfo database
 
+	"franchise-saas-backend/config"
 	"franchise-saas-backend/internal/database"
 	"franchise-saas-backend/internal/handlers"
+	"franchise-saas-backend/internal/mailer"
 	"franchise-saas-backend/internal/middleware"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/password"
+	"franchise-saas-backend/internal/ratelimit"
+	"franchise-saas-backend/internal/repository"
 	"franchise-saas-backend/internal/services"
+	"franchise-saas-backend/internal/tokens"
+	"franchise-saas-backend/migrations"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -29,14 +42,61 @@ func init() {
 	viper.SetDefault("port", "8080")
 	viper.SetDefault("db_host", "localhost")
 	viper.SetDefault("db_port", "5432")
-	viper.SetDefault("db_user", "postgres")
-	viper.SetDefault("db_password", "postgres")
+	viper.SetDefault("db_user", "franchise_app")
+	viper.SetDefault("db_password", "franchise_app")
 	viper.SetDefault("db_name", "franchise_db")
 	viper.SetDefault("jwt_secret", "default_secret_key_for_development_change_in_production")
 	viper.SetDefault("jwt_expiration_hours", 24)
+	viper.SetDefault("jwt_issuer", "franchise-saas")
+	viper.SetDefault("jwt_audience", "franchise-saas-api")
+	viper.SetDefault("jwt_keys_dir", "./keys")
+	viper.SetDefault("jwt_algorithm", "RS256")
+	viper.SetDefault("jwt_key_rotation_days", 30)
+	viper.SetDefault("jwt_key_activation_minutes", 60)
+	viper.SetDefault("jwt_keys_reload_minutes", 5)
 	viper.SetDefault("refresh_token_expiration_days", 7)
-	viper.SetDefault("cors_allowed_origins", []string{"*"})
+	viper.SetDefault("cors_allowed_origins", []string{})
+	viper.SetDefault("trusted_proxies", []string{})
 	viper.SetDefault("log_level", "info")
+	viper.SetDefault("auto_migrate", false)
+	viper.SetDefault("app_url", "http://localhost:3000")
+	viper.SetDefault("mail_driver", "log")
+	viper.SetDefault("mail_from", "Franchise SaaS <no-reply@localhost>")
+	viper.SetDefault("smtp_port", 587)
+	viper.SetDefault("password_reset_expiration_minutes", 60)
+	viper.SetDefault("email_verification_expiration_hours", 48)
+	viper.SetDefault("invitation_expiration_days", 7)
+	viper.SetDefault("impersonation_ttl_minutes", 30)
+	viper.SetDefault("feature_cache_seconds", 60)
+	viper.SetDefault("domain_cache_seconds", 60)
+	viper.SetDefault("session_cache_seconds", 10)
+	viper.SetDefault("quota_cache_seconds", 60)
+	viper.SetDefault("checklist_scheduler_minutes", 5)
+	viper.SetDefault("password_min_length", 8)
+	viper.SetDefault("password_require_letter", true)
+	viper.SetDefault("password_require_digit", true)
+	viper.SetDefault("password_reject_common", true)
+	viper.SetDefault("totp_issuer", "Franchise SaaS")
+	viper.SetDefault("login_free_attempts", 3)
+	viper.SetDefault("login_base_delay_seconds", 1)
+	viper.SetDefault("login_max_attempts", 10)
+	viper.SetDefault("login_lockout_minutes", 15)
+	viper.SetDefault("login_window_minutes", 60)
+	viper.SetDefault("login_ip_free_attempts", 10)
+	viper.SetDefault("login_ip_base_delay_seconds", 1)
+	viper.SetDefault("login_ip_max_attempts", 50)
+	viper.SetDefault("login_ip_lockout_minutes", 15)
+	viper.SetDefault("login_ip_window_minutes", 60)
+	viper.SetDefault("password_reset_free_attempts", 3)
+	viper.SetDefault("password_reset_base_delay_seconds", 60)
+	viper.SetDefault("password_reset_max_attempts", 10)
+	viper.SetDefault("password_reset_lockout_minutes", 60)
+	viper.SetDefault("password_reset_window_minutes", 60)
+	viper.SetDefault("password_reset_ip_free_attempts", 10)
+	viper.SetDefault("password_reset_ip_base_delay_seconds", 60)
+	viper.SetDefault("password_reset_ip_max_attempts", 50)
+	viper.SetDefault("password_reset_ip_lockout_minutes", 60)
+	viper.SetDefault("password_reset_ip_window_minutes", 60)
 
 	// Load environment variables with prefix
 	viper.SetEnvPrefix("FRANCHISE")
@@ -55,6 +115,16 @@ func init() {
 }
 
 func main() {
+	// Subcommands run instead of the HTTP server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "superadmin" {
+		runSuperadmin(os.Args[2:])
+		return
+	}
+
 	// Set Gin mode based on environment
 	if viper.GetString("gin_mode") == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -71,49 +141,178 @@ func main() {
 	// Create router with middleware
 	r := gin.New()
 
+	// Only the listed proxies may pass the client IP in X-Forwarded-For; by
+	// default none is trusted and the IP is the address of the connection
+	if err := r.SetTrustedProxies(viper.GetStringSlice("trusted_proxies")); err != nil {
+		log.Fatalf("Invalid trusted_proxies: %v", err)
+	}
+
 	// Add recovery middleware
 	r.Use(gin.Recovery())
 
+	// Connect to database
+	db, err := database.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer func() {
+		// sql.DB.Close() не возвращает ошибку
+		db.Close()
+		log.Println("Database connection closed")
+	}()
+
+	// Row-level security is the second line of tenant isolation; a role that
+	// bypasses it leaves only the application checks
+	if bypasses, err := database.BypassesRowLevelSecurity(context.Background(), db); err != nil {
+		log.Printf("WARNING: %v", err)
+	} else if bypasses {
+		log.Printf("WARNING: database user %q is a superuser or has BYPASSRLS, row-level security policies are NOT enforced. "+
+			"Connect as a plain role without SUPERUSER and BYPASSRLS (see postgres-init/01-app-role.sh)", viper.GetString("db_user"))
+	}
+
+	// Apply pending migrations before serving requests if enabled
+	if viper.GetBool("auto_migrate") {
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		if err := autoMigrate(context.Background(), migrator); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+	}
+
+	mail, err := mailer.New()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	passwordPolicy, err := password.LoadPolicy()
+	if err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+
+	// New tenants start with the features and limits of their plan
+	plans, err := config.LoadPlans()
+	if err != nil {
+		log.Fatalf("Failed to load plan catalogue: %v", err)
+	}
+	models.SetPlans(plans)
+
+	// Login throttling and API call counters are shared through Redis when it is configured
+	limiterStore, err := ratelimit.NewStore(config.LoadConfig().RedisURL)
+	if err != nil {
+		log.Fatalf("Failed to configure login limiter: %v", err)
+	}
+
+	// Signing keys are reloaded periodically to pick up rotated keys
+	keys, err := tokens.Load()
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	go keys.Run(context.Background(), time.Duration(viper.GetInt("jwt_keys_reload_minutes"))*time.Minute)
+
+	// Initialize services with dependencies
+	store := repository.NewPostgresStore(db)
+	issuer := tokens.LoadIssuer(keys)
+	authService := services.NewAuthService(store, mail, passwordPolicy, services.NewLoginLimiter(limiterStore), issuer)
+	roleService := services.NewRoleService(store)
+	userService := services.NewUserService(store, authService, passwordPolicy, roleService)
+	checklistService := services.NewChecklistService(store, roleService)
+	templateService := services.NewChecklistTemplateService(store, roleService)
+	sessionService := services.NewSessionService(store, authService, roleService)
+	invitationService := services.NewInvitationService(store, mail, roleService)
+	quotaService := services.NewQuotaService(store, limiterStore)
+	tenantService := services.NewTenantService(store, authService, invitationService, quotaService)
+	auditService := services.NewAuditService(store)
+	apiKeyService := services.NewAPIKeyService(store, roleService)
+	domainService := services.NewDomainService(store, net.DefaultResolver)
+	holidayService := services.NewHolidayService(store)
+
+	// Checklists of recurring templates are generated in the background; 0 disables it
+	scheduler := services.NewChecklistScheduler(store)
+	go scheduler.Run(context.Background(), time.Duration(viper.GetInt("checklist_scheduler_minutes"))*time.Minute)
+
+	// Custom domains of tenants are resolved before CORS, which allows the
+	// platform's own origins and the verified domains of tenants
+	r.Use(middleware.HostMiddleware(domainService))
+
+	origins := viper.GetStringSlice("cors_allowed_origins")
+	if len(origins) == 0 {
+		origins = []string{strings.TrimSuffix(viper.GetString("app_url"), "/")}
+	}
+
 	// Add CORS middleware with proper configuration
 	corsConfig := cors.Config{
-		AllowOrigins:     viper.GetStringSlice("cors_allowed_origins"),
+		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "X-Tenant-ID"},
-		ExposeHeaders:    []string{"Content-Length", "X-Total-Count"},
+		ExposeHeaders:    []string{"Content-Length", "X-Total-Count", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
 
-	if len(corsConfig.AllowOrigins) == 0 || corsConfig.AllowOrigins[0] == "*" {
+	if slices.Contains(origins, "*") {
 		corsConfig.AllowAllOrigins = true
 		corsConfig.AllowOrigins = nil
+	} else {
+		corsConfig.AllowOriginWithContextFunc = middleware.AllowedOrigin(domainService)
 	}
 
 	r.Use(cors.New(corsConfig))
 
-	// Connect to database
-	db, err := database.ConnectDB()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer func() {
-		// sql.DB.Close() не возвращает ошибку
-		db.Close()
-		log.Println("Database connection closed")
-	}()
-
-	// Initialize services with dependencies
-	authService := services.NewAuthService(db)
-	userService := services.NewUserService(db)
-	checklistService := services.NewChecklistService(db)
-
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
 	checklistHandler := handlers.NewChecklistHandler(checklistService)
+	templateHandler := handlers.NewChecklistTemplateHandler(templateService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	jwksHandler := handlers.NewJWKSHandler(keys)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	tenantHandler := handlers.NewTenantHandler(tenantService, quotaService)
+	adminHandler := handlers.NewAdminHandler(authService, auditService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	domainHandler := handlers.NewDomainHandler(domainService)
+	holidayHandler := handlers.NewHolidayHandler(holidayService)
+
+	authMiddleware := middleware.AuthMiddleware(issuer, authService, apiKeyService)
+	auditMiddleware := middleware.AuditMiddleware(auditService)
+	tenantMiddleware := middleware.TenantMiddleware(tenantService)
+	quotaMiddleware := middleware.QuotaMiddleware(quotaService)
+
+	// Routes require permissions, which are resolved from the roles of the tenant
+	can := func(permissions ...string) gin.HandlerFunc {
+		return middleware.PermissionMiddleware(roleService, permissions...)
+	}
+
+	// Modules answer only in tenants that have them switched on
+	feature := func(name string) gin.HandlerFunc {
+		return middleware.FeatureMiddleware(tenantService, name)
+	}
 
 	// Setup routes
-	setupRoutes(r, authHandler, userHandler, checklistHandler)
+	handlers.SetupRoutes(r, handlers.Handlers{
+		Auth:       authHandler,
+		User:       userHandler,
+		Checklist:  checklistHandler,
+		Template:   templateHandler,
+		Session:    sessionHandler,
+		JWKS:       jwksHandler,
+		Invitation: invitationHandler,
+		Tenant:     tenantHandler,
+		Admin:      adminHandler,
+		Role:       roleHandler,
+		APIKey:     apiKeyHandler,
+		Domain:     domainHandler,
+		Holiday:    holidayHandler,
+	}, handlers.RouteMiddleware{
+		Auth:    authMiddleware,
+		Audit:   auditMiddleware,
+		Tenant:  tenantMiddleware,
+		Quota:   quotaMiddleware,
+		Can:     can,
+		Feature: feature,
+	})
 
 	// Start server
 	startServer(r)
@@ -143,73 +342,6 @@ func setupLogger() *os.File {
 	return file
 }
 
-func setupRoutes(r *gin.Engine, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, checklistHandler *handlers.ChecklistHandler) {
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "OK",
-			"service":   "franchise-saas-backend",
-			"timestamp": time.Now().Format(time.RFC3339),
-			"version":   viper.GetString("version"),
-		})
-	})
-
-	// API v1 routes
-	api := r.Group("/api/v1")
-	{
-		// Public routes
-		public := api.Group("/auth")
-		{
-			public.POST("/register", authHandler.Register)
-			public.POST("/login", authHandler.Login)
-			public.POST("/refresh", authHandler.RefreshToken)
-		}
-
-		// Protected routes
-		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware())
-		{
-			// Auth routes
-			protected.POST("/auth/logout", authHandler.Logout)
-			protected.GET("/auth/me", authHandler.GetCurrentUser) // Add this endpoint
-
-			// User routes
-			users := protected.Group("/users")
-			{
-				users.GET("/profile", userHandler.GetProfile)
-				users.PUT("/profile", userHandler.UpdateProfile)
-			}
-
-			// Checklist routes
-			checklists := protected.Group("/checklists")
-			{
-				checklists.GET("", checklistHandler.GetChecklists)
-				checklists.GET("/:id", checklistHandler.GetChecklistByID)
-				checklists.POST("", checklistHandler.CreateChecklist)
-				checklists.PUT("/:id", checklistHandler.UpdateChecklist)
-				checklists.DELETE("/:id", checklistHandler.DeleteChecklist)
-				checklists.POST("/:id/complete", checklistHandler.CompleteChecklist)
-			}
-
-			// Dealer routes (for franchiser)
-			dealers := protected.Group("/dealers")
-			dealers.Use(middleware.RoleMiddleware("franchiser"))
-			{
-				dealers.GET("", userHandler.GetAllDealers)
-				dealers.GET("/:id", userHandler.GetDealerByID)
-			}
-		}
-	}
-
-	// 404 handler
-	r.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Not Found",
-			"message": "The requested resource was not found",
-		})
-	})
-}
-
 func startServer(r *gin.Engine) {
 	port := viper.GetString("port")
 	if port == "" {
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"franchise-saas-backend/internal/database"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+)
+
+const superadminUsage = "usage: server superadmin <email>"
+
+// runSuperadmin implements the "superadmin" subcommand, which promotes an
+// existing user to platform administrator
+func runSuperadmin(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, superadminUsage)
+		os.Exit(2)
+	}
+
+	db, err := database.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	users := repository.NewPostgresStore(db).Users()
+
+	user, err := users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(args[0])))
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", args[0], err)
+	}
+
+	if user.Role == models.RoleSuperadmin {
+		fmt.Printf("%s is already a superadmin\n", user.Email)
+		return
+	}
+
+	// The role change bumps the token version, so the user has to sign in again
+	user.Role = models.RoleSuperadmin
+	if err := users.Update(ctx, user); err != nil {
+		log.Fatalf("Failed to update user: %v", err)
+	}
+
+	fmt.Printf("%s is now a superadmin\n", user.Email)
+}
@@ -14,7 +14,7 @@ type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	JWTExpires  time.Duration
-	RedisURL    string
+	RedisURL    string // пустое значение — лимиты входа считаются в памяти процесса
 	Debug       bool
 	
 	DBHost     string
@@ -56,10 +56,6 @@ func LoadConfig() *Config {
 		config.JWTSecret = "default_secret_key_for_development"
 	}
 	
-	if config.RedisURL == "" {
-		config.RedisURL = "redis://localhost:6379"
-	}
-	
 	if config.DBHost == "" {
 		config.DBHost = "localhost"
 	}
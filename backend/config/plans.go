@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// LoadPlans читает каталог тарифов из ключа plans конфигурации. Ключ задаётся
+// в config.yaml или JSON-строкой в FRANCHISE_PLANS; без него действует
+// встроенный каталог. Заданный каталог заменяет встроенный целиком и должен
+// содержать тариф start, который получают новые сети.
+func LoadPlans() (map[string]models.Plan, error) {
+	raw := viper.Get("plans")
+	if raw == nil {
+		return models.DefaultPlans(), nil
+	}
+
+	// Значение из переменной окружения приходит строкой, из файла — картой
+	data, ok := raw.(string)
+	if !ok {
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plans: %w", err)
+		}
+		data = string(encoded)
+	}
+
+	plans := map[string]models.Plan{}
+	if err := json.Unmarshal([]byte(data), &plans); err != nil {
+		return nil, fmt.Errorf("invalid plans: %w", err)
+	}
+
+	if _, ok := plans[models.PlanStart]; !ok {
+		return nil, fmt.Errorf("invalid plans: plan %q is required", models.PlanStart)
+	}
+	for name, plan := range plans {
+		limits := plan.Limits
+		if limits.MaxDealers <= 0 || limits.MaxUsers <= 0 || limits.StorageGB <= 0 || limits.APICallsPerMonth <= 0 {
+			return nil, fmt.Errorf("invalid plans: every limit of plan %q must be positive", name)
+		}
+	}
+
+	return plans, nil
+}
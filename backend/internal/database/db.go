@@ -38,6 +38,21 @@ func ConnectDB() (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+// BypassesRowLevelSecurity reports whether the connected role is a superuser
+// or has BYPASSRLS. Row-level security policies do not apply to such a role,
+// so tenant isolation then rests on the application alone.
+func BypassesRowLevelSecurity(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	var bypasses bool
+	err := pool.QueryRow(ctx,
+		`SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`,
+	).Scan(&bypasses)
+	if err != nil {
+		return false, fmt.Errorf("failed to check database role: %w", err)
+	}
+
+	return bypasses, nil
+}
+
 // CloseDB closes the database connection pool
 func CloseDB(pool *pgxpool.Pool) {
 	if pool != nil {
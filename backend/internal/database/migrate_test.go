@@ -0,0 +1,317 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"franchise-saas-backend/migrations"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDatabaseURL names the variable holding the DSN of a PostgreSQL
+// database the migrator tests may create schemas in
+const testDatabaseURL = "FRANCHISE_TEST_DATABASE_URL"
+
+func TestParseMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantUp   string
+		wantDown string
+		wantErr  bool
+	}{
+		{
+			name:     "up and down",
+			content:  "-- +goose Up\nCREATE TABLE a (id INT);\n\n-- +goose Down\nDROP TABLE a;\n",
+			wantUp:   "CREATE TABLE a (id INT);\n\n",
+			wantDown: "DROP TABLE a;\n",
+		},
+		{
+			name:    "up only",
+			content: "-- +goose Up\nCREATE TABLE a (id INT);\n",
+			wantUp:  "CREATE TABLE a (id INT);\n",
+		},
+		{
+			name:     "text before the up section is ignored",
+			content:  "-- Creates a\n-- +goose Up\nCREATE TABLE a (id INT);\n-- +goose Down\nDROP TABLE a;\n",
+			wantUp:   "CREATE TABLE a (id INT);\n",
+			wantDown: "DROP TABLE a;\n",
+		},
+		{
+			name: "statement markers are dropped",
+			content: "-- +goose Up\n-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS INT AS $$ SELECT 1; $$ LANGUAGE sql;\n" +
+				"-- +goose StatementEnd\n-- +goose Down\nDROP FUNCTION f;\n",
+			wantUp:   "CREATE FUNCTION f() RETURNS INT AS $$ SELECT 1; $$ LANGUAGE sql;\n",
+			wantDown: "DROP FUNCTION f;\n",
+		},
+		{
+			name:     "indented annotations",
+			content:  "  -- +goose Up\nSELECT 1;\n\t-- +goose Down\nSELECT 2;\n",
+			wantUp:   "SELECT 1;\n",
+			wantDown: "SELECT 2;\n",
+		},
+		{
+			name:     "down before up",
+			content:  "-- +goose Down\nDROP TABLE a;\n-- +goose Up\nCREATE TABLE a (id INT);\n",
+			wantUp:   "CREATE TABLE a (id INT);\n",
+			wantDown: "DROP TABLE a;\n",
+		},
+		{
+			name:    "no up section",
+			content: "CREATE TABLE a (id INT);\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty up section",
+			content: "-- +goose Up\n\n-- +goose Down\nDROP TABLE a;\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down, err := parseMigration(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parse: got up %q, want an error", up)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if up != tt.wantUp || down != tt.wantDown {
+				t.Fatalf("parse: got up %q and down %q, want %q and %q", up, down, tt.wantUp, tt.wantDown)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	section := func(sql string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("-- +goose Up\n" + sql + "\n-- +goose Down\nSELECT 1;\n")}
+	}
+
+	t.Run("sorted by numeric version", func(t *testing.T) {
+		loaded, err := loadMigrations(fstest.MapFS{
+			"10_third.sql":  section("SELECT 10;"),
+			"2_second.sql":  section("SELECT 2;"),
+			"001_first.sql": section("SELECT 1;"),
+			"README.md":     &fstest.MapFile{Data: []byte("not a migration")},
+		})
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+
+		var got []string
+		for _, migration := range loaded {
+			got = append(got, migration.Name)
+		}
+		if strings.Join(got, ",") != "first,second,third" {
+			t.Fatalf("order: got %v", got)
+		}
+		if loaded[2].Version != 10 || !strings.Contains(loaded[2].Up, "SELECT 10;") {
+			t.Fatalf("third migration: got %+v", loaded[2])
+		}
+	})
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"duplicate version": {"1_a.sql": section("SELECT 1;"), "001_b.sql": section("SELECT 1;")},
+		"invalid file name": {"first.sql": section("SELECT 1;")},
+		"no up section":     {"1_a.sql": &fstest.MapFile{Data: []byte("SELECT 1;\n")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMigrations(fsys); err == nil {
+				t.Fatal("load: got no error")
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrations checks the shipped migrations: they parse, have
+// consecutive versions and can each be reverted
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := loadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	for i, migration := range loaded {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s: got version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d_%s has no down section", migration.Version, migration.Name)
+		}
+	}
+}
+
+// testSchemaPool connects to the test database with a fresh schema first on
+// the search path, so that the migrator's tables stay out of the way of other
+// tests sharing the database
+func testSchemaPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseURL)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURL)
+	}
+
+	ctx := context.Background()
+	schema := "migrate_test_" + uuid.NewString()[:8]
+
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse DSN: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func TestMigratorUpDownAndRerun(t *testing.T) {
+	pool := testSchemaPool(t)
+	ctx := context.Background()
+
+	// The third migration needs both tables, so it fails unless the versions
+	// are applied in numeric order
+	fsys := fstest.MapFS{
+		"1_create_a.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE a (id INT);\n-- +goose Down\nDROP TABLE a;\n")},
+		"2_create_b.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE b (id INT);\n-- +goose Down\nDROP TABLE b;\n")},
+		"10_copy_a.sql":   {Data: []byte("-- +goose Up\nINSERT INTO a VALUES (1);\nINSERT INTO b SELECT id FROM a;\n-- +goose Down\nDELETE FROM b;\nDELETE FROM a;\n")},
+		"not_applied.txt": {Data: []byte("ignored")},
+	}
+
+	migrator, err := NewMigrator(pool, fsys)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	versions := func(list []Migration) []int64 {
+		result := []int64{}
+		for _, migration := range list {
+			result = append(result, migration.Version)
+		}
+		return result
+	}
+	count := func(table string) int {
+		var n int
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		return n
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if got := versions(applied); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 10 {
+		t.Fatalf("applied: got %v, want [1 2 10]", got)
+	}
+
+	// Running again changes nothing
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("second up applied %v", versions(applied))
+	}
+	if count("b") != 1 {
+		t.Fatalf("rows in b after two runs: got %d, want 1", count("b"))
+	}
+
+	reverted, err := migrator.Down(ctx)
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if reverted.Version != 10 || count("b") != 0 {
+		t.Fatalf("down: reverted %d, %d rows left in b", reverted.Version, count("b"))
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, status := range statuses {
+		if (status.AppliedAt != nil) != (status.Version != 10) {
+			t.Errorf("status of %d: applied at %v", status.Version, status.AppliedAt)
+		}
+	}
+
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+	if got := versions(applied); len(got) != 1 || got[0] != 10 {
+		t.Fatalf("up after down: got %v, want [10]", got)
+	}
+
+	redone, err := migrator.Redo(ctx)
+	if err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if redone.Version != 10 || count("b") != 1 {
+		t.Fatalf("redo: redid %d, %d rows in b", redone.Version, count("b"))
+	}
+}
+
+func TestMigratorRollsBackAFailedMigration(t *testing.T) {
+	pool := testSchemaPool(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(pool, fstest.MapFS{
+		"1_create_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INT);\n-- +goose Down\nDROP TABLE a;\n")},
+		"2_broken.sql":   {Data: []byte("-- +goose Up\nCREATE TABLE c (id INT);\nSELECT missing FROM a;\n-- +goose Down\nDROP TABLE c;\n")},
+	})
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "2_broken") {
+		t.Fatalf("up: got %v, want the failure of 2_broken", err)
+	}
+
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass('c') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatalf("check table: %v", err)
+	}
+	if exists {
+		t.Fatal("the failed migration left its table behind")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Fatalf("statuses: first applied %v, second applied %v", statuses[0].AppliedAt, statuses[1].AppliedAt)
+	}
+}
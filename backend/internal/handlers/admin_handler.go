@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminHandler serves the superadmin support tools: impersonation and the audit log
+type AdminHandler struct {
+	auth  *services.AuthService
+	audit *services.AuditService
+}
+
+func NewAdminHandler(auth *services.AuthService, audit *services.AuditService) *AdminHandler {
+	return &AdminHandler{
+		auth:  auth,
+		audit: audit,
+	}
+}
+
+// Impersonate issues a short-lived token acting as another user
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	var req models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.auth.Impersonate(c.Request.Context(), c.GetString("userID"), req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The user does not exist",
+			})
+		case errors.Is(err, services.ErrImpersonationForbidden):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Impersonation not allowed",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrUserInactive):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Account deactivated",
+				Message: "The user account has been deactivated",
+			})
+		case errors.Is(err, services.ErrTenantSuspended):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Tenant suspended",
+				Message: "The user's franchise network is suspended",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Impersonation failed",
+				Message: "Internal server error",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListAuditLog returns a page of the audit log, optionally for one actor or tenant
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := models.AuditFilter{
+		ActorID:  c.Query("actor_id"),
+		TenantID: c.Query("tenant_id"),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+
+	for _, id := range []string{filter.ActorID, filter.TenantID} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid filter",
+				Message: "actor_id and tenant_id must be UUIDs",
+			})
+			return
+		}
+	}
+
+	entries, err := h.audit.ListAuditLog(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list audit log",
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
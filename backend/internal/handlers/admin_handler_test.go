@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/tokens"
+
+	"github.com/google/uuid"
+)
+
+// tokenClaims decodes the claims of a token without verifying it
+func tokenClaims(t *testing.T, token string) tokens.Claims {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+
+	var claims tokens.Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("decode claims: %v", err)
+	}
+	return claims
+}
+
+// auditLog returns the audit entries of the tenant
+func (s *testServer) auditLog(adminToken, tenantID string) []models.AuditEntry {
+	s.t.Helper()
+
+	var entries []models.AuditEntry
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/admin/audit-log?tenant_id="+tenantID, adminToken, nil, &entries)
+	return entries
+}
+
+func TestOnlySuperadminsActInAnotherTenant(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("owner@example.com")
+	other := s.register("other@example.com")
+	tenantID := owner.User.TenantID
+	_, dealer := s.addUser(tenantID, models.RoleDealer)
+	admin, adminLogin := s.addUser(s.register("platform@example.com").User.TenantID, models.RoleSuperadmin)
+
+	inTenant := func(id string) map[string]string { return map[string]string{"X-Tenant-ID": id} }
+
+	tests := []struct {
+		name   string
+		token  string
+		tenant string
+		status int
+	}{
+		{name: "franchiser of another tenant", token: other.Token, tenant: tenantID, status: http.StatusForbidden},
+		{name: "dealer", token: dealer.Token, tenant: other.User.TenantID, status: http.StatusForbidden},
+		{name: "own tenant", token: owner.Token, tenant: tenantID, status: http.StatusOK},
+		{name: "superadmin", token: adminLogin.Token, tenant: tenantID, status: http.StatusOK},
+		{name: "superadmin in an unknown tenant", token: adminLogin.Token, tenant: uuid.NewString(), status: http.StatusNotFound},
+		{name: "superadmin with a malformed tenant", token: adminLogin.Token, tenant: "not-a-uuid", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.doWithHeaders(http.MethodGet, "/api/v1/tenant", tt.token, inTenant(tt.tenant), nil)
+			if w.Code != tt.status {
+				t.Fatalf("GET /tenant: got %d %s, want %d", w.Code, w.Body, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var tenant models.Tenant
+			if err := json.Unmarshal(w.Body.Bytes(), &tenant); err != nil {
+				t.Fatalf("decode tenant: %v", err)
+			}
+			if tenant.ID != tt.tenant {
+				t.Fatalf("tenant: got %s, want %s", tenant.ID, tt.tenant)
+			}
+		})
+	}
+
+	// Only the superadmin's request into the tenant is audited, not the
+	// tenant's own ones
+	entries := s.auditLog(adminLogin.Token, tenantID)
+	if len(entries) != 1 {
+		t.Fatalf("audit entries: got %+v, want 1", entries)
+	}
+	entry := entries[0]
+	if entry.Action != models.AuditActionRequest || entry.ActorID != admin.ID || entry.UserID != admin.ID ||
+		entry.Method != http.MethodGet || entry.Path != "/api/v1/tenant" || entry.Status != http.StatusOK {
+		t.Fatalf("audit entry: got %+v", entry)
+	}
+
+	// The audit log itself is for superadmins only
+	s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/admin/audit-log", owner.Token, nil, nil)
+}
+
+func TestImpersonation(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("owner@example.com")
+	tenantID := owner.User.TenantID
+	dealer, _ := s.addUser(tenantID, models.RoleDealer)
+	admin, adminLogin := s.addUser(s.register("platform@example.com").User.TenantID, models.RoleSuperadmin)
+	otherAdmin, _ := s.addUser(admin.TenantID, models.RoleSuperadmin)
+
+	for _, refused := range []struct{ token, userID string }{
+		{owner.Token, dealer.ID},          // not a superadmin
+		{adminLogin.Token, otherAdmin.ID}, // superadmins cannot be impersonated
+		{adminLogin.Token, admin.ID},
+	} {
+		s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/admin/impersonate", refused.token, map[string]any{
+			"user_id": refused.userID,
+			"reason":  "Support ticket 42",
+		}, nil)
+	}
+
+	var response models.ImpersonationResponse
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/admin/impersonate", adminLogin.Token, map[string]any{
+		"user_id": dealer.ID,
+		"reason":  "Support ticket 42",
+	}, &response)
+
+	claims := tokenClaims(t, response.Token)
+	if claims.UserID != dealer.ID || claims.TenantID != tenantID || claims.SessionID != "" {
+		t.Fatalf("impersonation claims: got %+v", claims)
+	}
+	if claims.Actor == nil || claims.Actor.UserID != admin.ID || claims.Actor.Email != admin.Email {
+		t.Fatalf("act claim: got %+v, want the superadmin", claims.Actor)
+	}
+
+	var me models.User
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", response.Token, nil, &me)
+	if me.ID != dealer.ID {
+		t.Fatalf("me while impersonating: got %s, want %s", me.ID, dealer.ID)
+	}
+
+	// Credentials and sign-in settings stay with the user
+	s.expect(http.StatusForbidden, http.MethodPut, "/api/v1/users/password", response.Token, map[string]any{
+		"current_password": testPassword,
+		"new_password":     "N3wSecurePass",
+	}, nil)
+	s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/auth/2fa/setup", response.Token, nil, nil)
+
+	entries := s.auditLog(adminLogin.Token, tenantID)
+	actions := map[string]int{}
+	for _, entry := range entries {
+		if entry.ActorID != admin.ID || entry.UserID != dealer.ID {
+			t.Fatalf("audit entry of another actor or user: %+v", entry)
+		}
+		actions[entry.Action+" "+entry.Method+" "+entry.Path]++
+		if entry.Action == models.AuditActionImpersonationStarted && entry.Reason != "Support ticket 42" {
+			t.Errorf("impersonation reason: got %q", entry.Reason)
+		}
+	}
+	for _, want := range []string{
+		models.AuditActionImpersonationStarted + "  ",
+		models.AuditActionRequest + " GET /api/v1/auth/me",
+		models.AuditActionRequest + " PUT /api/v1/users/password",
+		models.AuditActionRequest + " POST /api/v1/auth/2fa/setup",
+	} {
+		if actions[want] != 1 {
+			t.Errorf("audit entries %q: got %d, want 1 in %v", want, actions[want], actions)
+		}
+	}
+
+	// A password change of the superadmin ends the impersonation too
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/users/password", adminLogin.Token, map[string]any{
+		"current_password": testPassword,
+		"new_password":     "N3wSecurePass",
+	}, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", response.Token, nil, nil)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler serves the API keys of the caller's tenant
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+// ListAPIKeys returns the keys of the tenant without their secrets
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.service.ListAPIKeys(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey issues a key; the response carries the only copy of it
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.GetString("role"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey stops a key from working
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	if err := h.service.RevokeAPIKey(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "API key revoked",
+	})
+}
+
+func (h *APIKeyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "API key not found",
+			Message: "The requested API key does not exist or is already revoked",
+		})
+	case errors.Is(err, services.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid API key",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrScopeNotHeld):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Insufficient permissions",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "API key request failed",
+			Message: "Internal server error",
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestAPIKeyScopesAreLimitedToCreatorPermissions(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", franchiser.Token, map[string]any{
+		"name":        "integrator",
+		"permissions": []string{models.PermissionManageAPIKeys, models.PermissionViewAllDealers},
+	}, nil)
+	_, integrator := s.addUser(franchiser.User.TenantID, "integrator")
+
+	s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/api-keys", integrator.Token, map[string]any{
+		"name":   "Website",
+		"scopes": []string{models.PermissionViewAllDealers, models.PermissionManageTenant},
+	}, nil)
+
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/api-keys", integrator.Token, map[string]any{
+		"name":   "Website",
+		"scopes": []string{models.PermissionViewAllDealers},
+	}, nil)
+
+	// The franchiser holds every permission a key can have
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/api-keys", franchiser.Token, map[string]any{
+		"name":   "Back office",
+		"scopes": []string{models.PermissionManageTenant},
+	}, nil)
+}
@@ -1,96 +0,0 @@
-package handlers
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"franchise-saas-backend/internal/services"
-	"franchise-saas-backend/internal/models"
-)
-
-// AuthHandler предоставляет методы для обработки аутентификационных запросов
-type AuthHandler struct {
-	authService *services.AuthService
-}
-
-// NewAuthHandler создает новый экземпляр AuthHandler
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{
-		authService: authService,
-	}
-}
-
-// Register handles user registration
-func (h *AuthHandler) Register(c *gin.Context) {
-	var req models.UserRegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
-		return
-	}
-
-	ctx := c.Request.Context()
-	result, err := h.authService.Register(ctx, &req)
-	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"data":    result,
-	})
-}
-
-// Login handles user login
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req models.UserLoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
-		return
-	}
-
-	ctx := c.Request.Context()
-	result, err := h.authService.Login(ctx, &req)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    result,
-	})
-}
-
-// Logout handles user logout
-func (h *AuthHandler) Logout(c *gin.Context) {
-	// TODO: Implement logout logic (invalidate refresh token, etc.)
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Logged out successfully",
-	})
-}
-
-// RefreshToken handles token refresh
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
-		return
-	}
-
-	ctx := c.Request.Context()
-	result, err := h.authService.RefreshToken(ctx, req.RefreshToken)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    result,
-	})
-}
\ No newline at end of file
@@ -1,21 +1,24 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
 
+	"franchise-saas-backend/internal/mailer"
 	"franchise-saas-backend/internal/models"
 	"franchise-saas-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// AuthHandler handles registration, login and token requests
 type AuthHandler struct {
 	service *services.AuthService
 }
 
+// NewAuthHandler creates a new AuthHandler
 func NewAuthHandler(service *services.AuthService) *AuthHandler {
 	return &AuthHandler{
 		service: service,
@@ -26,219 +29,354 @@ func NewAuthHandler(service *services.AuthService) *AuthHandler {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.UserRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"message": err.Error(),
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	// Validate password strength
-	if len(req.Password) < 8 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Password too weak",
-			"message": "Password must be at least 8 characters long",
-		})
-		return
-	}
-
-	// Check if user already exists
-	existingUser, err := h.service.GetUserByEmail(req.Email)
+	result, err := h.service.Register(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": "Could not check user existence",
-		})
+		switch {
+		case errors.Is(err, services.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Password too weak",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrInvalidSlug):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid tenant",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrSlugTaken):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Slug already taken",
+				Message: "Another franchise network already uses this slug",
+			})
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "User already exists",
+				Message: "A user with this email already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "User creation failed",
+				Message: "Internal server error",
+			})
+		}
 		return
 	}
 
-	if existingUser != nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":   "User already exists",
-			"message": "A user with this email already exists",
+	c.JSON(http.StatusCreated, result)
+}
+
+// Login handles user login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.UserLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	result, challenge, err := h.service.Login(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Password hashing failed",
-			"message": "Internal server error",
-		})
+		switch {
+		case errors.Is(err, services.ErrTooManyAttempts):
+			tooManyAttempts(c, err, "Too many failed login attempts, try again later")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid credentials",
+				Message: "Invalid email or password",
+			})
+		case errors.Is(err, services.ErrUserInactive):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Account deactivated",
+				Message: "This account has been deactivated",
+			})
+		case errors.Is(err, services.ErrTenantSuspended):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Tenant suspended",
+				Message: "This franchise network has been suspended, please contact support",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Login failed",
+				Message: "Internal server error",
+			})
+		}
 		return
 	}
 
-	// Create user
-	user := &models.User{
-		ID:        uuid.New().String(),
-		Email:     req.Email,
-		Password:  string(hashedPassword),
-		Role:      req.Role,
-		TenantID:  req.TenantID,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Phone:     req.Phone,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
 	}
 
-	createdUser, err := h.service.CreateUser(user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "User creation failed",
-			"message": err.Error(),
+	c.JSON(http.StatusOK, result)
+}
+
+// Logout revokes the session of the current access token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.service.Logout(c.Request.Context(), c.GetString("userID"), c.GetString("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Message: "Could not revoke the session",
 		})
 		return
 	}
 
-	// Generate JWT tokens
-	token, refreshToken, err := h.service.GenerateTokens(createdUser.ID, createdUser.Email, createdUser.Role, createdUser.TenantID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Token generation failed",
-			"message": err.Error(),
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Successfully logged out",
+	})
+}
+
+// LogoutAll revokes every session of the current user
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.service.LogoutAll(c.Request.Context(), c.GetString("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Message: "Could not revoke sessions",
 		})
 		return
 	}
 
-	// Don't return password in response
-	createdUser.Password = ""
-
-	c.JSON(http.StatusCreated, models.AuthResponse{
-		User:         *createdUser,
-		Token:        token,
-		RefreshToken: refreshToken,
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Successfully logged out from all devices",
 	})
 }
 
-// Login handles user login
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req models.UserLoginRequest
+// RefreshToken handles token refresh
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"message": err.Error(),
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	user, err := h.service.GetUserByEmail(req.Email)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": "Could not retrieve user",
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Missing refresh token",
+			Message: "Refresh token is required",
 		})
 		return
 	}
 
-	if user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Invalid credentials",
-			"message": "Invalid email or password",
-		})
+	result, err := h.service.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserInactive):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Account deactivated",
+				Message: "This account has been deactivated",
+			})
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Refresh token reused",
+				Message: "This refresh token was already used; the session has been revoked, please log in again",
+			})
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid refresh token",
+				Message: "The refresh token is invalid or expired",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Token refresh failed",
+				Message: "Internal server error",
+			})
+		}
 		return
 	}
 
-	// Compare passwords
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Invalid credentials",
-			"message": "Invalid email or password",
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCurrentUser returns current user info
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "User not authenticated",
 		})
 		return
 	}
 
-	// Generate JWT tokens
-	token, refreshToken, err := h.service.GenerateTokens(user.ID, user.Email, user.Role, user.TenantID)
+	user, err := h.service.GetUserByID(c.Request.Context(), userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Token generation failed",
-			"message": err.Error(),
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The user does not exist",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve user",
+			Message: "Internal server error",
 		})
 		return
 	}
 
-	// Don't return password in response
 	user.Password = ""
+	c.JSON(http.StatusOK, user)
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the email is registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email, clientInfo(c)); err != nil {
+		if errors.Is(err, services.ErrTooManyAttempts) {
+			tooManyAttempts(c, err, "Too many password reset requests, try again later")
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Password reset failed",
+			Message: "Internal server error",
+		})
+		return
+	}
 
-	c.JSON(http.StatusOK, models.AuthResponse{
-		User:         *user,
-		Token:        token,
-		RefreshToken: refreshToken,
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "If an account with this email exists, a password reset link has been sent",
 	})
 }
 
-// Logout handles user logout
-func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a real application, you would invalidate the refresh token here
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" {
-		// Add token to blacklist or mark as invalid
-		_ = h.service.InvalidateToken(authHeader)
+// ResetPassword sets a new password using the emailed token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Successfully logged out",
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Password too weak",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrInvalidUserToken):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid token",
+				Message: "The password reset link is invalid or expired",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Password reset failed",
+				Message: "Internal server error",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Password has been reset, please log in with the new password",
 	})
 }
 
-// RefreshToken handles token refresh
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	var req models.RefreshTokenRequest
+// VerifyEmail confirms the email address using the emailed token
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"message": err.Error(),
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	if req.RefreshToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Missing refresh token",
-			"message": "Refresh token is required",
+	if err := h.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid token",
+				Message: "The verification link is invalid or expired",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Email verification failed",
+			Message: "Internal server error",
 		})
 		return
 	}
 
-	// Validate refresh token
-	newToken, newRefreshToken, err := h.service.RefreshTokens(req.RefreshToken)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Email verified successfully",
+	})
+}
+
+// ResendEmailVerification sends a new verification link to the current user
+func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
+	err := h.service.ResendEmailVerification(c.Request.Context(), c.GetString("userID"), clientInfo(c).Language)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Invalid refresh token",
-			"message": err.Error(),
-		})
+		switch {
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Email already verified",
+				Message: "The email address is already verified",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "The user does not exist",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Email verification failed",
+				Message: "Internal server error",
+			})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, models.TokenResponse{
-		Token:        newToken,
-		RefreshToken: newRefreshToken,
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Verification email sent",
 	})
 }
 
-// GetCurrentUser returns current user info
-func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Unauthorized",
-			"message": "User not authenticated",
-		})
-		return
+// clientInfo extracts the client address, user agent and preferred language
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Language:     mailer.MatchLanguage(c.GetHeader("Accept-Language")),
+		HostTenantID: c.GetString("hostTenantID"),
 	}
+}
 
-	user, err := h.service.GetUserByID(userID.(string))
-	if err != nil || user == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "User not found",
-			"message": "The user does not exist",
-		})
-		return
+// tooManyAttempts responds 429 with a Retry-After header in whole seconds
+func tooManyAttempts(c *gin.Context, err error, message string) {
+	var throttled *services.ThrottledError
+	if errors.As(err, &throttled) {
+		seconds := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
 	}
 
-	user.Password = ""
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Error:   "Too many attempts",
+		Message: message,
+	})
 }
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestServer(t)
+
+	registered := s.register("owner@example.com")
+	if registered.User.Role != models.RoleFranchiser || registered.Tenant == nil {
+		t.Fatalf("register: got role %q and tenant %v", registered.User.Role, registered.Tenant)
+	}
+
+	var me models.User
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", registered.Token, nil, &me)
+	if me.ID != registered.User.ID || me.Password != "" {
+		t.Errorf("me: got %+v", me)
+	}
+
+	s.expect(http.StatusConflict, http.MethodPost, "/api/v1/auth/register", "", map[string]any{
+		"email":       "OWNER@example.com",
+		"password":    testPassword,
+		"tenant_name": "Another network",
+	}, nil)
+
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", "", map[string]any{
+		"email":    "owner@example.com",
+		"password": "Wr0ngPassword",
+	}, nil)
+	s.login("owner@example.com")
+
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", "", nil, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", "not-a-token", nil, nil)
+}
+
+func TestRegisterIgnoresPlan(t *testing.T) {
+	s := newTestServer(t)
+
+	var registered models.AuthResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/register", "", map[string]any{
+		"email":       "owner@example.com",
+		"password":    testPassword,
+		"tenant_name": "Network",
+		"plan":        models.PlanEnterprise,
+	}, &registered)
+	if registered.Tenant.Plan != models.PlanStart {
+		t.Fatalf("plan: got %q, want %q", registered.Tenant.Plan, models.PlanStart)
+	}
+
+	settings, err := registered.Tenant.ParseSettings()
+	if err != nil {
+		t.Fatalf("parse settings: %v", err)
+	}
+	if want := models.DefaultTenantSettings(models.PlanStart, "Network").Limits; settings.Limits != want {
+		t.Errorf("limits: got %+v, want %+v", settings.Limits, want)
+	}
+}
+
+func TestRegisterCannotJoinAnExistingTenant(t *testing.T) {
+	s := newTestServer(t)
+	existing := s.register("owner@example.com")
+
+	// Only invitations lead into an existing network; register always
+	// starts a new one owned by the new franchiser
+	var registered models.AuthResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/register", "", map[string]any{
+		"email":       "intruder@example.com",
+		"password":    testPassword,
+		"tenant_name": "Network",
+		"tenant_id":   existing.User.TenantID,
+		"role":        models.RoleDealer,
+	}, &registered)
+	if registered.User.Role != models.RoleFranchiser {
+		t.Errorf("role: got %q, want %q", registered.User.Role, models.RoleFranchiser)
+	}
+	if registered.User.TenantID == existing.User.TenantID || registered.Tenant.ID != registered.User.TenantID {
+		t.Errorf("tenant: got user in %s and tenant %s, existing tenant is %s",
+			registered.User.TenantID, registered.Tenant.ID, existing.User.TenantID)
+	}
+
+	users, err := s.store.Users().ListByTenant(context.Background(), existing.User.TenantID, "")
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	if len(users) != 1 {
+		t.Errorf("users of the existing tenant: got %d, want 1", len(users))
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s := newTestServer(t)
+	registered := s.register("owner@example.com")
+
+	var refreshed models.TokenResponse
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": registered.RefreshToken,
+	}, &refreshed)
+	if refreshed.RefreshToken == registered.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+
+	// Presenting the rotated token again ends the whole login
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": registered.RefreshToken,
+	}, nil)
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": refreshed.RefreshToken,
+	}, nil)
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestServer(t)
+	registered := s.register("owner@example.com")
+	other := s.login("owner@example.com")
+
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", registered.Token, nil, nil)
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": registered.RefreshToken,
+	}, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", registered.Token, nil, nil)
+
+	var sessions []models.ActiveSession
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/sessions", other.Token, nil, &sessions)
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("sessions after logout: got %+v", sessions)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	s := newTestServer(t)
+	registered := s.register("owner@example.com")
+	other := s.login("owner@example.com")
+
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout-all", registered.Token, nil, nil)
+	for _, token := range []string{registered.Token, other.Token} {
+		s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", token, nil, nil)
+	}
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": other.RefreshToken,
+	}, nil)
+
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", s.login("owner@example.com").Token, nil, nil)
+}
+
+func TestRefreshTokenReuseEndsAccessTokens(t *testing.T) {
+	s := newTestServer(t)
+	registered := s.register("owner@example.com")
+
+	var refreshed models.TokenResponse
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": registered.RefreshToken,
+	}, &refreshed)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", refreshed.Token, nil, nil)
+
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": registered.RefreshToken,
+	}, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", refreshed.Token, nil, nil)
+}
+
+func TestForgotPasswordIsThrottled(t *testing.T) {
+	for key, value := range map[string]int{
+		"password_reset_free_attempts":         2,
+		"password_reset_base_delay_seconds":    60,
+		"password_reset_window_minutes":        60,
+		"password_reset_ip_free_attempts":      4,
+		"password_reset_ip_base_delay_seconds": 60,
+		"password_reset_ip_window_minutes":     60,
+	} {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	s := newTestServer(t)
+	s.register("owner@example.com")
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		return s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", map[string]any{"email": email})
+	}
+
+	for range 3 {
+		if w := forgot("owner@example.com"); w.Code != http.StatusOK {
+			t.Fatalf("forgot password: got %d %s", w.Code, w.Body)
+		}
+	}
+	w := forgot("OWNER@example.com")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("forgot password beyond the email limit: got %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Unknown emails count the same, so the limit reveals nothing
+	for range 2 {
+		if w := forgot("nobody@example.com"); w.Code != http.StatusOK {
+			t.Fatalf("forgot password of an unknown email: got %d", w.Code)
+		}
+	}
+	if w := forgot("someone@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("forgot password beyond the IP limit: got %d, want 429", w.Code)
+	}
+}
+
+func TestStaleTokenVersionIsRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *testServer, user *models.User)
+	}{
+		{
+			name: "password change",
+			change: func(s *testServer, user *models.User) {
+				other := s.login(user.Email)
+				s.expect(http.StatusOK, http.MethodPut, "/api/v1/users/password", other.Token, map[string]any{
+					"current_password": testPassword,
+					"new_password":     "N3wSecurePass",
+				}, nil)
+			},
+		},
+		{
+			name: "role change",
+			change: func(s *testServer, user *models.User) {
+				user.Role = models.RoleManager
+				if err := s.store.Users().Update(context.Background(), user); err != nil {
+					s.t.Fatalf("update user: %v", err)
+				}
+			},
+		},
+		{
+			name: "deactivation",
+			change: func(s *testServer, user *models.User) {
+				user.IsActive = false
+				if err := s.store.Users().Update(context.Background(), user); err != nil {
+					s.t.Fatalf("update user: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			franchiser := s.register("owner@example.com")
+			user, login := s.addUser(franchiser.User.TenantID, models.RoleDealer)
+			s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", login.Token, nil, nil)
+
+			tt.change(s, user)
+
+			// The token still verifies, but its ver claim is behind the user's
+			w := s.do(http.MethodGet, "/api/v1/auth/me", login.Token, nil)
+			if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "revoked") {
+				t.Fatalf("me with a stale token: got %d %s, want 401 revoked", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"franchise-saas-backend/internal/middleware"
 	"franchise-saas-backend/internal/models"
 	"franchise-saas-backend/internal/services"
 
@@ -21,7 +23,8 @@ func NewChecklistHandler(service *services.ChecklistService) *ChecklistHandler {
 	}
 }
 
-// GetChecklists retrieves all checklists for the authenticated user
+// GetChecklists retrieves all checklists for the authenticated user, or with
+// dealer_id those of a dealer the reviewer oversees
 func (h *ChecklistHandler) GetChecklists(c *gin.Context) {
 	// Extract user ID from context (set by middleware)
 	userID, exists := c.Get("userID")
@@ -48,7 +51,12 @@ func (h *ChecklistHandler) GetChecklists(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	checklists, err := h.service.GetChecklistsByUserID(userID.(string), limit, offset)
+	if dealerID := c.Query("dealer_id"); dealerID != "" && dealerID != userID.(string) {
+		h.getDealerChecklists(c, dealerID, limit, offset)
+		return
+	}
+
+	checklists, err := h.service.GetChecklistsByUserID(c.Request.Context(), c.GetString("tenantID"), userID.(string), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to retrieve checklists",
@@ -83,8 +91,20 @@ func (h *ChecklistHandler) GetChecklistByID(c *gin.Context) {
 		return
 	}
 
-	checklist, err := h.service.GetChecklistByID(checklistID, userID.(string))
+	checklist, err := h.service.GetChecklistByID(c.Request.Context(), c.GetString("tenantID"), checklistID, userID.(string))
+	if errors.Is(err, services.ErrChecklistNotFound) && middleware.HasPermission(c, models.PermissionReviewChecklists) {
+		// Reviewers also see the checklists of the dealers they oversee
+		checklist, err = h.service.GetDealerChecklist(c.Request.Context(), c.GetString("tenantID"), dealerScope(c), checklistID)
+	}
 	if err != nil {
+		if errors.Is(err, services.ErrChecklistNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Checklist not found",
+				Message: "The requested checklist does not exist",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to retrieve checklist",
 			Message: "Could not fetch checklist data",
@@ -92,14 +112,6 @@ func (h *ChecklistHandler) GetChecklistByID(c *gin.Context) {
 		return
 	}
 
-	if checklist == nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Checklist not found",
-			Message: "The requested checklist does not exist",
-		})
-		return
-	}
-
 	c.JSON(http.StatusOK, checklist)
 }
 
@@ -124,20 +136,36 @@ func (h *ChecklistHandler) CreateChecklist(c *gin.Context) {
 		return
 	}
 
-	// Create checklist
+	tenantID, _ := c.Get("tenantID")
+	tenantIDStr, _ := tenantID.(string)
+
+	// Older clients send only created_at; use it as the checklist date
+	date := req.Date
+	if date.IsZero() {
+		date = req.CreatedAt
+	}
+
+	// Status and KPI score are derived from the tasks by the service
 	checklist := &models.Checklist{
 		ID:          uuid.New().String(),
 		Title:       req.Title,
 		Description: req.Description,
 		UserID:      userID.(string),
-		Status:      "pending",
-		CreatedAt:   req.CreatedAt,
-		UpdatedAt:   req.CreatedAt,
+		TenantID:    tenantIDStr,
+		Date:        date,
 		Tasks:       req.Tasks,
 	}
 
-	createdChecklist, err := h.service.CreateChecklist(checklist)
+	createdChecklist, err := h.service.CreateChecklist(c.Request.Context(), checklist)
 	if err != nil {
+		if errors.Is(err, services.ErrChecklistExists) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Checklist already exists",
+				Message: "A checklist for this date already exists",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to create checklist",
 			Message: "Could not create checklist",
@@ -181,9 +209,9 @@ func (h *ChecklistHandler) UpdateChecklist(c *gin.Context) {
 	}
 
 	// Update checklist
-	updatedChecklist, err := h.service.UpdateChecklist(checklistID, userID.(string), req)
+	updatedChecklist, err := h.service.UpdateChecklist(c.Request.Context(), c.GetString("tenantID"), checklistID, userID.(string), req)
 	if err != nil {
-		if err.Error() == "checklist not found" {
+		if errors.Is(err, services.ErrChecklistNotFound) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
 				Error:   "Checklist not found",
 				Message: "The requested checklist does not exist",
@@ -224,9 +252,9 @@ func (h *ChecklistHandler) DeleteChecklist(c *gin.Context) {
 		return
 	}
 
-	err := h.service.DeleteChecklist(checklistID, userID.(string))
+	err := h.service.DeleteChecklist(c.Request.Context(), c.GetString("tenantID"), checklistID, userID.(string))
 	if err != nil {
-		if err.Error() == "checklist not found" {
+		if errors.Is(err, services.ErrChecklistNotFound) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
 				Error:   "Checklist not found",
 				Message: "The requested checklist does not exist",
@@ -269,9 +297,9 @@ func (h *ChecklistHandler) CompleteChecklist(c *gin.Context) {
 		return
 	}
 
-	updatedChecklist, err := h.service.CompleteChecklist(checklistID, userID.(string))
+	updatedChecklist, err := h.service.CompleteChecklist(c.Request.Context(), c.GetString("tenantID"), checklistID, userID.(string))
 	if err != nil {
-		if err.Error() == "checklist not found" {
+		if errors.Is(err, services.ErrChecklistNotFound) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
 				Error:   "Checklist not found",
 				Message: "The requested checklist does not exist",
@@ -288,3 +316,75 @@ func (h *ChecklistHandler) CompleteChecklist(c *gin.Context) {
 
 	c.JSON(http.StatusOK, updatedChecklist)
 }
+
+// ReviewTask lets a manager verify a task of a dealer's checklist and comment on it
+func (h *ChecklistHandler) ReviewTask(c *gin.Context) {
+	var req models.TaskReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	task, err := h.service.ReviewTask(c.Request.Context(), c.GetString("tenantID"), dealerScope(c), c.GetString("userID"),
+		c.Param("id"), c.Param("taskId"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrChecklistNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Checklist not found",
+				Message: "The requested checklist does not exist",
+			})
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Task not found",
+				Message: "The checklist has no such task",
+			})
+		case errors.Is(err, services.ErrTaskNotCompleted):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Task not completed",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to review task",
+				Message: "Could not save the review",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// getDealerChecklists serves GET /checklists?dealer_id=... for reviewers
+func (h *ChecklistHandler) getDealerChecklists(c *gin.Context, dealerID string, limit, offset int) {
+	if !middleware.HasPermission(c, models.PermissionReviewChecklists) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Insufficient permissions",
+			Message: "User does not have permission: " + models.PermissionReviewChecklists,
+		})
+		return
+	}
+
+	checklists, err := h.service.GetDealerChecklists(c.Request.Context(), c.GetString("tenantID"), dealerScope(c), dealerID, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrDealerNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Dealer not found",
+				Message: "The requested dealer does not exist",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve checklists",
+			Message: "Could not fetch checklist data",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, checklists)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestChecklistLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("owner@example.com")
+	_, dealer := s.addUser(owner.User.TenantID, models.RoleDealer)
+	_, colleague := s.addUser(owner.User.TenantID, models.RoleDealer)
+
+	var created models.Checklist
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/checklists", dealer.Token, map[string]any{
+		"title": "Opening",
+		"date":  "2025-03-03T00:00:00Z",
+		"tasks": []map[string]any{{"title": "Open the doors"}, {"title": "Count the till"}},
+	}, &created)
+	if len(created.Tasks) != 2 || created.Status != "pending" {
+		t.Fatalf("create: got %+v", created)
+	}
+
+	path := "/api/v1/checklists/" + created.ID
+	s.expect(http.StatusOK, http.MethodGet, path, dealer.Token, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodGet, path, colleague.Token, nil, nil)
+	s.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/checklists/not-an-id", dealer.Token, nil, nil)
+
+	var completed models.Checklist
+	s.expect(http.StatusOK, http.MethodPost, path+"/complete", dealer.Token, nil, &completed)
+	if completed.Status != "completed" {
+		t.Errorf("complete: got status %q", completed.Status)
+	}
+
+	s.expect(http.StatusNotFound, http.MethodDelete, path, colleague.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodDelete, path, dealer.Token, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodGet, path, dealer.Token, nil, nil)
+}
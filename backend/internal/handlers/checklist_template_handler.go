@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"franchise-saas-backend/internal/middleware"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChecklistTemplateHandler serves the checklist templates of the caller's
+// tenant and the checklists made from them
+type ChecklistTemplateHandler struct {
+	service *services.ChecklistTemplateService
+}
+
+func NewChecklistTemplateHandler(service *services.ChecklistTemplateService) *ChecklistTemplateHandler {
+	return &ChecklistTemplateHandler{
+		service: service,
+	}
+}
+
+// ListTemplates returns every template to template authors and the assigned
+// ones to everybody else
+func (h *ChecklistTemplateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), manageTemplates(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate returns a template with the content of its current version
+func (h *ChecklistTemplateHandler) GetTemplate(c *gin.Context) {
+	template, err := h.service.GetTemplate(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.Param("id"), manageTemplates(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// CreateTemplate creates a template
+func (h *ChecklistTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req models.ChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate replaces the content and the assignment of a template
+func (h *ChecklistTemplateHandler) UpdateTemplate(c *gin.Context) {
+	var req models.ChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate removes a template; checklists made from it are kept
+func (h *ChecklistTemplateHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.service.DeleteTemplate(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Checklist template deleted",
+	})
+}
+
+// ListVersions returns the version history of a template
+func (h *ChecklistTemplateHandler) ListVersions(c *gin.Context) {
+	versions, err := h.service.ListVersions(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// CreateChecklist makes the caller's checklist for a day from a template; the
+// body is optional
+func (h *ChecklistTemplateHandler) CreateChecklist(c *gin.Context) {
+	var req models.ChecklistFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	checklist, err := h.service.CreateChecklist(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.Param("id"), req, manageTemplates(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, checklist)
+}
+
+func (h *ChecklistTemplateHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Checklist template not found",
+			Message: "The requested checklist template does not exist",
+		})
+	case errors.Is(err, services.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid checklist template",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrChecklistExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Checklist already exists",
+			Message: "A checklist for this date already exists",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Checklist template request failed",
+			Message: "Internal server error",
+		})
+	}
+}
+
+// manageTemplates reports whether the caller authors templates and so sees
+// and uses all of them, not only those assigned to them
+func manageTemplates(c *gin.Context) bool {
+	return middleware.HasPermission(c, models.PermissionManageTemplates)
+}
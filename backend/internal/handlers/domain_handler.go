@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DomainHandler serves the custom domains of the caller's tenant and the
+// branding of the domain a request was made to
+type DomainHandler struct {
+	service *services.DomainService
+}
+
+func NewDomainHandler(service *services.DomainService) *DomainHandler {
+	return &DomainHandler{
+		service: service,
+	}
+}
+
+// GetBranding returns the logo, colors and company name of the network whose
+// domain the request was made to; it needs no authentication
+func (h *DomainHandler) GetBranding(c *gin.Context) {
+	tenantID := c.GetString("hostTenantID")
+	if tenantID == "" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Unknown domain",
+			Message: "No franchise network has verified this domain",
+		})
+		return
+	}
+
+	branding, err := h.service.GetBranding(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
+
+// ListDomains returns the custom domains of the tenant
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	domains, err := h.service.ListDomains(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domains)
+}
+
+// AddDomain registers a custom domain together with the TXT record that verifies it
+func (h *DomainHandler) AddDomain(c *gin.Context) {
+	var req models.TenantDomainCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	domain, err := h.service.AddDomain(c.Request.Context(), c.GetString("tenantID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+// VerifyDomain checks the TXT record of a domain
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	domain, err := h.service.VerifyDomain(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain)
+}
+
+// DeleteDomain removes a custom domain
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	if err := h.service.DeleteDomain(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Domain deleted",
+	})
+}
+
+func (h *DomainHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDomainNotFound), errors.Is(err, services.ErrTenantNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Domain not found",
+			Message: "The requested domain does not exist",
+		})
+	case errors.Is(err, services.ErrInvalidDomain):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid domain",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrDomainTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Domain already taken",
+			Message: "The network has already added this domain or another network has verified it",
+		})
+	case errors.Is(err, services.ErrDomainNotVerified):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Error:   "Domain not verified",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Domain request failed",
+			Message: "Internal server error",
+		})
+	}
+}
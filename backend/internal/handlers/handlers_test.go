@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"franchise-saas-backend/internal/mailer"
+	"franchise-saas-backend/internal/middleware"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/password"
+	"franchise-saas-backend/internal/ratelimit"
+	"franchise-saas-backend/internal/repository"
+	"franchise-saas-backend/internal/services"
+	"franchise-saas-backend/internal/tokens"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// linkTokenRe finds the token of a link in an email
+var linkTokenRe = regexp.MustCompile(`[?&]token=([^\s&]+)`)
+
+// testPassword is accepted by the default password policy
+const testPassword = "Secur3Pass"
+
+var (
+	keysOnce sync.Once
+	keys     *tokens.KeySet
+	keysErr  error
+
+	// testPasswordHash is computed once, bcrypt is slow on purpose
+	testPasswordHash, _ = bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+)
+
+// testServer is the API wired as in cmd/server on top of the in-memory store
+type testServer struct {
+	t      *testing.T
+	store  *repository.MemoryStore
+	router *gin.Engine
+	mail   *testMailer
+}
+
+// testMailer keeps the messages instead of sending them
+type testMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// token returns the token of the link in the last message sent to the address
+func (m *testMailer) token(t *testing.T, to string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To != to {
+			continue
+		}
+		match := linkTokenRe.FindStringSubmatch(m.sent[i].Body)
+		if match == nil {
+			t.Fatalf("message to %s has no link: %s", to, m.sent[i].Body)
+		}
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatalf("link token: %v", err)
+		}
+		return token
+	}
+
+	t.Fatalf("no message was sent to %s", to)
+	return ""
+}
+
+// testResolver answers TXT lookups from a fixed map
+type testResolver map[string][]string
+
+func (r testResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r[name], nil
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keysOnce.Do(func() {
+		keys, keysErr = tokens.NewKeySet(tokens.Config{
+			Dir:       t.TempDir(),
+			Algorithm: tokens.AlgorithmEdDSA,
+			Retention: time.Hour,
+		})
+	})
+	if keysErr != nil {
+		t.Fatalf("signing keys: %v", keysErr)
+	}
+
+	policy, err := password.LoadPolicy()
+	if err != nil {
+		t.Fatalf("password policy: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	mail := &testMailer{}
+	counters := ratelimit.NewMemoryStore()
+	issuer := tokens.NewIssuer(keys, "franchise-saas", "franchise-saas-api", time.Hour)
+
+	authService := services.NewAuthService(store, mail, policy, services.NewLoginLimiter(counters), issuer)
+	roleService := services.NewRoleService(store)
+	invitationService := services.NewInvitationService(store, mail, roleService)
+	quotaService := services.NewQuotaService(store, counters)
+	tenantService := services.NewTenantService(store, authService, invitationService, quotaService)
+	apiKeyService := services.NewAPIKeyService(store, roleService)
+	domainService := services.NewDomainService(store, testResolver{})
+	auditService := services.NewAuditService(store)
+
+	can := func(permissions ...string) gin.HandlerFunc {
+		return middleware.PermissionMiddleware(roleService, permissions...)
+	}
+	feature := func(name string) gin.HandlerFunc {
+		return middleware.FeatureMiddleware(tenantService, name)
+	}
+
+	r := gin.New()
+	r.Use(middleware.HostMiddleware(domainService))
+
+	SetupRoutes(r, Handlers{
+		Auth:       NewAuthHandler(authService),
+		User:       NewUserHandler(services.NewUserService(store, authService, policy, roleService)),
+		Checklist:  NewChecklistHandler(services.NewChecklistService(store, roleService)),
+		Template:   NewChecklistTemplateHandler(services.NewChecklistTemplateService(store, roleService)),
+		Session:    NewSessionHandler(services.NewSessionService(store, authService, roleService)),
+		JWKS:       NewJWKSHandler(keys),
+		Invitation: NewInvitationHandler(invitationService),
+		Tenant:     NewTenantHandler(tenantService, quotaService),
+		Admin:      NewAdminHandler(authService, auditService),
+		Role:       NewRoleHandler(roleService),
+		APIKey:     NewAPIKeyHandler(apiKeyService),
+		Domain:     NewDomainHandler(domainService),
+		Holiday:    NewHolidayHandler(services.NewHolidayService(store)),
+	}, RouteMiddleware{
+		Auth:    middleware.AuthMiddleware(issuer, authService, apiKeyService),
+		Audit:   middleware.AuditMiddleware(auditService),
+		Tenant:  middleware.TenantMiddleware(tenantService),
+		Quota:   middleware.QuotaMiddleware(quotaService),
+		Can:     can,
+		Feature: feature,
+	})
+
+	return &testServer{t: t, store: store, router: r, mail: mail}
+}
+
+// do sends a JSON request, authenticated when token is set
+func (s *testServer) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.doWithHeaders(method, path, token, nil, body)
+}
+
+// doWithHeaders sends a JSON request with extra headers
+func (s *testServer) doWithHeaders(method, path, token string, headers map[string]string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// expect sends a request, fails the test unless it answers with status and
+// decodes the response into out when it is not nil
+func (s *testServer) expect(status int, method, path, token string, body, out any) {
+	s.t.Helper()
+
+	w := s.do(method, path, token, body)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: got %d %s, want %d", method, path, w.Code, w.Body.String(), status)
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+}
+
+// register signs up a franchiser with a new network and returns the response
+func (s *testServer) register(email string) models.AuthResponse {
+	s.t.Helper()
+
+	var resp models.AuthResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/register", "", map[string]any{
+		"email":       email,
+		"password":    testPassword,
+		"tenant_name": "Network of " + email,
+	}, &resp)
+	return resp
+}
+
+// addUser creates a verified user of the tenant and signs them in
+func (s *testServer) addUser(tenantID, role string) (*models.User, models.AuthResponse) {
+	s.t.Helper()
+
+	id := uuid.NewString()
+	user := &models.User{
+		ID:            id,
+		Email:         id[:8] + "@example.com",
+		Password:      string(testPasswordHash),
+		Role:          role,
+		TenantID:      tenantID,
+		IsActive:      true,
+		EmailVerified: true,
+	}
+	if err := s.store.Users().Create(context.Background(), user); err != nil {
+		s.t.Fatalf("create user: %v", err)
+	}
+
+	return user, s.login(user.Email)
+}
+
+// login signs a user in with the test password
+func (s *testServer) login(email string) models.AuthResponse {
+	s.t.Helper()
+
+	var resp models.AuthResponse
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", "", map[string]any{
+		"email":    email,
+		"password": testPassword,
+	}, &resp)
+	return resp
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HolidayHandler serves the holiday calendar of the caller's tenant
+type HolidayHandler struct {
+	service *services.HolidayService
+}
+
+func NewHolidayHandler(service *services.HolidayService) *HolidayHandler {
+	return &HolidayHandler{
+		service: service,
+	}
+}
+
+// ListHolidays returns the holidays of the tenant in the year given by the
+// year query parameter, the current year by default
+func (h *HolidayHandler) ListHolidays(c *gin.Context) {
+	year := time.Now().Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 9999 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid year",
+				Message: "year must be a number between 1 and 9999",
+			})
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := h.service.ListHolidays(c.Request.Context(), c.GetString("tenantID"), year)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+// AddHoliday adds a date to the holiday calendar
+func (h *HolidayHandler) AddHoliday(c *gin.Context) {
+	var req models.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	holiday, err := h.service.AddHoliday(c.Request.Context(), c.GetString("tenantID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// DeleteHoliday removes a date from the holiday calendar
+func (h *HolidayHandler) DeleteHoliday(c *gin.Context) {
+	if err := h.service.DeleteHoliday(c.Request.Context(), c.GetString("tenantID"), c.Param("date")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Holiday deleted",
+	})
+}
+
+func (h *HolidayHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrHolidayNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Holiday not found",
+			Message: "The date is not in the holiday calendar",
+		})
+	case errors.Is(err, services.ErrInvalidHoliday):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid holiday",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrHolidayExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Holiday already exists",
+			Message: "The date already is in the holiday calendar",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Holiday request failed",
+			Message: "Internal server error",
+		})
+	}
+}
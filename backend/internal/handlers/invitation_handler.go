@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationHandler lets franchisers invite dealers and managers
+type InvitationHandler struct {
+	service *services.InvitationService
+}
+
+func NewInvitationHandler(service *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		service: service,
+	}
+}
+
+// CreateInvitation invites a dealer or manager into the caller's tenant
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	var req models.InvitationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	invitation, err := h.service.CreateInvitation(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.GetString("role"), req, clientInfo(c).Language)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListInvitations returns the invitations that were not accepted or revoked yet
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.service.ListInvitations(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// ResendInvitation emails a new link with a fresh expiry
+func (h *InvitationHandler) ResendInvitation(c *gin.Context) {
+	invitation, err := h.service.ResendInvitation(c.Request.Context(), c.GetString("tenantID"), c.GetString("userID"), c.GetString("role"), c.Param("id"), clientInfo(c).Language)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// RevokeInvitation invalidates the invitation link
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	if err := h.service.RevokeInvitation(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Invitation revoked",
+	})
+}
+
+func (h *InvitationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Invitation not found",
+			Message: "The invitation does not exist or is no longer pending",
+		})
+	case errors.Is(err, services.ErrInvitationExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Invitation already pending",
+			Message: "This email already has a pending invitation; resend or revoke it instead",
+		})
+	case errors.Is(err, services.ErrEmailTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "User already exists",
+			Message: "A user with this email already exists",
+		})
+	case errors.Is(err, services.ErrInvalidManager):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid manager",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid role",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrRoleNotHeld):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Insufficient permissions",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrUserLimitReached), errors.Is(err, services.ErrDealerLimitReached):
+		quotaExceeded(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Invitation request failed",
+			Message: "Internal server error",
+		})
+	}
+}
+
+// AcceptInvitation creates the invited account and signs it in
+func (h *AuthHandler) AcceptInvitation(c *gin.Context) {
+	var req models.InvitationAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.AcceptInvitation(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Password too weak",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrInvalidInvitation):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid invitation",
+				Message: "The invitation link is invalid, expired or was already used",
+			})
+		case errors.Is(err, services.ErrTenantSuspended):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Tenant suspended",
+				Message: "This franchise network has been suspended, please contact support",
+			})
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "User already exists",
+				Message: "A user with this email already exists",
+			})
+		case errors.Is(err, services.ErrUserLimitReached), errors.Is(err, services.ErrDealerLimitReached):
+			quotaExceeded(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Invitation acceptance failed",
+				Message: "Internal server error",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
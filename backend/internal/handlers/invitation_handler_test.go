@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestInvitationLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	tenantID := franchiser.User.TenantID
+
+	var invitation models.Invitation
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email":      "Dealer@Example.com",
+		"role":       models.RoleDealer,
+		"first_name": "Dana",
+	}, &invitation)
+	if invitation.TenantID != tenantID || invitation.Email != "dealer@example.com" || invitation.Status != models.InvitationPending {
+		t.Fatalf("invitation: got %+v", invitation)
+	}
+	firstLink := s.mail.token(t, "dealer@example.com")
+
+	// One open invitation per email
+	s.expect(http.StatusConflict, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "dealer@example.com",
+		"role":  models.RoleDealer,
+	}, nil)
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "owner2@example.com",
+		"role":  models.RoleFranchiser,
+	}, nil)
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "nobody@example.com",
+		"role":  "no_such_role",
+	}, nil)
+
+	var listed []models.Invitation
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/invitations", franchiser.Token, nil, &listed)
+	if len(listed) != 1 || listed[0].ID != invitation.ID {
+		t.Fatalf("invitations: got %+v", listed)
+	}
+
+	// Resending replaces the link
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/invitations/"+invitation.ID+"/resend", franchiser.Token, nil, nil)
+	link := s.mail.token(t, "dealer@example.com")
+	if link == firstLink {
+		t.Fatal("resend kept the link")
+	}
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    firstLink,
+		"password": testPassword,
+	}, nil)
+
+	// The invitee joins the inviter's network with the invited role
+	var accepted models.AuthResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    link,
+		"password": testPassword,
+	}, &accepted)
+	if accepted.User.TenantID != tenantID || accepted.User.Role != models.RoleDealer || accepted.User.FirstName != "Dana" {
+		t.Fatalf("accepted user: got %+v", accepted.User)
+	}
+	var tenant models.Tenant
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/tenant", accepted.Token, nil, &tenant)
+	if tenant.ID != tenantID {
+		t.Fatalf("tenant of the invitee: got %s, want %s", tenant.ID, tenantID)
+	}
+
+	// An accepted invitation is closed
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    link,
+		"password": testPassword,
+	}, nil)
+	s.expect(http.StatusNotFound, http.MethodPost, "/api/v1/invitations/"+invitation.ID+"/resend", franchiser.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/invitations", franchiser.Token, nil, &listed)
+	if len(listed) != 0 {
+		t.Fatalf("invitations after acceptance: got %+v", listed)
+	}
+}
+
+func TestInvitationRevokeAndExpiry(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	ctx := context.Background()
+
+	var revoked models.Invitation
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "revoked@example.com",
+		"role":  models.RoleDealer,
+	}, &revoked)
+	link := s.mail.token(t, "revoked@example.com")
+	s.expect(http.StatusOK, http.MethodDelete, "/api/v1/invitations/"+revoked.ID, franchiser.Token, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodDelete, "/api/v1/invitations/"+revoked.ID, franchiser.Token, nil, nil)
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    link,
+		"password": testPassword,
+	}, nil)
+
+	var expiring models.Invitation
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "late@example.com",
+		"role":  models.RoleManager,
+	}, &expiring)
+	link = s.mail.token(t, "late@example.com")
+
+	stored, err := s.store.Invitations().GetByID(ctx, expiring.TenantID, expiring.ID)
+	if err != nil {
+		t.Fatalf("get invitation: %v", err)
+	}
+	stored.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := s.store.Invitations().UpdateToken(ctx, stored); err != nil {
+		t.Fatalf("expire invitation: %v", err)
+	}
+
+	var listed []models.Invitation
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/invitations", franchiser.Token, nil, &listed)
+	if len(listed) != 1 || listed[0].Status != models.InvitationExpired {
+		t.Fatalf("invitations: got %+v", listed)
+	}
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    link,
+		"password": testPassword,
+	}, nil)
+
+	// Resending revives an expired invitation
+	var resent models.Invitation
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/invitations/"+expiring.ID+"/resend", franchiser.Token, nil, &resent)
+	if resent.Status != models.InvitationPending {
+		t.Fatalf("resent invitation: got status %q", resent.Status)
+	}
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/invitations/accept", "", map[string]any{
+		"token":    s.mail.token(t, "late@example.com"),
+		"password": testPassword,
+	}, nil)
+}
+
+func TestInvitationRequiresPermissionsOfTheRole(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	tenantID := franchiser.User.TenantID
+
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", franchiser.Token, map[string]any{
+		"name":        "recruiter",
+		"permissions": []string{models.PermissionManageInvitations, models.PermissionManageChecklists},
+	}, nil)
+	_, recruiter := s.addUser(tenantID, "recruiter")
+
+	// A manager reviews checklists and sees dealers, which a recruiter cannot
+	s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/invitations", recruiter.Token, map[string]any{
+		"email": "manager@example.com",
+		"role":  models.RoleManager,
+	}, nil)
+	s.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/invitations", recruiter.Token, map[string]any{
+		"email": "owner2@example.com",
+		"role":  models.RoleFranchiser,
+	}, nil)
+
+	// A dealer, or another recruiter, holds nothing a recruiter lacks
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", recruiter.Token, map[string]any{
+		"email": "dealer@example.com",
+		"role":  models.RoleDealer,
+	}, nil)
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", recruiter.Token, map[string]any{
+		"email": "peer@example.com",
+		"role":  "recruiter",
+	}, nil)
+
+	// Nor can the recruiter resend an invitation into a stronger role
+	var invitation models.Invitation
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", franchiser.Token, map[string]any{
+		"email": "manager@example.com",
+		"role":  models.RoleManager,
+	}, &invitation)
+	s.expect(http.StatusForbidden, http.MethodPost, "/api/v1/invitations/"+invitation.ID+"/resend", recruiter.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/invitations/"+invitation.ID+"/resend", franchiser.Token, nil, nil)
+}
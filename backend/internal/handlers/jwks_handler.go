@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"franchise-saas-backend/internal/tokens"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public signing keys
+type JWKSHandler struct {
+	keys *tokens.KeySet
+}
+
+func NewJWKSHandler(keys *tokens.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// GetJWKS returns the keys other services verify access tokens with
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Short caching keeps rotated keys visible soon after they are added
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler serves the permission catalogue and the roles of the caller's tenant
+type RoleHandler struct {
+	service *services.RoleService
+}
+
+func NewRoleHandler(service *services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+	}
+}
+
+// ListPermissions returns every permission a role can be granted
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListPermissions())
+}
+
+// ListRoles returns the roles of the tenant
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole defines a custom role
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req models.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), c.GetString("tenantID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole changes the description or permissions of a role
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var req models.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), c.GetString("tenantID"), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole removes a custom role
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	if err := h.service.DeleteRole(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Role deleted",
+	})
+}
+
+func (h *RoleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Role not found",
+			Message: "The requested role does not exist",
+		})
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid role",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrRoleExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Role already exists",
+			Message: "The tenant already has a role with this name",
+		})
+	case errors.Is(err, services.ErrRoleBuiltIn), errors.Is(err, services.ErrRoleInUse):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Role cannot be changed",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Role request failed",
+			Message: "Internal server error",
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"franchise-saas-backend/internal/middleware"
+	"franchise-saas-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// Handlers are the handlers the API routes are served by
+type Handlers struct {
+	Auth       *AuthHandler
+	User       *UserHandler
+	Checklist  *ChecklistHandler
+	Template   *ChecklistTemplateHandler
+	Session    *SessionHandler
+	JWKS       *JWKSHandler
+	Invitation *InvitationHandler
+	Tenant     *TenantHandler
+	Admin      *AdminHandler
+	Role       *RoleHandler
+	APIKey     *APIKeyHandler
+	Domain     *DomainHandler
+	Holiday    *HolidayHandler
+}
+
+// RouteMiddleware is the middleware the routes are guarded with
+type RouteMiddleware struct {
+	Auth   gin.HandlerFunc
+	Audit  gin.HandlerFunc
+	Tenant gin.HandlerFunc
+	Quota  gin.HandlerFunc
+
+	// Can requires one of the permissions
+	Can func(permissions ...string) gin.HandlerFunc
+	// Feature requires the tenant to have the feature switched on
+	Feature func(name string) gin.HandlerFunc
+}
+
+// SetupRoutes registers the routes of the API on r
+func SetupRoutes(r *gin.Engine, h Handlers, mw RouteMiddleware) {
+	// Health check endpoint
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "OK",
+			"service":   "franchise-saas-backend",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"version":   viper.GetString("version"),
+		})
+	})
+
+	// Public keys for verifying access tokens
+	r.GET("/.well-known/jwks.json", h.JWKS.GetJWKS)
+
+	// API v1 routes
+	api := r.Group("/api/v1")
+	{
+		// Branding of the network whose custom domain the request was made to
+		api.GET("/public/branding", h.Domain.GetBranding)
+
+		// Public routes
+		public := api.Group("/auth")
+		{
+			public.POST("/register", h.Auth.Register)
+			public.POST("/login", h.Auth.Login)
+			public.POST("/refresh", h.Auth.RefreshToken)
+			public.POST("/forgot-password", h.Auth.ForgotPassword)
+			public.POST("/reset-password", h.Auth.ResetPassword)
+			public.POST("/verify-email", h.Auth.VerifyEmail)
+			public.POST("/2fa/verify", h.Auth.VerifyTwoFactor)
+			public.POST("/2fa/challenge/setup", h.Auth.SetupTwoFactorChallenge)
+			public.POST("/invitations/accept", h.Auth.AcceptInvitation)
+		}
+
+		// Protected routes, limited to the tenant of the access token or, for a
+		// superadmin, to the one in X-Tenant-ID. Requests made as another user
+		// or in another tenant are audited. API keys reach the routes their
+		// scopes allow. Every request counts against the monthly API call
+		// limit of the tenant.
+		protected := api.Group("")
+		protected.Use(mw.Auth, mw.Audit, mw.Tenant, mw.Quota)
+		{
+			// Routes of the signed-in user's own account, closed to API keys
+			account := protected.Group("")
+			account.Use(middleware.RequireUser())
+			{
+				// Auth routes
+				account.POST("/auth/logout", h.Auth.Logout)
+				account.POST("/auth/logout-all", h.Auth.LogoutAll)
+				account.GET("/auth/me", h.Auth.GetCurrentUser) // Add this endpoint
+				account.POST("/auth/verify-email/resend", h.Auth.ResendEmailVerification)
+				account.GET("/auth/2fa", h.Auth.GetTwoFactorStatus)
+				account.POST("/auth/2fa/setup", middleware.ForbidImpersonation(), h.Auth.SetupTwoFactor)
+				account.POST("/auth/2fa/confirm", middleware.ForbidImpersonation(), h.Auth.ConfirmTwoFactor)
+				account.POST("/auth/2fa/recovery-codes", middleware.ForbidImpersonation(), h.Auth.RegenerateRecoveryCodes)
+				account.POST("/auth/2fa/disable", middleware.ForbidImpersonation(), h.Auth.DisableTwoFactor)
+				account.GET("/auth/sessions", h.Session.ListSessions)
+				account.DELETE("/auth/sessions/:id", h.Session.RevokeSession)
+
+				// User routes
+				users := account.Group("/users")
+				{
+					users.GET("/profile", h.User.GetProfile)
+					users.PUT("/profile", h.User.UpdateProfile)
+					users.PUT("/password", middleware.ForbidImpersonation(), h.User.ChangePassword)
+				}
+			}
+
+			// Tenant of the current user
+			protected.GET("/tenant", h.Tenant.GetTenant)
+			protected.PUT("/tenant", mw.Can(models.PermissionManageTenant), h.Tenant.UpdateTenant)
+			protected.GET("/tenant/usage", mw.Can(models.PermissionManageTenant), h.Tenant.GetUsage)
+
+			// Custom domains of the tenant
+			domains := protected.Group("/tenant/domains")
+			domains.Use(mw.Can(models.PermissionManageTenant))
+			{
+				domains.GET("", h.Domain.ListDomains)
+				domains.POST("", h.Domain.AddDomain)
+				domains.POST("/:id/verify", h.Domain.VerifyDomain)
+				domains.DELETE("/:id", h.Domain.DeleteDomain)
+			}
+
+			// Holiday calendar of the tenant, skipped by the checklist scheduler
+			holidays := protected.Group("/tenant/holidays")
+			holidays.Use(mw.Feature(models.FeatureChecklist), mw.Can(models.PermissionManageTenant))
+			{
+				holidays.GET("", h.Holiday.ListHolidays)
+				holidays.POST("", h.Holiday.AddHoliday)
+				holidays.DELETE("/:date", h.Holiday.DeleteHoliday)
+			}
+
+			// Permission catalogue and the roles of the tenant
+			protected.GET("/permissions", h.Role.ListPermissions)
+			roles := protected.Group("/roles")
+			roles.Use(mw.Can(models.PermissionManageRoles))
+			{
+				roles.GET("", h.Role.ListRoles)
+				roles.POST("", h.Role.CreateRole)
+				roles.PUT("/:id", h.Role.UpdateRole)
+				roles.DELETE("/:id", h.Role.DeleteRole)
+			}
+
+			// API keys for integrations; a key is shown once, on creation
+			apiKeys := protected.Group("/api-keys")
+			apiKeys.Use(mw.Can(models.PermissionManageAPIKeys))
+			{
+				apiKeys.GET("", h.APIKey.ListAPIKeys)
+				apiKeys.POST("", middleware.ForbidImpersonation(), h.APIKey.CreateAPIKey)
+				apiKeys.DELETE("/:id", h.APIKey.RevokeAPIKey)
+			}
+
+			// Checklist routes; reviewers read and review the checklists of the
+			// dealers they oversee
+			checklists := protected.Group("/checklists")
+			checklists.Use(mw.Feature(models.FeatureChecklist))
+			{
+				checklists.GET("", mw.Can(models.PermissionManageChecklists, models.PermissionReviewChecklists), h.Checklist.GetChecklists)
+				checklists.GET("/:id", mw.Can(models.PermissionManageChecklists, models.PermissionReviewChecklists), h.Checklist.GetChecklistByID)
+				checklists.POST("", mw.Can(models.PermissionManageChecklists), h.Checklist.CreateChecklist)
+				checklists.PUT("/:id", mw.Can(models.PermissionManageChecklists), h.Checklist.UpdateChecklist)
+				checklists.DELETE("/:id", mw.Can(models.PermissionManageChecklists), h.Checklist.DeleteChecklist)
+				checklists.POST("/:id/complete", mw.Can(models.PermissionManageChecklists), h.Checklist.CompleteChecklist)
+				checklists.POST("/:id/tasks/:taskId/review", mw.Can(models.PermissionReviewChecklists), h.Checklist.ReviewTask)
+				checklists.POST("/from-template/:id", mw.Can(models.PermissionManageChecklists), h.Template.CreateChecklist)
+			}
+
+			// Checklist template routes; dealers see the templates assigned to them
+			templates := protected.Group("/checklist-templates")
+			templates.Use(mw.Feature(models.FeatureChecklist))
+			{
+				templates.GET("", mw.Can(models.PermissionManageTemplates, models.PermissionManageChecklists), h.Template.ListTemplates)
+				templates.GET("/:id", mw.Can(models.PermissionManageTemplates, models.PermissionManageChecklists), h.Template.GetTemplate)
+				templates.GET("/:id/versions", mw.Can(models.PermissionManageTemplates), h.Template.ListVersions)
+				templates.POST("", mw.Can(models.PermissionManageTemplates), h.Template.CreateTemplate)
+				templates.PUT("/:id", mw.Can(models.PermissionManageTemplates), h.Template.UpdateTemplate)
+				templates.DELETE("/:id", mw.Can(models.PermissionManageTemplates), h.Template.DeleteTemplate)
+			}
+
+			// Invitation routes
+			invitations := protected.Group("/invitations")
+			invitations.Use(mw.Can(models.PermissionManageInvitations))
+			{
+				invitations.GET("", h.Invitation.ListInvitations)
+				invitations.POST("", h.Invitation.CreateInvitation)
+				invitations.POST("/:id/resend", h.Invitation.ResendInvitation)
+				invitations.DELETE("/:id", h.Invitation.RevokeInvitation)
+			}
+
+			// Dealers assigned to each manager
+			managers := protected.Group("/managers")
+			managers.Use(mw.Can(models.PermissionAssignDealers))
+			{
+				managers.GET("/:id/dealers", h.User.GetManagerDealers)
+				managers.PUT("/:id/dealers", h.User.AssignDealers)
+			}
+
+			// Dealer routes; without view_all_dealers only the assigned dealers are visible
+			dealers := protected.Group("/dealers")
+			{
+				dealers.GET("", mw.Can(models.PermissionViewAllDealers, models.PermissionViewAssignedDealers), h.User.GetAllDealers)
+				dealers.GET("/:id", mw.Can(models.PermissionViewAllDealers, models.PermissionViewAssignedDealers), h.User.GetDealerByID)
+				dealers.GET("/:id/sessions", mw.Can(models.PermissionManageDealerSessions), h.Session.ListDealerSessions)
+				dealers.DELETE("/:id/sessions", mw.Can(models.PermissionManageDealerSessions), h.Session.RevokeAllDealerSessions)
+				dealers.DELETE("/:id/sessions/:sessionId", mw.Can(models.PermissionManageDealerSessions), h.Session.RevokeDealerSession)
+			}
+		}
+
+		// Platform administration (for superadmin); it works across tenants,
+		// so it is not limited by TenantMiddleware
+		admin := api.Group("/admin")
+		admin.Use(mw.Auth, mw.Can(models.PermissionManagePlatform))
+		{
+			admin.GET("/tenants", h.Tenant.ListTenants)
+			admin.POST("/tenants", h.Tenant.CreateTenant)
+			admin.GET("/tenants/:id", h.Tenant.GetTenantByID)
+			admin.PUT("/tenants/:id", h.Tenant.AdminUpdateTenant)
+			admin.POST("/tenants/:id/suspend", h.Tenant.SuspendTenant)
+			admin.POST("/tenants/:id/resume", h.Tenant.ResumeTenant)
+			admin.DELETE("/tenants/:id", h.Tenant.DeleteTenant)
+			admin.PUT("/tenants/:id/features", h.Tenant.SetTenantFeatures)
+			admin.GET("/plans", h.Tenant.ListPlans)
+			admin.PUT("/plans/:plan/features", h.Tenant.SetPlanFeatures)
+			admin.POST("/impersonate", h.Admin.Impersonate)
+			admin.GET("/audit-log", h.Admin.ListAuditLog)
+		}
+	}
+
+	// 404 handler
+	r.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not Found",
+			"message": "The requested resource was not found",
+		})
+	})
+}
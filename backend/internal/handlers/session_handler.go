@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler exposes the signed-in devices of users
+type SessionHandler struct {
+	service *services.SessionService
+}
+
+func NewSessionHandler(service *services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+	}
+}
+
+// ListSessions returns the active logins of the current user
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.GetString("userID"), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retrieve sessions",
+			Message: "Could not fetch active sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs the current user out of one device
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	err := h.service.RevokeSession(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Session revoked successfully",
+	})
+}
+
+// ListDealerSessions returns the active logins of a dealer of the franchiser's tenant
+func (h *SessionHandler) ListDealerSessions(c *gin.Context) {
+	sessions, err := h.service.ListDealerSessions(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeDealerSession signs a dealer out of one device
+func (h *SessionHandler) RevokeDealerSession(c *gin.Context) {
+	err := h.service.RevokeDealerSession(c.Request.Context(), c.GetString("tenantID"), c.Param("id"), c.Param("sessionId"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Session revoked successfully",
+	})
+}
+
+// RevokeAllDealerSessions signs a dealer out of every device
+func (h *SessionHandler) RevokeAllDealerSessions(c *gin.Context) {
+	err := h.service.RevokeAllDealerSessions(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "All sessions of the dealer were revoked",
+	})
+}
+
+func (h *SessionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Session not found",
+			Message: "The requested session does not exist or has already ended",
+		})
+	case errors.Is(err, services.ErrDealerNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Dealer not found",
+			Message: "The requested dealer does not exist",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Session operation failed",
+			Message: "Internal server error",
+		})
+	}
+}
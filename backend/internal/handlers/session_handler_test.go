@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestRevokeSessionEndsAccessToken(t *testing.T) {
+	s := newTestServer(t)
+	registered := s.register("owner@example.com")
+	other := s.login("owner@example.com")
+
+	var sessions []models.ActiveSession
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/sessions", registered.Token, nil, &sessions)
+	for _, session := range sessions {
+		if !session.Current {
+			s.expect(http.StatusOK, http.MethodDelete, "/api/v1/auth/sessions/"+session.ID, registered.Token, nil, nil)
+		}
+	}
+
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", other.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", registered.Token, nil, nil)
+}
+
+func TestRevokeDealerSessions(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	dealer, phone := s.addUser(franchiser.User.TenantID, models.RoleDealer)
+	laptop := s.login(dealer.Email)
+	path := "/api/v1/dealers/" + dealer.ID + "/sessions"
+
+	// Signing the dealer out of one device leaves the other one working
+	var sessions []models.ActiveSession
+	s.expect(http.StatusOK, http.MethodGet, path, franchiser.Token, nil, &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("dealer sessions: got %d, want 2", len(sessions))
+	}
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", phone.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodDelete, path+"/"+sessions[0].ID, franchiser.Token, nil, nil)
+	revoked := 0
+	for _, token := range []string{phone.Token, laptop.Token} {
+		if s.do(http.MethodGet, "/api/v1/auth/me", token, nil).Code == http.StatusUnauthorized {
+			revoked++
+		}
+	}
+	if revoked != 1 {
+		t.Fatalf("%d access tokens stopped working, want 1", revoked)
+	}
+
+	s.expect(http.StatusOK, http.MethodDelete, path, franchiser.Token, nil, nil)
+	for _, token := range []string{phone.Token, laptop.Token} {
+		s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", token, nil, nil)
+	}
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", s.login(dealer.Email).Token, nil, nil)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHandler serves the caller's own tenant and the superadmin tenant API
+type TenantHandler struct {
+	service *services.TenantService
+	quotas  *services.QuotaService
+}
+
+func NewTenantHandler(service *services.TenantService, quotas *services.QuotaService) *TenantHandler {
+	return &TenantHandler{
+		service: service,
+		quotas:  quotas,
+	}
+}
+
+// GetTenant returns the tenant of the authenticated user
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	tenant, err := h.service.GetTenant(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// UpdateTenant lets a franchiser change the name, city, branding and features of their tenant
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	var req models.TenantUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.service.UpdateTenant(c.Request.Context(), c.GetString("tenantID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// GetUsage returns the consumption of the user's tenant against its limits
+func (h *TenantHandler) GetUsage(c *gin.Context) {
+	usage, err := h.quotas.GetUsage(c.Request.Context(), c.GetString("tenantID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// ListTenants returns a page of all tenants; the total is sent in X-Total-Count
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := models.TenantFilter{
+		Search: c.Query("search"),
+		Plan:   c.Query("plan"),
+		Status: c.Query("status"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+
+	tenants, total, err := h.service.ListTenants(c.Request.Context(), filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.JSON(http.StatusOK, tenants)
+}
+
+// CreateTenant creates a tenant and optionally invites its franchiser
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req models.TenantCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.service.CreateTenant(c.Request.Context(), c.GetString("userID"), req, clientInfo(c).Language)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// GetTenantByID returns any tenant
+func (h *TenantHandler) GetTenantByID(c *gin.Context) {
+	tenant, err := h.service.GetTenant(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// AdminUpdateTenant changes the name, city, plan or settings of any tenant
+func (h *TenantHandler) AdminUpdateTenant(c *gin.Context) {
+	var req models.TenantAdminUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.service.AdminUpdateTenant(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// SetTenantFeatures switches modules of any tenant on or off, also beyond its plan
+func (h *TenantHandler) SetTenantFeatures(c *gin.Context) {
+	var req models.FeatureToggleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.service.SetTenantFeatures(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// ListPlans returns the plan catalogue
+func (h *TenantHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// SetPlanFeatures switches modules of a plan on or off for its new and current tenants
+func (h *TenantHandler) SetPlanFeatures(c *gin.Context) {
+	var req models.FeatureToggleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	plan, err := h.service.SetPlanFeatures(c.Request.Context(), c.Param("plan"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// SuspendTenant locks all users of a tenant out
+func (h *TenantHandler) SuspendTenant(c *gin.Context) {
+	tenant, err := h.service.SuspendTenant(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// ResumeTenant lifts a suspension
+func (h *TenantHandler) ResumeTenant(c *gin.Context) {
+	tenant, err := h.service.ResumeTenant(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// DeleteTenant removes a tenant with all of its data
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	if err := h.service.DeleteTenant(c.Request.Context(), c.GetString("tenantID"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Tenant deleted",
+	})
+}
+
+func (h *TenantHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTenantNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Tenant not found",
+			Message: "The requested tenant does not exist",
+		})
+	case errors.Is(err, services.ErrInvalidSettings), errors.Is(err, services.ErrInvalidSlug), errors.Is(err, services.ErrInvalidPlan):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid tenant",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrFeatureNotInPlan):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Feature not available",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrSlugTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Slug already taken",
+			Message: "Another franchise network already uses this slug",
+		})
+	case errors.Is(err, services.ErrEmailTaken):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "User already exists",
+			Message: "A user with the owner's email already exists",
+		})
+	case errors.Is(err, services.ErrOwnTenant):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Own tenant",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Tenant request failed",
+			Message: "Internal server error",
+		})
+	}
+}
+
+// quotaExceeded responds 402 with the code of the plan limit the tenant has reached
+func quotaExceeded(c *gin.Context, err error) {
+	code := models.ErrorCodeUserLimit
+	if errors.Is(err, services.ErrDealerLimitReached) {
+		code = models.ErrorCodeDealerLimit
+	}
+
+	c.JSON(http.StatusPaymentRequired, models.ErrorResponse{
+		Error:   "Plan limit reached",
+		Message: err.Error() + "; upgrade the plan or free up capacity",
+		Code:    code,
+	})
+}
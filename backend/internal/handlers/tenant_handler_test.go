@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestPlanFeaturesKeepTenantOptOuts(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	_, admin := s.addUser(franchiser.User.TenantID, models.RoleSuperadmin)
+
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+
+	features := models.AllFeatures
+	features.Checklist = false
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{"features": features}, nil)
+	s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+
+	// Toggling the module on the plan must not undo the tenant's choice
+	for _, enabled := range []bool{false, true} {
+		s.expect(http.StatusOK, http.MethodPut, "/api/v1/admin/plans/"+models.PlanStart+"/features", admin.Token,
+			map[string]bool{models.FeatureChecklist: enabled}, nil)
+	}
+	s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+
+	features.Checklist = true
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{"features": features}, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+
+	// A module the plan drops is gone for every tenant on it
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/admin/plans/"+models.PlanStart+"/features", admin.Token,
+		map[string]bool{models.FeatureChecklist: false}, nil)
+	s.expect(http.StatusForbidden, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+
+	// until a superadmin grants it to the tenant
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/admin/tenants/"+franchiser.User.TenantID+"/features", admin.Token,
+		map[string]bool{models.FeatureChecklist: true}, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/checklists", franchiser.Token, nil, nil)
+}
+
+func TestFranchiserCannotEnableFeatureBeyondPlan(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+
+	features := models.AllFeatures
+	features.MarketingAutomation = false
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{"features": features}, nil)
+
+	features.MarketingAutomation = true
+	s.expect(http.StatusForbidden, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{"features": features}, nil)
+}
+
+func TestUpdateTenantSecurity(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+
+	var tenant models.Tenant
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{
+		"security": map[string]any{"mfa_required_roles": []string{models.RoleManager, models.RoleFranchiser}},
+	}, &tenant)
+	var settings models.TenantSettings
+	if err := json.Unmarshal(tenant.Settings, &settings); err != nil {
+		t.Fatalf("decode settings: %v", err)
+	}
+	if !slices.Equal(settings.Security.MFARequiredRoles, []string{models.RoleManager, models.RoleFranchiser}) {
+		t.Errorf("mfa_required_roles: got %v", settings.Security.MFARequiredRoles)
+	}
+
+	for _, roles := range [][]string{{"auditor"}, {"Not a role"}, {models.RoleDealer, models.RoleDealer}} {
+		s.expect(http.StatusBadRequest, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{
+			"security": map[string]any{"mfa_required_roles": roles},
+		}, nil)
+	}
+
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{
+		"security": map[string]any{},
+	}, &tenant)
+	settings = models.TenantSettings{}
+	if err := json.Unmarshal(tenant.Settings, &settings); err != nil {
+		t.Fatalf("decode settings: %v", err)
+	}
+	if len(settings.Security.MFARequiredRoles) != 0 {
+		t.Errorf("mfa_required_roles after clearing: got %v", settings.Security.MFARequiredRoles)
+	}
+}
+
+func TestGetTenantShowsSecurity(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	_, manager := s.addUser(franchiser.User.TenantID, models.RoleManager)
+
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/tenant", franchiser.Token, map[string]any{
+		"security": map[string]any{"mfa_required_roles": []string{models.RoleManager}},
+	}, nil)
+	s.expect(http.StatusForbidden, http.MethodPut, "/api/v1/tenant", manager.Token, map[string]any{
+		"security": map[string]any{},
+	}, nil)
+
+	var tenant struct {
+		Settings struct {
+			Security models.TenantSecuritySettings `json:"security"`
+		} `json:"settings"`
+	}
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/tenant", manager.Token, nil, &tenant)
+	if !slices.Equal(tenant.Settings.Security.MFARequiredRoles, []string{models.RoleManager}) {
+		t.Errorf("security in GET /tenant: got %+v", tenant.Settings.Security)
+	}
+}
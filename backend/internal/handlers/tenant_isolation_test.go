@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+// TestTenantIsolation calls every endpoint that takes an ID with the token of
+// one network and the IDs of another; each must answer as if the ID did not exist
+func TestTenantIsolation(t *testing.T) {
+	s := newTestServer(t)
+	other := s.register("other@example.com")
+	otherManager, _ := s.addUser(other.User.TenantID, models.RoleManager)
+	otherDealer, otherDealerLogin := s.addUser(other.User.TenantID, models.RoleDealer)
+
+	var checklist models.Checklist
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/checklists", otherDealerLogin.Token, map[string]any{
+		"title": "Opening",
+		"date":  "2025-03-03T00:00:00Z",
+		"tasks": []map[string]any{{"title": "Open the doors"}},
+	}, &checklist)
+
+	var sessions []models.ActiveSession
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/sessions", otherDealerLogin.Token, nil, &sessions)
+
+	var invitation models.Invitation
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/invitations", other.Token, map[string]any{
+		"email": "invitee@example.com",
+		"role":  models.RoleDealer,
+	}, &invitation)
+
+	var apiKey models.APIKeyCreateResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/api-keys", other.Token, map[string]any{
+		"name":   "Website",
+		"scopes": []string{models.PermissionViewAllDealers},
+	}, &apiKey)
+
+	var template models.ChecklistTemplate
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/checklist-templates", other.Token, map[string]any{
+		"name":  "Opening",
+		"tasks": []map[string]any{{"title": "Open the doors"}},
+	}, &template)
+
+	var domain models.TenantDomainResponse
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/tenant/domains", other.Token, map[string]any{
+		"domain": "shop.example.com",
+	}, &domain)
+
+	var role models.Role
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", other.Token, map[string]any{
+		"name":        "auditor",
+		"permissions": []string{models.PermissionReviewChecklists},
+	}, &role)
+
+	owner := s.register("owner@example.com")
+	_, dealer := s.addUser(owner.User.TenantID, models.RoleDealer)
+
+	checklistPath := "/api/v1/checklists/" + checklist.ID
+	templatePath := "/api/v1/checklist-templates/" + template.ID
+	dealerPath := "/api/v1/dealers/" + otherDealer.ID
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   any
+	}{
+		{"get checklist", owner.Token, http.MethodGet, checklistPath, nil},
+		{"get checklist as dealer", dealer.Token, http.MethodGet, checklistPath, nil},
+		{"update checklist", dealer.Token, http.MethodPut, checklistPath, map[string]any{"title": "Mine"}},
+		{"complete checklist", dealer.Token, http.MethodPost, checklistPath + "/complete", nil},
+		{"delete checklist", dealer.Token, http.MethodDelete, checklistPath, nil},
+		{"review task", owner.Token, http.MethodPost, checklistPath + "/tasks/" + checklist.Tasks[0].ID + "/review", map[string]any{"verified": true}},
+
+		{"get dealer", owner.Token, http.MethodGet, dealerPath, nil},
+		{"list dealer sessions", owner.Token, http.MethodGet, dealerPath + "/sessions", nil},
+		{"revoke dealer sessions", owner.Token, http.MethodDelete, dealerPath + "/sessions", nil},
+		{"revoke dealer session", owner.Token, http.MethodDelete, dealerPath + "/sessions/" + sessions[0].ID, nil},
+		{"list assigned dealers", owner.Token, http.MethodGet, "/api/v1/managers/" + otherManager.ID + "/dealers", nil},
+		{"assign dealers", owner.Token, http.MethodPut, "/api/v1/managers/" + otherManager.ID + "/dealers", map[string]any{"dealer_ids": []string{}}},
+
+		{"revoke own session", dealer.Token, http.MethodDelete, "/api/v1/auth/sessions/" + sessions[0].ID, nil},
+
+		{"resend invitation", owner.Token, http.MethodPost, "/api/v1/invitations/" + invitation.ID + "/resend", nil},
+		{"revoke invitation", owner.Token, http.MethodDelete, "/api/v1/invitations/" + invitation.ID, nil},
+
+		{"revoke API key", owner.Token, http.MethodDelete, "/api/v1/api-keys/" + apiKey.ID, nil},
+
+		{"get template", owner.Token, http.MethodGet, templatePath, nil},
+		{"list template versions", owner.Token, http.MethodGet, templatePath + "/versions", nil},
+		{"update template", owner.Token, http.MethodPut, templatePath, map[string]any{"name": "Mine", "tasks": []map[string]any{{"title": "Open"}}}},
+		{"delete template", owner.Token, http.MethodDelete, templatePath, nil},
+		{"checklist from template", dealer.Token, http.MethodPost, "/api/v1/checklists/from-template/" + template.ID, map[string]any{"date": "2025-03-03"}},
+
+		{"verify domain", owner.Token, http.MethodPost, "/api/v1/tenant/domains/" + domain.ID + "/verify", nil},
+		{"delete domain", owner.Token, http.MethodDelete, "/api/v1/tenant/domains/" + domain.ID, nil},
+
+		{"update role", owner.Token, http.MethodPut, "/api/v1/roles/" + role.ID, map[string]any{"permissions": []string{models.PermissionManageTenant}}},
+		{"delete role", owner.Token, http.MethodDelete, "/api/v1/roles/" + role.ID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(tt.method, tt.path, tt.token, tt.body); w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: got %d %s, want 404", tt.method, tt.path, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The other network's data is unchanged
+	s.expect(http.StatusOK, http.MethodGet, checklistPath, otherDealerLogin.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodGet, templatePath, other.Token, nil, nil)
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", otherDealerLogin.Token, nil, nil)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTwoFactor completes a two-step login with a TOTP or recovery code
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.VerifyTwoFactor(c.Request.Context(), req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid code",
+				Message: "The two-factor code is invalid or was already used",
+			})
+			return
+		}
+
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetupTwoFactorChallenge starts a required enrollment during login
+func (h *AuthHandler) SetupTwoFactorChallenge(c *gin.Context) {
+	var req models.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.SetupTwoFactorChallenge(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTwoFactorStatus returns the 2FA state of the current user
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	result, err := h.service.TwoFactorStatus(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetupTwoFactor generates a new TOTP secret for the current user
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	result, err := h.service.SetupTwoFactor(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ConfirmTwoFactor enables 2FA with the first code from the authenticator
+func (h *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(c.Request.Context(), c.GetString("userID"), req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("userID"), req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns 2FA off for the current user
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(c.Request.Context(), c.GetString("userID"), req.Password, req.Code); err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+func (h *AuthHandler) twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid challenge",
+			Message: "The login challenge is invalid or expired, please log in again",
+		})
+	case errors.Is(err, services.ErrUserInactive):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Account deactivated",
+			Message: "This account has been deactivated",
+		})
+	case errors.Is(err, services.ErrTenantSuspended):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Tenant suspended",
+			Message: "This franchise network has been suspended, please contact support",
+		})
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid code",
+			Message: "The two-factor code is invalid or was already used",
+		})
+	case errors.Is(err, services.ErrInvalidCurrentPassword):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid password",
+			Message: "The password is incorrect",
+		})
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Already enabled",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrTwoFactorNotStarted):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Two-factor authentication not set up",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrTwoFactorMandatory):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Two-factor authentication required",
+			Message: "Your organisation requires two-factor authentication for your role",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "User not found",
+			Message: "The user does not exist",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Two-factor operation failed",
+			Message: "Internal server error",
+		})
+	}
+}
@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 
+	"franchise-saas-backend/internal/middleware"
 	"franchise-saas-backend/internal/models"
 	"franchise-saas-backend/internal/services"
 
@@ -42,8 +43,16 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.GetUserByID(userID.(string))
+	user, err := h.service.GetUserByID(c.Request.Context(), userID.(string))
 	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Пользователь не найден",
+				Message: "Запрашиваемый пользователь не существует",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Не удалось получить данные пользователя",
 			Message: "Не удалось загрузить профиль пользователя",
@@ -51,14 +60,6 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	if user == nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Пользователь не найден",
-			Message: "Запрашиваемый пользователь не существует",
-		})
-		return
-	}
-
 	// Не возвращаем хеш пароля
 	user.Password = ""
 	c.JSON(http.StatusOK, user)
@@ -98,8 +99,16 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// Обновление данных пользователя
-	updatedUser, err := h.service.UpdateUser(userID.(string), req)
+	updatedUser, err := h.service.UpdateUser(c.Request.Context(), userID.(string), req)
 	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Пользователь не найден",
+				Message: "Запрашиваемый пользователь не существует",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Не удалось обновить пользователя",
 			Message: "Не удалось обновить профиль пользователя",
@@ -112,9 +121,75 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedUser)
 }
 
+// ChangePassword меняет пароль аутентифицированного пользователя
+// @Summary Смена пароля
+// @Description Проверяет текущий пароль, сохраняет новый и завершает все остальные сессии пользователя. Текущая сессия получает новую пару токенов
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param password body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} models.ChangePasswordResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/password [put]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Неверные данные запроса",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	renewed, err := h.service.ChangeUserPassword(c.Request.Context(), c.GetString("userID"), c.GetString("sessionID"), req.CurrentPassword, req.NewPassword, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCurrentPassword):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Неверный текущий пароль",
+				Message: "Текущий пароль указан неверно",
+			})
+		case errors.Is(err, services.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Слишком слабый пароль",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Пользователь не найден",
+				Message: "Запрашиваемый пользователь не существует",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Не удалось сменить пароль",
+				Message: "Внутренняя ошибка сервера",
+			})
+		}
+		return
+	}
+
+	// Прежний токен доступа отозван вместе с остальными; текущий сеанс
+	// продолжается с новой парой токенов
+	if renewed == nil {
+		c.JSON(http.StatusOK, models.ChangePasswordResponse{
+			Message: "Пароль успешно изменён, войдите заново",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ChangePasswordResponse{
+		Message:      "Пароль успешно изменён, остальные сеансы завершены",
+		Token:        renewed.Token,
+		RefreshToken: renewed.RefreshToken,
+	})
+}
+
 // GetAllDealers получает всех дилеров для франчайзера
 // @Summary Получение всех дилеров
-// @Description Получение всех дилеров франчайзинговой сети (доступно только франчайзеру)
+// @Description Получение всех дилеров франчайзинговой сети (view_all_dealers; с view_assigned_dealers — только назначенные дилеры)
 // @Tags dealers
 // @Security BearerAuth
 // @Produce json
@@ -124,16 +199,6 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse
 // @Router /dealers [get]
 func (h *UserHandler) GetAllDealers(c *gin.Context) {
-	// Извлечение роли пользователя из контекста
-	userRole, exists := c.Get("role")
-	if !exists || userRole != "franchiser" {
-		c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Error:   "Доступ запрещён",
-			Message: "Только франчайзеры могут получить доступ к этому ресурсу",
-		})
-		return
-	}
-
 	// Извлечение ID тенанта из контекста
 	tenantID, exists := c.Get("tenantID")
 	if !exists {
@@ -144,12 +209,8 @@ func (h *UserHandler) GetAllDealers(c *gin.Context) {
 		return
 	}
 
-	dealerType := c.Query("type")
-	if dealerType == "" {
-		dealerType = "dealer" // по умолчанию
-	}
-
-	dealers, err := h.service.GetDealersByTenant(tenantID.(string), dealerType)
+	// Без type возвращаются пользователи всех дилерских ролей
+	dealers, err := h.service.GetDealersByTenant(c.Request.Context(), tenantID.(string), dealerScope(c), c.Query("type"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Не удалось получить дилеров",
@@ -168,7 +229,7 @@ func (h *UserHandler) GetAllDealers(c *gin.Context) {
 
 // GetDealerByID получает конкретного дилера по ID
 // @Summary Получение дилера по ID
-// @Description Получение конкретного дилера по ID (доступно только франчайзеру)
+// @Description Получение конкретного дилера по ID (view_all_dealers; с view_assigned_dealers — только назначенные дилеры)
 // @Tags dealers
 // @Security BearerAuth
 // @Produce json
@@ -180,16 +241,6 @@ func (h *UserHandler) GetAllDealers(c *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse
 // @Router /dealers/{id} [get]
 func (h *UserHandler) GetDealerByID(c *gin.Context) {
-	// Извлечение роли пользователя из контекста
-	userRole, exists := c.Get("role")
-	if !exists || userRole != "franchiser" {
-		c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Error:   "Доступ запрещён",
-			Message: "Только франчайзеры могут получить доступ к этому ресурсу",
-		})
-		return
-	}
-
 	dealerID := c.Param("id")
 
 	// Валидация формата UUID
@@ -201,8 +252,17 @@ func (h *UserHandler) GetDealerByID(c *gin.Context) {
 		return
 	}
 
-	dealer, err := h.service.GetUserByID(dealerID)
+	// Дилеры других тенантов и чужих менеджеров не отличаются от несуществующих
+	dealer, err := h.service.GetDealer(c.Request.Context(), c.GetString("tenantID"), dealerScope(c), dealerID)
 	if err != nil {
+		if errors.Is(err, services.ErrDealerNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Дилер не найден",
+				Message: "Запрашиваемый дилер не существует",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Не удалось получить дилера",
 			Message: "Не удалось загрузить информацию о дилере",
@@ -210,15 +270,98 @@ func (h *UserHandler) GetDealerByID(c *gin.Context) {
 		return
 	}
 
-	if dealer == nil || !strings.EqualFold(dealer.Role, "dealer") {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Дилер не найден",
-			Message: "Запрашиваемый дилер не существует",
+	// Не возвращаем хеш пароля
+	dealer.Password = ""
+	c.JSON(http.StatusOK, dealer)
+}
+
+// GetManagerDealers возвращает дилеров, назначенных менеджеру
+// @Summary Дилеры менеджера
+// @Description Получение дилеров, назначенных менеджеру (требуется разрешение assign_dealers)
+// @Tags managers
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID менеджера"
+// @Success 200 {array} models.User
+// @Failure 404 {object} models.ErrorResponse
+// @Router /managers/{id}/dealers [get]
+func (h *UserHandler) GetManagerDealers(c *gin.Context) {
+	dealers, err := h.service.GetAssignedDealers(c.Request.Context(), c.GetString("tenantID"), c.Param("id"))
+	if err != nil {
+		h.handleAssignmentError(c, err)
+		return
+	}
+
+	// Не возвращаем хеши паролей
+	for i := range dealers {
+		dealers[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, dealers)
+}
+
+// AssignDealers заменяет список дилеров менеджера
+// @Summary Назначение дилеров менеджеру
+// @Description Заменяет дилеров, назначенных менеджеру; изменения действуют сразу (требуется разрешение assign_dealers)
+// @Tags managers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID менеджера"
+// @Param request body models.DealerAssignmentRequest true "ID дилеров"
+// @Success 200 {array} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /managers/{id}/dealers [put]
+func (h *UserHandler) AssignDealers(c *gin.Context) {
+	var req models.DealerAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Неверные данные запроса",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	// Не возвращаем хеш пароля
-	dealer.Password = ""
-	c.JSON(http.StatusOK, dealer)
-}
\ No newline at end of file
+	dealers, err := h.service.AssignDealers(c.Request.Context(), c.GetString("tenantID"), c.Param("id"), req.DealerIDs)
+	if err != nil {
+		h.handleAssignmentError(c, err)
+		return
+	}
+
+	// Не возвращаем хеши паролей
+	for i := range dealers {
+		dealers[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, dealers)
+}
+
+func (h *UserHandler) handleAssignmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidManager):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Менеджер не найден",
+			Message: "Запрашиваемый менеджер не существует или неактивен",
+		})
+	case errors.Is(err, services.ErrInvalidDealers):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Неверный список дилеров",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Не удалось назначить дилеров",
+			Message: "Внутренняя ошибка сервера",
+		})
+	}
+}
+
+// dealerScope возвращает менеджера, чьих дилеров видит пользователь, или
+// пустую строку, если ему доступны все дилеры сети
+func dealerScope(c *gin.Context) string {
+	if middleware.HasPermission(c, models.PermissionViewAllDealers) {
+		return ""
+	}
+	return c.GetString("userID")
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+)
+
+func TestAssignDealersFollowsRolePermissions(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	tenantID := franchiser.User.TenantID
+
+	// Custom roles are told apart by their permissions, not their names
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", franchiser.Token, map[string]any{
+		"name":        "area_lead",
+		"permissions": []string{models.PermissionViewAssignedDealers, models.PermissionReviewChecklists},
+	}, nil)
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", franchiser.Token, map[string]any{
+		"name":        "outlet",
+		"permissions": []string{models.PermissionManageChecklists},
+	}, nil)
+	lead, leadLogin := s.addUser(tenantID, "area_lead")
+	outlet, _ := s.addUser(tenantID, "outlet")
+	dealer, _ := s.addUser(tenantID, models.RoleDealer)
+
+	var assigned []models.User
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/managers/"+lead.ID+"/dealers", franchiser.Token, map[string]any{
+		"dealer_ids": []string{outlet.ID, dealer.ID},
+	}, &assigned)
+	if len(assigned) != 2 {
+		t.Fatalf("assigned dealers: got %d, want 2", len(assigned))
+	}
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/dealers/"+outlet.ID, leadLogin.Token, nil, nil)
+
+	// A dealer does not supervise other dealers, and a supervisor is no dealer
+	s.expect(http.StatusNotFound, http.MethodPut, "/api/v1/managers/"+outlet.ID+"/dealers", franchiser.Token, map[string]any{
+		"dealer_ids": []string{dealer.ID},
+	}, nil)
+	s.expect(http.StatusBadRequest, http.MethodPut, "/api/v1/managers/"+lead.ID+"/dealers", franchiser.Token, map[string]any{
+		"dealer_ids": []string{lead.ID},
+	}, nil)
+}
+
+func TestCustomDealerRoleIsADealer(t *testing.T) {
+	s := newTestServer(t)
+	franchiser := s.register("owner@example.com")
+	tenantID := franchiser.User.TenantID
+
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/roles", franchiser.Token, map[string]any{
+		"name":        "outlet",
+		"permissions": []string{models.PermissionManageChecklists},
+	}, nil)
+	outlet, outletLogin := s.addUser(tenantID, "outlet")
+	dealer, _ := s.addUser(tenantID, models.RoleDealer)
+	s.addUser(tenantID, models.RoleManager)
+
+	var dealers []models.User
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/dealers", franchiser.Token, nil, &dealers)
+	ids := []string{}
+	for _, d := range dealers {
+		ids = append(ids, d.ID)
+	}
+	if len(ids) != 2 || !slices.Contains(ids, outlet.ID) || !slices.Contains(ids, dealer.ID) {
+		t.Fatalf("dealers: got %v, want %s and %s", ids, outlet.ID, dealer.ID)
+	}
+
+	// A template assigned to the network reaches the custom dealer role
+	var template models.ChecklistTemplate
+	s.expect(http.StatusCreated, http.MethodPost, "/api/v1/checklist-templates", franchiser.Token, map[string]any{
+		"name":  "Opening",
+		"tasks": []map[string]any{{"title": "Open the doors"}},
+	}, &template)
+	var templates []models.ChecklistTemplate
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/checklist-templates", outletLogin.Token, nil, &templates)
+	if len(templates) != 1 || templates[0].ID != template.ID {
+		t.Fatalf("templates of the outlet: got %+v", templates)
+	}
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/checklist-templates/"+template.ID, outletLogin.Token, nil, nil)
+}
+
+func TestChangePassword(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("franchiser@example.com")
+	other := s.login("franchiser@example.com")
+
+	change := func(current, next string) map[string]any {
+		return map[string]any{"current_password": current, "new_password": next}
+	}
+	for name, body := range map[string]map[string]any{
+		"wrong current password": change("Wr0ngPassword", "N3wSecurePass"),
+		"unchanged password":     change(testPassword, testPassword),
+		"too short":              change(testPassword, "Sh0rt"),
+		"local part of email":    change(testPassword, "Franchiser"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := s.do(http.MethodPut, "/api/v1/users/password", owner.Token, body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("PUT /users/password: got %d %s, want 400", w.Code, w.Body)
+			}
+		})
+	}
+
+	var changed models.ChangePasswordResponse
+	s.expect(http.StatusOK, http.MethodPut, "/api/v1/users/password", owner.Token, change(testPassword, "N3wSecurePass"), &changed)
+	if changed.Token == "" || changed.RefreshToken == "" {
+		t.Fatalf("change password: got %+v, want a token pair", changed)
+	}
+	if tokenClaims(t, changed.Token).SessionID != tokenClaims(t, owner.Token).SessionID {
+		t.Fatal("the new token belongs to another login")
+	}
+
+	// The login continues with the new pair; the old access token and the
+	// other login are gone
+	s.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/me", changed.Token, nil, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", owner.Token, nil, nil)
+	s.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/me", other.Token, nil, nil)
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": other.RefreshToken,
+	}, nil)
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/refresh", "", map[string]any{
+		"refresh_token": changed.RefreshToken,
+	}, nil)
+
+	s.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", "", map[string]any{
+		"email":    "franchiser@example.com",
+		"password": testPassword,
+	}, nil)
+	s.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", "", map[string]any{
+		"email":    "franchiser@example.com",
+		"password": "N3wSecurePass",
+	}, nil)
+}
@@ -0,0 +1,90 @@
+// Package mailer sends transactional email through SMTP or, for local
+// development, writes it to the log or to files.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates the mailer selected by the mail_driver setting: smtp, file or log
+func New() (Mailer, error) {
+	from := viper.GetString("mail_from")
+	if from == "" {
+		from = "Franchise SaaS <no-reply@localhost>"
+	}
+
+	switch driver := viper.GetString("mail_driver"); driver {
+	case "", "log":
+		return NewLogMailer(from), nil
+	case "file":
+		dir := viper.GetString("mail_dir")
+		if dir == "" {
+			dir = "./mail"
+		}
+		return NewFileMailer(dir, from)
+	case "smtp":
+		port := viper.GetInt("smtp_port")
+		if port == 0 {
+			port = 587
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     viper.GetString("smtp_host"),
+			Port:     port,
+			Username: viper.GetString("smtp_username"),
+			Password: viper.GetString("smtp_password"),
+			From:     from,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+// buildMessage renders msg as an RFC 5322 message with a quoted-printable UTF-8 body
+func buildMessage(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", uuid.New().String(), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is used when the requested language has no templates
+const DefaultLanguage = "ru"
+
+// Template names
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+	TemplateInvitation        = "invitation"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates maps "name.lang" to a parsed template defining "subject" and "body"
+var templates = mustLoadTemplates()
+
+func mustLoadTemplates() map[string]*template.Template {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		key := strings.TrimSuffix(file.Name(), ".tmpl")
+		result[key] = template.Must(template.ParseFS(templateFS, "templates/"+file.Name()))
+	}
+
+	return result
+}
+
+// Render builds a message from the named template in the given language,
+// falling back to DefaultLanguage
+func Render(name, lang, to string, data any) (Message, error) {
+	tmpl, ok := templates[name+"."+lang]
+	if !ok {
+		tmpl, ok = templates[name+"."+DefaultLanguage]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
+
+// MatchLanguage picks the first supported language from an Accept-Language header
+func MatchLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if lang == "ru" || lang == "en" {
+			return lang
+		}
+	}
+
+	return DefaultLanguage
+}
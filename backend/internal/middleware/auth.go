@@ -1,19 +1,112 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+	"franchise-saas-backend/internal/tokens"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/spf13/viper"
+	"github.com/google/uuid"
 )
 
-// AuthMiddleware validates the JWT token in the Authorization header
-func AuthMiddleware() gin.HandlerFunc {
+// TokenValidator rejects, with tokens.ErrTokenRevoked, tokens issued before
+// the user's last password or role change and tokens of revoked logins
+type TokenValidator interface {
+	ValidateTokenVersion(ctx context.Context, userID string, version int) error
+	ValidateSession(ctx context.Context, userID, sessionID string) error
+}
+
+// APIKeyAuthenticator looks up the API key a request was made with; it
+// returns nil for keys that are unknown or no longer valid
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// TenantChecker reports whether a tenant exists
+type TenantChecker interface {
+	TenantExists(ctx context.Context, tenantID string) (bool, error)
+}
+
+// PermissionResolver returns the effective permissions of a role in a tenant
+type PermissionResolver interface {
+	ResolvePermissions(ctx context.Context, tenantID, role string) ([]string, error)
+}
+
+// APICallCounter counts the API calls of a tenant. It returns the calls made
+// this month, including this one, and the monthly limit.
+type APICallCounter interface {
+	CountAPICall(ctx context.Context, tenantID string) (int64, int64, error)
+}
+
+// FeatureChecker reports whether a module is switched on for a tenant
+type FeatureChecker interface {
+	FeatureEnabled(ctx context.Context, tenantID, feature string) (bool, error)
+}
+
+// HostResolver maps the host of a request to the tenant that verified it as
+// its custom domain
+type HostResolver interface {
+	ResolveHost(ctx context.Context, host string) (string, error)
+	OriginAllowed(ctx context.Context, origin, hostTenantID string) bool
+}
+
+// ActionRecorder stores audit entries
+type ActionRecorder interface {
+	RecordAction(ctx context.Context, entry models.AuditEntry) error
+}
+
+// HostMiddleware sets hostTenantID when the request was made to a tenant's
+// custom domain, so that sign-in and the public branding know the network
+// before anyone is authenticated. It must run before the CORS middleware.
+func HostMiddleware(hosts HostResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := hosts.ResolveHost(c.Request.Context(), c.Request.Host)
+		if err != nil {
+			// The platform's own domain keeps working without the lookup
+			log.Printf("failed to resolve host %s: %v", c.Request.Host, err)
+		}
+		if tenantID != "" {
+			c.Set("hostTenantID", tenantID)
+		}
+
+		// Continue to the next handler
+		c.Next()
+	}
+}
+
+// AllowedOrigin is the CORS origin check for the verified domains of
+// tenants; on a tenant's domain only that tenant's domains are allowed
+func AllowedOrigin(hosts HostResolver) func(c *gin.Context, origin string) bool {
+	return func(c *gin.Context, origin string) bool {
+		return hosts.OriginAllowed(c.Request.Context(), origin, c.GetString("hostTenantID"))
+	}
+}
+
+// AuthMiddleware validates the access token in the Authorization header.
+// For impersonation tokens it also sets actorID and actorEmail to the
+// superadmin acting as the user. Integrations authenticate with an API key
+// instead, sent as "Authorization: ApiKey <key>" or in X-API-Key.
+func AuthMiddleware(issuer *tokens.Issuer, validator TokenValidator, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			authenticateAPIKey(c, apiKeys, key)
+			return
+		}
+		if len(authHeader) >= 7 && strings.EqualFold(authHeader[0:7], "APIKEY ") {
+			authenticateAPIKey(c, apiKeys, strings.TrimSpace(authHeader[7:]))
+			return
+		}
+
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "Authorization header required",
@@ -37,77 +130,210 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
+		claims, err := issuer.ParseAccess(tokenString)
+		if err != nil {
+			message := "The provided token is invalid or expired"
+			if errors.Is(err, tokens.ErrWrongTokenType) {
+				message = "The provided token is not an access token"
 			}
 
-			// Return the secret key
-			return []byte(viper.GetString("jwt_secret")), nil
-		})
-
-		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "Invalid token",
-				"message": "The provided token is invalid or expired",
+				"message": message,
 			})
 			c.Abort()
 			return
 		}
 
-		// Extract claims if token is valid
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Extract user information from claims
-			userID, ok := claims["user_id"].(string)
-			if !ok {
+		// Tokens issued before a password or role change are no longer valid;
+		// an impersonation token also ends when the superadmin's changes.
+		// Tokens of a login that was signed out end with it.
+		err = validator.ValidateTokenVersion(c.Request.Context(), claims.UserID, claims.TokenVersion)
+		if err == nil && claims.Actor != nil {
+			err = validator.ValidateTokenVersion(c.Request.Context(), claims.Actor.UserID, claims.Actor.TokenVersion)
+		}
+		if err == nil && claims.SessionID != "" {
+			err = validator.ValidateSession(c.Request.Context(), claims.UserID, claims.SessionID)
+		}
+		if err != nil {
+			if errors.Is(err, tokens.ErrTokenRevoked) {
 				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "Invalid token claims",
-					"message": "Token does not contain valid user ID",
+					"error":   "Invalid token",
+					"message": "The token was revoked, please sign in again",
 				})
-				c.Abort()
-				return
-			}
-
-			userEmail, ok := claims["email"].(string)
-			if !ok {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "Invalid token claims",
-					"message": "Token does not contain valid email",
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "Authentication failed",
+					"message": "Could not validate the token",
 				})
-				c.Abort()
-				return
 			}
+			c.Abort()
+			return
+		}
 
-			userRole, ok := claims["role"].(string)
-			if !ok {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "Invalid token claims",
-					"message": "Token does not contain valid role",
+		// Set user info in context for use by handlers
+		c.Set("userID", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("tenantID", claims.TenantID)
+
+		// Session ID identifies the login; legacy tokens may not carry it
+		if claims.SessionID != "" {
+			c.Set("sessionID", claims.SessionID)
+		}
+
+		if claims.Actor != nil {
+			c.Set("actorID", claims.Actor.UserID)
+			c.Set("actorEmail", claims.Actor.Email)
+		}
+
+		// Continue to the next handler
+		c.Next()
+	}
+}
+
+// authenticateAPIKey sets the context of a request made with an API key. The
+// key acts as a service principal: userID is the ID of the key, role is
+// api_key and the permissions are the scopes of the key.
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, key string) {
+	apiKey, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Authentication failed",
+			"message": "Could not validate the API key",
+		})
+		c.Abort()
+		return
+	}
+	if apiKey == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid API key",
+			"message": "The provided API key is invalid, expired or revoked",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", apiKey.ID)
+	c.Set("role", models.RoleAPIKey)
+	c.Set("tenantID", apiKey.TenantID)
+	c.Set("apiKeyID", apiKey.ID)
+	c.Set("permissions", apiKey.Scopes)
+
+	// Continue to the next handler
+	c.Next()
+}
+
+// RequireUser rejects requests made with an API key, for endpoints that act
+// on the signed-in user's own account
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Not allowed for API keys",
+				"message": "This endpoint requires a signed-in user",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TenantMiddleware scopes the request to the tenant of the access token. A
+// superadmin can act inside any existing tenant by sending its ID in the
+// X-Tenant-ID header; actorID is then set to the superadmin. The tenant is put
+// into the request context, where the PostgreSQL store picks it up and sets
+// app.tenant_id for the row-level security policies.
+func TenantMiddleware(tenants TenantChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract tenant ID from context (set by AuthMiddleware)
+		tenantID := c.GetString("tenantID")
+		if _, err := uuid.Parse(tenantID); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Tenant information missing",
+				"message": "User does not belong to any tenant",
+			})
+			c.Abort()
+			return
+		}
+
+		if override := c.GetHeader("X-Tenant-ID"); override != "" && override != tenantID {
+			if c.GetString("role") != models.RoleSuperadmin {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":   "Insufficient permissions",
+					"message": "Only a superadmin can act in another tenant",
 				})
 				c.Abort()
 				return
 			}
 
-			tenantID, ok := claims["tenant_id"].(string)
-			if !ok {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "Invalid token claims",
-					"message": "Token does not contain valid tenant ID",
+			exists := false
+			if _, err := uuid.Parse(override); err == nil {
+				var checkErr error
+				exists, checkErr = tenants.TenantExists(c.Request.Context(), override)
+				if checkErr != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{
+						"error":   "Tenant check failed",
+						"message": "Could not load the requested tenant",
+					})
+					c.Abort()
+					return
+				}
+			}
+			if !exists {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error":   "Tenant not found",
+					"message": "The tenant in X-Tenant-ID does not exist",
 				})
 				c.Abort()
 				return
 			}
 
-			// Set user info in context for use by handlers
-			c.Set("userID", userID)
-			c.Set("email", userEmail)
-			c.Set("role", userRole)
+			tenantID = override
 			c.Set("tenantID", tenantID)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Invalid token",
-				"message": "The provided token could not be validated",
+			c.Set("actorID", c.GetString("userID"))
+			c.Set("actorEmail", c.GetString("email"))
+		}
+
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), tenantID))
+
+		// Continue to the next handler
+		c.Next()
+	}
+}
+
+// QuotaMiddleware counts every request against the monthly API call limit of
+// the tenant and rejects requests beyond it with 429. Requests a superadmin
+// makes in the tenant or as one of its users are not counted. It must run
+// after TenantMiddleware.
+func QuotaMiddleware(counter APICallCounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("actorID") != "" {
+			c.Next()
+			return
+		}
+
+		used, limit, err := counter.CountAPICall(c.Request.Context(), c.GetString("tenantID"))
+		if err != nil {
+			// A failing counter store should not take the API down with it
+			log.Printf("failed to count API call of tenant %s: %v", c.GetString("tenantID"), err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(limit-used, 0), 10))
+
+		if used > limit {
+			now := time.Now().UTC()
+			nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			c.Header("Retry-After", strconv.FormatInt(int64(nextMonth.Sub(now).Seconds())+1, 10))
+			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Error:   "API call limit reached",
+				Message: "The network has used all API calls of its plan for this month",
+				Code:    models.ErrorCodeAPICallLimit,
 			})
 			c.Abort()
 			return
@@ -118,25 +344,25 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RoleMiddleware checks if the user has the required role
-func RoleMiddleware(requiredRole string) gin.HandlerFunc {
+// FeatureMiddleware rejects requests to a module the tenant has not switched
+// on with 403. It must run after TenantMiddleware.
+func FeatureMiddleware(checker FeatureChecker, feature string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First, ensure the user is authenticated
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Authentication required",
-				"message": "User not authenticated",
+		enabled, err := checker.FeatureEnabled(c.Request.Context(), c.GetString("tenantID"), feature)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Feature check failed",
+				"message": "Could not load the features of the network",
 			})
 			c.Abort()
 			return
 		}
 
-		// Check if the user has the required role
-		if role.(string) != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Insufficient permissions",
-				"message": "User does not have the required role: " + requiredRole,
+		if !enabled {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Feature disabled",
+				Message: "The " + feature + " module is not enabled for the network",
+				Code:    models.ErrorCodeFeature,
 			})
 			c.Abort()
 			return
@@ -147,39 +373,61 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 	}
 }
 
-// TenantMiddleware ensures the user belongs to the correct tenant
-func TenantMiddleware() gin.HandlerFunc {
+// AuditMiddleware records every request made through an impersonation token
+// or inside another tenant, with both the real and the effective identity.
+// It must run before TenantMiddleware so that it sees the outcome of both.
+func AuditMiddleware(recorder ActionRecorder) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract tenant ID from context (set by AuthMiddleware)
-		tenantID, exists := c.Get("tenantID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "Tenant information missing",
-				"message": "User does not belong to any tenant",
-			})
-			c.Abort()
+		c.Next()
+
+		actorID := c.GetString("actorID")
+		if actorID == "" {
 			return
 		}
 
-		// You can add additional tenant validation logic here if needed
-		// For example, checking if the tenant is active, etc.
+		entry := models.AuditEntry{
+			ActorID:   actorID,
+			UserID:    c.GetString("userID"),
+			TenantID:  c.GetString("tenantID"),
+			Action:    models.AuditActionRequest,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
 
-		// Set tenant ID in context for use by handlers
-		c.Set("tenantID", tenantID)
+		// The response is already written, so a failure can only be logged
+		if err := recorder.RecordAction(c.Request.Context(), entry); err != nil {
+			log.Printf("failed to record audit entry for %s %s by %s: %v", entry.Method, entry.Path, actorID, err)
+		}
+	}
+}
+
+// ForbidImpersonation rejects impersonation tokens, for endpoints that change
+// the user's credentials or sign-in settings
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("actorID") != "" && c.GetString("actorID") != c.GetString("userID") {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Not allowed while impersonating",
+				"message": "This action can only be taken by the user themselves",
+			})
+			c.Abort()
+			return
+		}
 
-		// Continue to the next handler
 		c.Next()
 	}
 }
 
-// PermissionMiddleware checks if the user has the required permission
-func PermissionMiddleware(permission string) gin.HandlerFunc {
-	// This is a simplified version - in a real application you might want to
-	// implement a more sophisticated permission system
+// PermissionMiddleware rejects users whose role has none of the permissions.
+// The effective permissions are resolved once per request and kept in the
+// context under "permissions" for later checks and for HasPermission.
+func PermissionMiddleware(resolver PermissionResolver, permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract user role from context
-		role, exists := c.Get("role")
-		if !exists {
+		role := c.GetString("role")
+		if role == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "Authentication required",
 				"message": "User not authenticated",
@@ -188,22 +436,23 @@ func PermissionMiddleware(permission string) gin.HandlerFunc {
 			return
 		}
 
-		// Simple role-based permissions mapping
-		allowedRoles := getRolesForPermission(permission)
-		currentRole := role.(string)
-
-		isAllowed := false
-		for _, allowedRole := range allowedRoles {
-			if currentRole == allowedRole {
-				isAllowed = true
-				break
+		if _, cached := c.Get("permissions"); !cached {
+			resolved, err := resolver.ResolvePermissions(c.Request.Context(), c.GetString("tenantID"), role)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "Permission check failed",
+					"message": "Could not load the permissions of the user",
+				})
+				c.Abort()
+				return
 			}
+			c.Set("permissions", resolved)
 		}
 
-		if !isAllowed {
+		if !slices.ContainsFunc(permissions, func(permission string) bool { return HasPermission(c, permission) }) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"error":   "Insufficient permissions",
-				"message": "User does not have permission: " + permission,
+				"message": "User does not have permission: " + strings.Join(permissions, " or "),
 			})
 			c.Abort()
 			return
@@ -214,16 +463,10 @@ func PermissionMiddleware(permission string) gin.HandlerFunc {
 	}
 }
 
-// Helper function to define role-permission mapping
-func getRolesForPermission(permission string) []string {
-	switch permission {
-	case "manage_checklists":
-		return []string{"franchiser", "manager", "dealer"}
-	case "view_all_dealers":
-		return []string{"franchiser"}
-	case "manage_tenant":
-		return []string{"franchiser"}
-	default:
-		return []string{} // No roles have this permission by default
-	}
+// HasPermission reports whether the permissions resolved by
+// PermissionMiddleware for this request include permission
+func HasPermission(c *gin.Context, permission string) bool {
+	permissions, _ := c.Get("permissions")
+	granted, _ := permissions.([]string)
+	return slices.Contains(granted, permission)
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, so that leaked keys are easy to spot
+const APIKeyPrefix = "fsk_"
+
+// APIKey is a credential for server-to-server integrations of a tenant. The
+// key itself is shown once on creation; only its hash is stored.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	TenantID   string     `json:"tenant_id" db:"tenant_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // first characters of the key, to tell keys apart
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"` // permissions the key grants
+	CreatedBy  string     `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsActive reports whether the key can still be used at the given time
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyCreateRequest represents the data needed to create an API key; a key
+// without expires_at does not expire
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyCreateResponse carries the only copy of the new key
+type APIKeyCreateResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyScopes lists the permissions an API key can be granted. A key is not
+// a person: it has no checklists or assigned dealers, cannot sign off work or
+// invite anyone, and cannot manage roles or other keys.
+var APIKeyScopes = []string{
+	PermissionManageTenant,
+	PermissionViewAllDealers,
+	PermissionAssignDealers,
+	PermissionManageDealerSessions,
+}
+
+// IsAPIKeyScope reports whether an API key can be granted the permission
+func IsAPIKeyScope(permission string) bool {
+	return slices.Contains(APIKeyScopes, permission)
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Audit log actions
+const (
+	// AuditActionRequest is an API request made as another user or in another tenant
+	AuditActionRequest = "request"
+	// AuditActionImpersonationStarted is the issue of an impersonation token
+	AuditActionImpersonationStarted = "impersonation_started"
+)
+
+// AuditEntry records an action taken with an identity other than the caller's
+// own: through an impersonation token or inside another tenant via X-Tenant-ID
+type AuditEntry struct {
+	ID        string    `json:"id" db:"id"`
+	ActorID   string    `json:"actor_id" db:"actor_id"`       // the superadmin who really acted
+	UserID    string    `json:"user_id" db:"user_id"`         // the user the action was taken as
+	TenantID  string    `json:"tenant_id" db:"tenant_id"`     // the tenant the action was taken in
+	Action    string    `json:"action" db:"action"`           // request, impersonation_started
+	Method    string    `json:"method,omitempty" db:"method"` // HTTP method of a request
+	Path      string    `json:"path,omitempty" db:"path"`
+	Status    int       `json:"status,omitempty" db:"status"` // HTTP status of the response
+	Reason    string    `json:"reason,omitempty" db:"reason"` // why impersonation was started
+	IPAddress string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent string    `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter represents the filter options for listing the audit log
+type AuditFilter struct {
+	ActorID  string
+	TenantID string
+	Limit    int
+	Offset   int
+}
+
+// ImpersonationRequest represents a superadmin's request to act as a user
+type ImpersonationRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonationResponse carries the impersonation token; it cannot be refreshed
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
@@ -4,13 +4,25 @@ import "time"
 
 // Task represents a single task within a checklist
 type Task struct {
-	ID          string    `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description,omitempty" db:"description"`
-	Status      string    `json:"status" db:"status"` // pending, in_progress, completed
-	Order       int       `json:"order" db:"order"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string     `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description,omitempty" db:"description"`
+	Category    string     `json:"category,omitempty" db:"category"`
+	Priority    string     `json:"priority,omitempty" db:"priority"` // low, medium, high
+	Status      string     `json:"status" db:"status"`               // pending, in_progress, completed
+	Order       int        `json:"order" db:"position"`
+	Deadline    *time.Time `json:"deadline,omitempty" db:"deadline"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Set by a manager reviewing the checklist; the owner cannot change them
+	VerifiedAt    *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	VerifiedBy    string     `json:"verified_by,omitempty" db:"verified_by"`
+	ReviewComment string     `json:"review_comment,omitempty" db:"review_comment"`
+
+	// Proof the task asks for, set by the template it came from
+	Verification string `json:"verification,omitempty" db:"verification"`
 }
 
 // Checklist represents a checklist with multiple tasks
@@ -20,19 +32,25 @@ type Checklist struct {
 	Description string    `json:"description,omitempty" db:"description"`
 	UserID      string    `json:"user_id" db:"user_id"`
 	TenantID    string    `json:"tenant_id" db:"tenant_id"`
+	Date        time.Time `json:"date" db:"date"`
 	Status      string    `json:"status" db:"status"` // pending, in_progress, completed
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	Tasks       []Task    `json:"tasks" db:"tasks"`
 	KPIScore    float64   `json:"kpi_score" db:"kpi_score"`
+
+	// Set when the checklist was made from a template
+	TemplateID      string `json:"template_id,omitempty" db:"template_id"`
+	TemplateVersion int    `json:"template_version,omitempty" db:"template_version"`
 }
 
 // ChecklistCreateRequest represents the data needed to create a checklist
 type ChecklistCreateRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description"`
+	Title       string    `json:"title" validate:"required"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
 	CreatedAt   time.Time `json:"created_at"`
-	Tasks       []Task `json:"tasks"`
+	Tasks       []Task    `json:"tasks"`
 }
 
 // ChecklistUpdateRequest represents the data needed to update a checklist
@@ -43,6 +61,13 @@ type ChecklistUpdateRequest struct {
 	Tasks       []Task `json:"tasks,omitempty"`
 }
 
+// TaskReviewRequest represents a manager's review of a task. Verified
+// verifies the task or withdraws the verification; an empty comment removes it.
+type TaskReviewRequest struct {
+	Verified *bool   `json:"verified,omitempty"`
+	Comment  *string `json:"comment,omitempty" binding:"omitempty,max=2000"`
+}
+
 // ChecklistFilter represents the filter options for retrieving checklists
 type ChecklistFilter struct {
 	UserID   string
@@ -52,4 +77,4 @@ type ChecklistFilter struct {
 	DateTo   time.Time
 	Page     int
 	Limit    int
-}
\ No newline at end of file
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Proof a task of a template asks for before it counts as done
+const (
+	TaskVerificationPhoto   = "photo"   // the dealer attaches a photo
+	TaskVerificationComment = "comment" // the dealer describes the result
+	TaskVerificationManager = "manager" // a reviewer has to verify the task
+)
+
+// How urgent a task of a template is
+const (
+	TaskPriorityLow    = "low"
+	TaskPriorityMedium = "medium" // the default
+	TaskPriorityHigh   = "high"
+)
+
+// MaxDeadlineOffset is the last minute of the day a task can be due at
+const MaxDeadlineOffset = 24*60 - 1
+
+// Whom a template is assigned to
+const (
+	TemplateScopeAll     = "all"     // every dealer of the network
+	TemplateScopeCity    = "city"    // the dealers of one city
+	TemplateScopeDealers = "dealers" // the listed dealers
+)
+
+// How often the scheduler makes checklists from a template
+const (
+	RecurrenceDaily    = "daily"
+	RecurrenceWeekdays = "weekdays" // Monday to Friday
+	RecurrenceWeekly   = "weekly"
+	RecurrenceMonthly  = "monthly"
+)
+
+// ChecklistTemplate is a reusable checklist authored by the franchiser.
+// Name, description and tasks are those of the current version; every change
+// to them creates a new version, so checklists keep pointing at the version
+// they were made from.
+type ChecklistTemplate struct {
+	ID          string             `json:"id" db:"id"`
+	TenantID    string             `json:"tenant_id" db:"tenant_id"`
+	Name        string             `json:"name" db:"name"`
+	Description string             `json:"description,omitempty" db:"description"`
+	Version     int                `json:"version" db:"version"`
+	Tasks       []TemplateTask     `json:"tasks" db:"tasks"`
+	Assignment  TemplateAssignment `json:"assignment" db:"assignment"`
+	CreatedBy   string             `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+
+	// Recurrence is nil for templates dealers only use by hand
+	Recurrence *TemplateRecurrence `json:"recurrence,omitempty" db:"recurrence"`
+}
+
+// ChecklistTemplateVersion is the content of a template as it was at one version
+type ChecklistTemplateVersion struct {
+	TemplateID  string         `json:"template_id" db:"template_id"`
+	Version     int            `json:"version" db:"version"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description,omitempty" db:"description"`
+	Tasks       []TemplateTask `json:"tasks" db:"tasks"`
+	CreatedBy   string         `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// TemplateTask is a task of a template; tasks are ordered as listed
+type TemplateTask struct {
+	Title       string `json:"title" binding:"required,max=500"`
+	Description string `json:"description,omitempty" binding:"max=2000"`
+	Category    string `json:"category,omitempty" binding:"max=100"`
+	Priority    string `json:"priority,omitempty" binding:"omitempty,oneof=low medium high"`
+	// DeadlineOffset is the time of day the task is due, in minutes after
+	// midnight in the network's timezone
+	DeadlineOffset *int   `json:"deadline_offset_minutes,omitempty" binding:"omitempty,min=0,max=1439"`
+	Verification   string `json:"verification,omitempty" binding:"omitempty,oneof=photo comment manager"`
+}
+
+// TemplateAssignment names the dealers a template is meant for
+type TemplateAssignment struct {
+	Scope     string   `json:"scope" binding:"omitempty,oneof=all city dealers"`
+	City      string   `json:"city,omitempty" binding:"max=255"`
+	DealerIDs []string `json:"dealer_ids,omitempty" binding:"dive,uuid"`
+}
+
+// Includes reports whether the template is assigned to the user; isDealer
+// tells whether the user's role is a dealer role, templates are only for dealers
+func (a TemplateAssignment) Includes(dealer *User, isDealer bool) bool {
+	if !isDealer {
+		return false
+	}
+
+	switch a.Scope {
+	case TemplateScopeCity:
+		return dealer.City != "" && strings.EqualFold(strings.TrimSpace(dealer.City), strings.TrimSpace(a.City))
+	case TemplateScopeDealers:
+		return slices.Contains(a.DealerIDs, dealer.ID)
+	default:
+		return true
+	}
+}
+
+// TemplateRecurrence names the days on which the scheduler makes the
+// checklists of the assigned dealers from a template
+type TemplateRecurrence struct {
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekdays weekly monthly"`
+	Weekday   int    `json:"weekday,omitempty" binding:"omitempty,min=1,max=7"`    // weekly: 1 for Monday to 7 for Sunday
+	MonthDay  int    `json:"month_day,omitempty" binding:"omitempty,min=1,max=31"` // monthly: shorter months use their last day
+}
+
+// Due reports whether the template recurs on the calendar date
+func (r *TemplateRecurrence) Due(date time.Time) bool {
+	if r == nil {
+		return false
+	}
+
+	switch r.Frequency {
+	case RecurrenceDaily:
+		return true
+	case RecurrenceWeekdays:
+		return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday
+	case RecurrenceWeekly:
+		weekday := int(date.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		return weekday == r.Weekday
+	case RecurrenceMonthly:
+		lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return date.Day() == min(r.MonthDay, lastDay)
+	}
+	return false
+}
+
+// ChecklistTemplateRequest represents a new template or the new content of a
+// template; it replaces the stored one as a whole
+type ChecklistTemplateRequest struct {
+	Name        string              `json:"name" binding:"required,max=255"`
+	Description string              `json:"description" binding:"max=2000"`
+	Tasks       []TemplateTask      `json:"tasks" binding:"required,min=1,max=100,dive"`
+	Assignment  TemplateAssignment  `json:"assignment"`
+	Recurrence  *TemplateRecurrence `json:"recurrence,omitempty"`
+}
+
+// ChecklistFromTemplateRequest represents the day a checklist is made for;
+// today in the network's timezone when empty
+type ChecklistFromTemplateRequest struct {
+	Date string `json:"date" binding:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// DomainVerificationPrefix is prepended to a domain to name the TXT record
+// that proves the tenant controls it
+const DomainVerificationPrefix = "_franchise-verification."
+
+// TenantDomain is a custom domain a franchise network serves the app from.
+// Only verified domains resolve to the tenant.
+type TenantDomain struct {
+	ID                string     `json:"id" db:"id"`
+	TenantID          string     `json:"tenant_id" db:"tenant_id"`
+	Domain            string     `json:"domain" db:"domain"` // lowercase host name without port
+	VerificationToken string     `json:"verification_token" db:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsVerified reports whether the tenant has proven it controls the domain
+func (d *TenantDomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// VerificationRecord is the name of the TXT record that must hold the token
+func (d *TenantDomain) VerificationRecord() string {
+	return DomainVerificationPrefix + d.Domain
+}
+
+// TenantDomainCreateRequest represents the data needed to add a custom domain
+type TenantDomainCreateRequest struct {
+	Domain string `json:"domain" binding:"required,max=253"`
+}
+
+// TenantDomainResponse is a domain together with the DNS record to create
+type TenantDomainResponse struct {
+	TenantDomain
+	VerificationRecord string `json:"verification_record"`
+}
+
+// PublicBranding is what the login page of a custom domain shows before anyone signs in
+type PublicBranding struct {
+	Slug string `json:"slug"`
+	BrandingSettings
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Holiday is a non-working day of a tenant; the scheduler makes no
+// checklists on it
+type Holiday struct {
+	TenantID  string    `json:"tenant_id" db:"tenant_id"`
+	Date      time.Time `json:"date" db:"date"`
+	Name      string    `json:"name,omitempty" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// HolidayRequest represents a day to add to the holiday calendar
+type HolidayRequest struct {
+	Date string `json:"date" binding:"required,datetime=2006-01-02"`
+	Name string `json:"name" binding:"max=255"`
+}
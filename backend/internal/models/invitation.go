@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationExpired  = "expired"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+)
+
+// Invitation lets a franchiser bring a dealer or manager into the tenant, or
+// a superadmin invite the franchiser of a new tenant
+type Invitation struct {
+	ID             string     `json:"id" db:"id"`
+	TenantID       string     `json:"tenant_id" db:"tenant_id"`
+	Email          string     `json:"email" db:"email"`
+	Role           string     `json:"role" db:"role"` // a role of the tenant; franchiser for tenant owners
+	ManagerID      string     `json:"manager_id,omitempty" db:"manager_id"`
+	FirstName      string     `json:"first_name,omitempty" db:"first_name"`
+	LastName       string     `json:"last_name,omitempty" db:"last_name"`
+	InvitedBy      string     `json:"invited_by,omitempty" db:"invited_by"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	AcceptedUserID string     `json:"accepted_user_id,omitempty" db:"accepted_user_id"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Status         string     `json:"status" db:"-"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsOpen reports whether the invitation was neither accepted nor revoked
+func (i *Invitation) IsOpen() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil
+}
+
+// CurrentStatus derives the status from the timestamps
+func (i *Invitation) CurrentStatus(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case now.After(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// InvitationCreateRequest represents the data needed to invite a user
+type InvitationCreateRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Role      string `json:"role" binding:"required,max=50"` // any role of the tenant except franchiser
+	ManagerID string `json:"manager_id"`                     // only for dealers
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// InvitationAcceptRequest represents the data needed to accept an invitation
+type InvitationAcceptRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+	City      string `json:"city" binding:"max=255"`
+}
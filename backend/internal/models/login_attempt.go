@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Login failure reasons
+const (
+	LoginFailedInvalidCredentials = "invalid_credentials"
+	LoginFailedUserInactive       = "user_inactive"
+	LoginFailedThrottled          = "throttled"
+	LoginFailedInvalidTwoFactor   = "invalid_two_factor"
+	LoginFailedTenantSuspended    = "tenant_suspended"
+	LoginFailedWrongDomain        = "wrong_domain"
+)
+
+// LoginAttempt records a completed or failed sign-in
+type LoginAttempt struct {
+	ID            string    `json:"id" db:"id"`
+	UserID        string    `json:"user_id,omitempty" db:"user_id"` // empty for unknown emails
+	Email         string    `json:"email" db:"email"`
+	IPAddress     string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent     string    `json:"user_agent,omitempty" db:"user_agent"`
+	Success       bool      `json:"success" db:"success"`
+	FailureReason string    `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
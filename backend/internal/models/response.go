@@ -5,15 +5,25 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
-// ErrorResponse represents an error response
+// ErrorResponse represents an error response; Code is set for errors clients
+// are expected to handle, such as a reached plan limit
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
 
+// Machine-readable error codes
+const (
+	ErrorCodeUserLimit    = "user_limit_reached"
+	ErrorCodeDealerLimit  = "dealer_limit_reached"
+	ErrorCodeAPICallLimit = "api_call_limit_reached"
+	ErrorCodeFeature      = "feature_disabled"
+)
+
 // RefreshTokenRequest represents the data needed for token refresh
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" validate:"required"`
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // PaginationResponse represents pagination metadata
@@ -22,4 +32,4 @@ type PaginationResponse struct {
 	Limit      int `json:"limit"`
 	Total      int `json:"total"`
 	TotalPages int `json:"total_pages"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Built-in roles. Superadmin is a platform role; the others exist in every
+// tenant. RoleAPIKey is the role of requests authenticated with an API key.
+const (
+	RoleSuperadmin = "superadmin"
+	RoleAPIKey     = "api_key"
+	RoleFranchiser = "franchiser"
+	RoleManager    = "manager"
+	RoleDealer     = "dealer"
+)
+
+// Permissions checked by the API
+const (
+	PermissionManageTenant         = "manage_tenant"
+	PermissionManageRoles          = "manage_roles"
+	PermissionManageAPIKeys        = "manage_api_keys"
+	PermissionManageInvitations    = "manage_invitations"
+	PermissionViewAllDealers       = "view_all_dealers"
+	PermissionViewAssignedDealers  = "view_assigned_dealers"
+	PermissionAssignDealers        = "assign_dealers"
+	PermissionManageDealerSessions = "manage_dealer_sessions"
+	PermissionManageChecklists     = "manage_checklists"
+	PermissionReviewChecklists     = "review_checklists"
+	PermissionManageTemplates      = "manage_checklist_templates"
+
+	// PermissionManagePlatform is held by superadmins only and cannot be
+	// granted to a tenant role
+	PermissionManagePlatform = "manage_platform"
+)
+
+// Permission describes a permission that tenant roles can be granted
+type Permission struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalogue lists every permission tenant roles can be granted
+var PermissionCatalogue = []Permission{
+	{Code: PermissionManageTenant, Description: "Change the network's name, city, branding and features"},
+	{Code: PermissionManageRoles, Description: "Create, change and delete roles"},
+	{Code: PermissionManageAPIKeys, Description: "Create and revoke API keys for integrations"},
+	{Code: PermissionManageInvitations, Description: "Invite users and manage open invitations"},
+	{Code: PermissionViewAllDealers, Description: "See every dealer of the network"},
+	{Code: PermissionViewAssignedDealers, Description: "See the dealers assigned to you"},
+	{Code: PermissionAssignDealers, Description: "Assign dealers to managers"},
+	{Code: PermissionManageDealerSessions, Description: "See and end the sessions of dealers"},
+	{Code: PermissionManageChecklists, Description: "Keep daily checklists"},
+	{Code: PermissionReviewChecklists, Description: "Verify tasks and comment on the checklists of dealers you can see"},
+	{Code: PermissionManageTemplates, Description: "Author checklist templates and assign them to dealers"},
+}
+
+// Role is a named set of permissions within a tenant. Users refer to their
+// role by name.
+type Role struct {
+	ID          string    `json:"id" db:"id"`
+	TenantID    string    `json:"tenant_id" db:"tenant_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	BuiltIn     bool      `json:"built_in" db:"built_in"` // franchiser, manager, dealer; cannot be deleted
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RoleCreateRequest represents the data needed to define a custom role
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// RoleUpdateRequest represents the changes to a role; the name cannot change
+// because users refer to it. Permissions are replaced as a whole when present.
+type RoleUpdateRequest struct {
+	Description *string  `json:"description,omitempty" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+var roleNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// IsValidRoleName reports whether name can name a role: lowercase latin
+// letters, digits and underscores, starting with a letter
+func IsValidRoleName(name string) bool {
+	return roleNameRe.MatchString(name)
+}
+
+// IsKnownPermission reports whether code is in the permission catalogue
+func IsKnownPermission(code string) bool {
+	return slices.ContainsFunc(PermissionCatalogue, func(p Permission) bool {
+		return p.Code == code
+	})
+}
+
+// AllTenantPermissions returns the code of every permission in the catalogue
+func AllTenantPermissions() []string {
+	codes := make([]string, len(PermissionCatalogue))
+	for i, p := range PermissionCatalogue {
+		codes[i] = p.Code
+	}
+	return codes
+}
+
+// DefaultRoles returns the built-in roles a new tenant starts with
+func DefaultRoles(tenantID string) []Role {
+	return []Role{
+		{
+			TenantID:    tenantID,
+			Name:        RoleFranchiser,
+			Description: "Owner of the franchise network",
+			Permissions: AllTenantPermissions(),
+			BuiltIn:     true,
+		},
+		{
+			TenantID:    tenantID,
+			Name:        RoleManager,
+			Description: "Manages a group of dealers",
+			Permissions: []string{PermissionManageChecklists, PermissionViewAssignedDealers, PermissionReviewChecklists},
+			BuiltIn:     true,
+		},
+		{
+			TenantID:    tenantID,
+			Name:        RoleDealer,
+			Description: "Runs a franchise outlet",
+			Permissions: []string{PermissionManageChecklists},
+			BuiltIn:     true,
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Session represents one refresh token of a signed-in user. Every refresh
+// rotates the token into a new row of the same family; the family ID
+// identifies the login and is carried in access tokens as the session ID.
+type Session struct {
+	ID              string     `json:"id" db:"id"`
+	UserID          string     `json:"user_id" db:"user_id"`
+	FamilyID        string     `json:"family_id" db:"family_id"`
+	RefreshToken    string     `json:"-" db:"refresh_token"` // SHA-256 hash of the token
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	AuthenticatedAt time.Time  `json:"authenticated_at" db:"authenticated_at"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RotatedAt       *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason   string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	IPAddress       string     `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent       string     `json:"user_agent,omitempty" db:"user_agent"`
+}
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout          = "logout"
+	SessionRevokedLogoutAll       = "logout_all"
+	SessionRevokedReuseDetected   = "reuse_detected"
+	SessionRevokedByUser          = "revoked_by_user"
+	SessionRevokedByFranchiser    = "revoked_by_franchiser"
+	SessionRevokedPasswordReset   = "password_reset"
+	SessionRevokedPasswordChanged = "password_changed"
+	SessionRevokedTenantSuspended = "tenant_suspended"
+)
+
+// ActiveSession describes a signed-in device as shown to users
+type ActiveSession struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Built-in tenant plans; the catalogue can change them and add more
+const (
+	PlanStart      = "start"
+	PlanBusiness   = "business"
+	PlanEnterprise = "enterprise"
+)
+
+// DefaultTimezone is the timezone of networks that have not chosen one
+const DefaultTimezone = "Europe/Moscow"
+
+// Tenant represents a franchise network
+type Tenant struct {
+	ID        string          `json:"id" db:"id"`
+	Name      string          `json:"name" db:"name"`
+	Slug      string          `json:"slug" db:"slug"` // unique short name of the network
+	City      string          `json:"city" db:"city"`
+	Plan      string          `json:"plan" db:"plan"` // a plan of the catalogue
+	Settings  json.RawMessage `json:"settings" db:"settings"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+
+	SuspendedAt *time.Time `json:"suspended_at,omitempty" db:"suspended_at"` // set while a superadmin has suspended the tenant
+}
+
+// IsSuspended reports whether users of the tenant are locked out
+func (t *Tenant) IsSuspended() bool {
+	return t.SuspendedAt != nil
+}
+
+// TenantUpdateRequest represents the changes a franchiser can make to their
+// tenant; branding, features and security are replaced as a whole when present
+type TenantUpdateRequest struct {
+	Name     string                  `json:"name,omitempty" binding:"max=255"`
+	City     string                  `json:"city,omitempty" binding:"max=255"`
+	Branding *BrandingSettings       `json:"branding,omitempty"`
+	Features *FeatureSettings        `json:"features,omitempty"`
+	Security *TenantSecuritySettings `json:"security,omitempty"`
+	Timezone string                  `json:"timezone,omitempty" binding:"max=64"`
+}
+
+// TenantCreateRequest represents the data a superadmin needs to create a tenant
+type TenantCreateRequest struct {
+	Name  string       `json:"name" binding:"required,max=255"`
+	Slug  string       `json:"slug" binding:"omitempty,max=63"`
+	City  string       `json:"city" binding:"max=255"`
+	Plan  string       `json:"plan" binding:"omitempty,max=50"`
+	Owner *TenantOwner `json:"owner,omitempty"`
+}
+
+// TenantOwner is the person invited as the franchiser of a new tenant
+type TenantOwner struct {
+	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// TenantAdminUpdateRequest represents the changes a superadmin can make to a
+// tenant. Settings replace the stored settings as a whole; when only the plan
+// changes, limits are reset to the new plan's defaults.
+type TenantAdminUpdateRequest struct {
+	Name     string          `json:"name,omitempty" binding:"max=255"`
+	City     string          `json:"city,omitempty" binding:"max=255"`
+	Plan     string          `json:"plan,omitempty" binding:"omitempty,max=50"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// TenantFilter represents the filter options for listing tenants
+type TenantFilter struct {
+	Search string // matches name or slug
+	Plan   string
+	Status string // active, suspended
+	Limit  int
+	Offset int
+}
+
+// TenantSettings is the known part of Tenant.Settings. Features are the
+// network's own switches: a module works when it is switched on here and the
+// plan includes it or a superadmin granted it.
+type TenantSettings struct {
+	Branding        BrandingSettings       `json:"branding"`
+	Features        FeatureSettings        `json:"features"`
+	GrantedFeatures FeatureSettings        `json:"granted_features"` // modules a superadmin enabled beyond the plan
+	Limits          TenantLimits           `json:"limits"`
+	Security        TenantSecuritySettings `json:"security"`
+	Timezone        string                 `json:"timezone"` // IANA name; checklist days and deadlines follow it
+}
+
+// EffectiveFeatures returns the modules that work for the tenant on a plan
+// with the given features
+func (s TenantSettings) EffectiveFeatures(plan FeatureSettings) FeatureSettings {
+	var effective FeatureSettings
+	for _, feature := range Features {
+		allowed := plan.Enabled(feature) || s.GrantedFeatures.Enabled(feature)
+		effective.Set(feature, allowed && s.Features.Enabled(feature))
+	}
+	return effective
+}
+
+// BrandingSettings controls how the network looks in the app and in emails
+type BrandingSettings struct {
+	LogoURL        string `json:"logo_url,omitempty"`
+	PrimaryColor   string `json:"primary_color"`
+	SecondaryColor string `json:"secondary_color"`
+	CompanyName    string `json:"company_name"`
+}
+
+// Modules that can be switched on and off per tenant
+const (
+	FeatureMarketingAutomation = "marketing_automation"
+	FeatureCRM                 = "crm"
+	FeatureReporting           = "reporting"
+	FeatureChecklist           = "checklist"
+)
+
+// Features lists every module, in the order of FeatureSettings
+var Features = []string{FeatureMarketingAutomation, FeatureCRM, FeatureReporting, FeatureChecklist}
+
+// AllFeatures has every module switched on
+var AllFeatures = FeatureSettings{MarketingAutomation: true, CRM: true, Reporting: true, Checklist: true}
+
+// FeatureSettings lists the modules enabled for the tenant
+type FeatureSettings struct {
+	MarketingAutomation bool `json:"marketing_automation"`
+	CRM                 bool `json:"crm"`
+	Reporting           bool `json:"reporting"`
+	Checklist           bool `json:"checklist"`
+}
+
+// Enabled reports whether the module is switched on; unknown modules are off
+func (f FeatureSettings) Enabled(feature string) bool {
+	switch feature {
+	case FeatureMarketingAutomation:
+		return f.MarketingAutomation
+	case FeatureCRM:
+		return f.CRM
+	case FeatureReporting:
+		return f.Reporting
+	case FeatureChecklist:
+		return f.Checklist
+	}
+	return false
+}
+
+// Set switches a module on or off; it returns false for unknown modules
+func (f *FeatureSettings) Set(feature string, enabled bool) bool {
+	switch feature {
+	case FeatureMarketingAutomation:
+		f.MarketingAutomation = enabled
+	case FeatureCRM:
+		f.CRM = enabled
+	case FeatureReporting:
+		f.Reporting = enabled
+	case FeatureChecklist:
+		f.Checklist = enabled
+	default:
+		return false
+	}
+	return true
+}
+
+// FeatureToggleRequest switches the listed modules on or off; modules that
+// are not listed keep their state
+type FeatureToggleRequest map[string]bool
+
+// PlanEntry is a plan of the catalogue as the platform API shows it. The
+// features include the toggles superadmins made for the plan.
+type PlanEntry struct {
+	Name string `json:"name"`
+	Plan
+}
+
+// TenantLimits holds the quotas of the tenant's plan
+type TenantLimits struct {
+	MaxDealers       int `json:"max_dealers"`
+	MaxUsers         int `json:"max_users"`
+	StorageGB        int `json:"storage_gb"`
+	APICallsPerMonth int `json:"api_calls_per_month"`
+}
+
+// TenantSecuritySettings holds the security options of a tenant
+type TenantSecuritySettings struct {
+	// MFARequiredRoles lists the roles that must use two-factor authentication
+	MFARequiredRoles []string `json:"mfa_required_roles,omitempty"`
+}
+
+// TenantUsage is the consumption of a tenant against the limits of its settings
+type TenantUsage struct {
+	Plan     string     `json:"plan"`
+	Period   string     `json:"period"` // month the API calls are counted for, YYYY-MM
+	Dealers  QuotaUsage `json:"dealers"`
+	Users    QuotaUsage `json:"users"`
+	Storage  QuotaUsage `json:"storage_bytes"`
+	APICalls QuotaUsage `json:"api_calls"`
+}
+
+// QuotaUsage is the consumption of one limit. Pending counts the open
+// invitations that will take a seat once accepted.
+type QuotaUsage struct {
+	Used    int64 `json:"used"`
+	Pending int64 `json:"pending,omitempty"`
+	Limit   int64 `json:"limit"`
+}
+
+// Plan is an entry of the plan catalogue: the features and limits a tenant
+// starts with on the plan
+type Plan struct {
+	Features FeatureSettings `json:"features"`
+	Limits   TenantLimits    `json:"limits"`
+}
+
+var (
+	plansMu sync.RWMutex
+	// plans is the plan catalogue; SetPlans replaces it with the configured one
+	plans = DefaultPlans()
+)
+
+// DefaultPlans returns the built-in plan catalogue
+func DefaultPlans() map[string]Plan {
+	return map[string]Plan{
+		PlanStart: {
+			Features: FeatureSettings{Checklist: true, CRM: true},
+			Limits:   TenantLimits{MaxDealers: 5, MaxUsers: 10, StorageGB: 5, APICallsPerMonth: 10_000},
+		},
+		PlanBusiness: {
+			Features: FeatureSettings{Checklist: true, CRM: true, Reporting: true},
+			Limits:   TenantLimits{MaxDealers: 50, MaxUsers: 100, StorageGB: 50, APICallsPerMonth: 100_000},
+		},
+		PlanEnterprise: {
+			Features: FeatureSettings{Checklist: true, CRM: true, Reporting: true, MarketingAutomation: true},
+			Limits:   TenantLimits{MaxDealers: 1000, MaxUsers: 2000, StorageGB: 500, APICallsPerMonth: 1_000_000},
+		},
+	}
+}
+
+// SetPlans replaces the plan catalogue. It is called once at startup with
+// the catalogue from the configuration.
+func SetPlans(catalogue map[string]Plan) {
+	plansMu.Lock()
+	defer plansMu.Unlock()
+
+	plans = maps.Clone(catalogue)
+}
+
+// GetPlan returns the plan from the catalogue
+func GetPlan(name string) (Plan, bool) {
+	plansMu.RLock()
+	defer plansMu.RUnlock()
+
+	plan, ok := plans[name]
+	return plan, ok
+}
+
+// PlanNames returns the names of the plans in the catalogue, sorted
+func PlanNames() []string {
+	plansMu.RLock()
+	defer plansMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(plans))
+}
+
+// IsValidPlan reports whether plan is in the catalogue
+func IsValidPlan(plan string) bool {
+	_, ok := GetPlan(plan)
+	return ok
+}
+
+// DefaultTenantSettings returns the settings a new tenant on plan starts with.
+// Every module is switched on, so the tenant gets whatever its plan includes.
+func DefaultTenantSettings(plan, companyName string) TenantSettings {
+	defaults, ok := GetPlan(plan)
+	if !ok {
+		defaults, _ = GetPlan(PlanStart)
+	}
+
+	return TenantSettings{
+		Branding: BrandingSettings{
+			PrimaryColor:   "#1890ff",
+			SecondaryColor: "#722ed1",
+			CompanyName:    companyName,
+		},
+		Features: AllFeatures,
+		Limits:   defaults.Limits,
+		Timezone: DefaultTimezone,
+	}
+}
+
+// ParseSettings decodes the settings JSON over the defaults of the tenant's
+// plan, so that sections missing from older tenants are filled in. Unknown
+// keys are ignored.
+func (t *Tenant) ParseSettings() (TenantSettings, error) {
+	settings := DefaultTenantSettings(t.Plan, t.Name)
+	if len(t.Settings) == 0 {
+		return settings, nil
+	}
+
+	err := json.Unmarshal(t.Settings, &settings)
+	return settings, err
+}
@@ -4,34 +4,47 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"password,omitempty" db:"password"`
-	Role      string    `json:"role" db:"role"`              // franchiser, dealer, manager
-	TenantID  string    `json:"tenant_id" db:"tenant_id"`   // ID of the franchise network
-	FirstName string    `json:"first_name,omitempty" db:"first_name"`
-	LastName  string    `json:"last_name,omitempty" db:"last_name"`
-	Phone     string    `json:"phone,omitempty" db:"phone"`
-	Avatar    string    `json:"avatar,omitempty" db:"avatar"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-}
-
-// UserRegisterRequest represents the data needed for user registration
+	ID            string    `json:"id" db:"id"`
+	Email         string    `json:"email" db:"email"`
+	Password      string    `json:"password,omitempty" db:"password_hash"`
+	Role          string    `json:"role" db:"role"`                       // superadmin or the name of a role of the tenant
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`             // ID of the franchise network
+	ManagerID     string    `json:"manager_id,omitempty" db:"manager_id"` // manager a dealer reports to
+	FirstName     string    `json:"first_name,omitempty" db:"first_name"`
+	LastName      string    `json:"last_name,omitempty" db:"last_name"`
+	Phone         string    `json:"phone,omitempty" db:"phone"`
+	Avatar        string    `json:"avatar,omitempty" db:"avatar"`
+	City          string    `json:"city,omitempty" db:"city"` // city of a dealer's outlet, for checklist templates
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	EmailVerified bool      `json:"email_verified" db:"email_verified"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	TwoFactorEnabled bool   `json:"two_factor_enabled" db:"two_factor_enabled"`
+	TOTPSecret       string `json:"-" db:"totp_secret"`       // base32, set once enrollment starts
+	TOTPLastCounter  int64  `json:"-" db:"totp_last_counter"` // time step of the last accepted code
+
+	TokenVersion int `json:"-" db:"token_version"` // bumped on password and role changes
+}
+
+// UserRegisterRequest represents the data needed to sign up a franchiser
+// together with their new franchise network. Self-registered networks always
+// start on the default plan; only a superadmin can choose or change it.
 type UserRegisterRequest struct {
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=6"`
-	Role      string `json:"role" validate:"required,oneof=franchiser dealer manager"`
-	TenantID  string `json:"tenant_id" validate:"required"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Phone     string `json:"phone"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	TenantName string `json:"tenant_name" binding:"required,max=255"`
+	TenantSlug string `json:"tenant_slug" binding:"omitempty,max=63"` // generated from the name when empty
+	City       string `json:"city" binding:"max=255"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Phone      string `json:"phone"`
 }
 
 // UserLoginRequest represents the data needed for user login
 type UserLoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
 }
 
 // UserUpdateRequest represents the data needed for user profile update
@@ -40,17 +53,39 @@ type UserUpdateRequest struct {
 	LastName  string `json:"last_name,omitempty"`
 	Phone     string `json:"phone,omitempty"`
 	Avatar    string `json:"avatar,omitempty"`
+	City      string `json:"city,omitempty" binding:"max=255"`
+}
+
+// DealerAssignmentRequest lists the dealers a manager should oversee; it
+// replaces the manager's current dealers
+type DealerAssignmentRequest struct {
+	DealerIDs []string `json:"dealer_ids" binding:"required,dive,uuid"`
+}
+
+// ChangePasswordRequest represents the data needed to change the password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	User         User   `json:"user"`
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token"`
+	User         User    `json:"user"`
+	Tenant       *Tenant `json:"tenant,omitempty"`
+	Token        string  `json:"token"`
+	RefreshToken string  `json:"refresh_token"`
 }
 
 // TokenResponse represents the token refresh response
 type TokenResponse struct {
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
-}
\ No newline at end of file
+}
+
+// ChangePasswordResponse carries the new token pair of the login the password
+// was changed from; without one the user has to sign in again
+type ChangePasswordResponse struct {
+	Message      string `json:"message"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
@@ -0,0 +1,130 @@
+// Package password validates new passwords against a configurable policy and
+// a bundled list of common passwords.
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+//go:embed common_passwords.txt
+var bundledCommonPasswords string
+
+// Policy lists the requirements a new password has to meet
+type Policy struct {
+	MinLength     int
+	RequireLetter bool
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectCommon  bool
+
+	commonPasswords map[string]struct{}
+}
+
+// LoadPolicy reads the password_* settings. The bundled common password list
+// can be extended with password_blocklist_file, one password per line.
+func LoadPolicy() (*Policy, error) {
+	policy := &Policy{
+		MinLength:     viper.GetInt("password_min_length"),
+		RequireLetter: viper.GetBool("password_require_letter"),
+		RequireUpper:  viper.GetBool("password_require_uppercase"),
+		RequireLower:  viper.GetBool("password_require_lowercase"),
+		RequireDigit:  viper.GetBool("password_require_digit"),
+		RequireSymbol: viper.GetBool("password_require_symbol"),
+		RejectCommon:  viper.GetBool("password_reject_common"),
+	}
+	if policy.MinLength <= 0 {
+		policy.MinLength = 8
+	}
+
+	policy.commonPasswords = map[string]struct{}{}
+	addPasswords(policy.commonPasswords, bundledCommonPasswords)
+
+	if file := viper.GetString("password_blocklist_file"); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password blocklist: %w", err)
+		}
+		addPasswords(policy.commonPasswords, string(content))
+	}
+
+	return policy, nil
+}
+
+// Validate returns a description of the first unmet requirement, or nil.
+// identity holds values the password must not be equal to, such as the email.
+func (p *Policy) Validate(password string, identity ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+
+	var letter, upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			letter, upper = true, true
+		case unicode.IsLower(r):
+			letter, lower = true, true
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireLetter && !letter:
+		return errors.New("password must contain a letter")
+	case p.RequireUpper && !upper:
+		return errors.New("password must contain an uppercase letter")
+	case p.RequireLower && !lower:
+		return errors.New("password must contain a lowercase letter")
+	case p.RequireDigit && !digit:
+		return errors.New("password must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return errors.New("password must contain a special character")
+	}
+
+	normalized := strings.ToLower(password)
+	if p.RejectCommon {
+		if _, ok := p.commonPasswords[normalized]; ok {
+			return errors.New("password is too common")
+		}
+	}
+
+	for _, value := range identity {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		local, _, _ := strings.Cut(value, "@")
+		if normalized == value || normalized == local {
+			return errors.New("password must not match your email")
+		}
+	}
+
+	return nil
+}
+
+func addPasswords(set map[string]struct{}, content string) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+}
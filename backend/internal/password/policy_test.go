@@ -0,0 +1,94 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidate(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	base, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if base.MinLength != 8 {
+		t.Fatalf("default minimum length: got %d, want 8", base.MinLength)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(p *Policy)
+		password string
+		identity []string
+		wantErr  bool
+	}{
+		{name: "long enough", password: "abcdefgh"},
+		{name: "too short", password: "abcdefg", wantErr: true},
+		{name: "length counts characters, not bytes", password: "пароль12"},
+		{name: "custom length", change: func(p *Policy) { p.MinLength = 12 }, password: "abcdefghijk", wantErr: true},
+
+		{name: "letter required", change: func(p *Policy) { p.RequireLetter = true }, password: "20250101!", wantErr: true},
+		{name: "non-Latin letter", change: func(p *Policy) { p.RequireLetter = true }, password: "пароль2025"},
+		{name: "uppercase required", change: func(p *Policy) { p.RequireUpper = true }, password: "secur3pass", wantErr: true},
+		{name: "uppercase present", change: func(p *Policy) { p.RequireUpper = true }, password: "Secur3pass"},
+		{name: "lowercase required", change: func(p *Policy) { p.RequireLower = true }, password: "SECUR3PASS", wantErr: true},
+		{name: "digit required", change: func(p *Policy) { p.RequireDigit = true }, password: "SecurePass", wantErr: true},
+		{name: "digit present", change: func(p *Policy) { p.RequireDigit = true }, password: "Secur3Pass"},
+		{name: "symbol required", change: func(p *Policy) { p.RequireSymbol = true }, password: "Secur3Pass", wantErr: true},
+		{name: "symbol present", change: func(p *Policy) { p.RequireSymbol = true }, password: "Secur3Pass!"},
+
+		{name: "common password", change: func(p *Policy) { p.RejectCommon = true }, password: "12345678", wantErr: true},
+		{name: "common password in another case", change: func(p *Policy) { p.RejectCommon = true }, password: "PASSWORD", wantErr: true},
+		{name: "common password allowed", password: "12345678"},
+
+		{name: "email", password: "Dealer@Example.com", identity: []string{"dealer@example.com"}, wantErr: true},
+		{name: "local part of the email", password: "Franchiser", identity: []string{" franchiser@example.com "}, wantErr: true},
+		{name: "unrelated to the email", password: "Secur3Pass", identity: []string{"franchiser@example.com", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := *base
+			if tt.change != nil {
+				tt.change(&policy)
+			}
+
+			err := policy.Validate(tt.password, tt.identity...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate %q: got %v, want error %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyBlocklistFile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	file := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(file, []byte("# local additions\n\nFranchise2025\n"), 0o600); err != nil {
+		t.Fatalf("write blocklist: %v", err)
+	}
+	viper.Set("password_reject_common", true)
+	viper.Set("password_blocklist_file", file)
+
+	policy, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	for _, blocked := range []string{"franchise2025", "12345678"} {
+		if policy.Validate(blocked) == nil {
+			t.Errorf("validate %q: got no error", blocked)
+		}
+	}
+	if err := policy.Validate("# local additions"); err != nil {
+		t.Errorf("validate a comment line: %v", err)
+	}
+
+	viper.Set("password_blocklist_file", filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := LoadPolicy(); err == nil {
+		t.Fatal("load policy with a missing blocklist: got no error")
+	}
+}
@@ -0,0 +1,112 @@
+// Package ratelimit throttles repeated failures, such as wrong passwords,
+// with exponential backoff and temporary lockout, and counts usage against
+// quotas. Counters live in memory or, when several replicas run, in Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps failure counters and locks
+type Store interface {
+	// Incr increments the counter and keeps it for window after the last increment
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Count returns the current value of the counter, zero if it expired
+	Count(ctx context.Context, key string) (int64, error)
+	Delete(ctx context.Context, key string) error
+	// Lock blocks the key for the given duration
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long the key stays blocked, zero if it is not
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+}
+
+// NewStore returns a Redis store when redisURL is set and an in-memory store otherwise
+func NewStore(redisURL string) (Store, error) {
+	if redisURL == "" {
+		return NewMemoryStore(), nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	return NewRedisStore(redis.NewClient(opts)), nil
+}
+
+// Policy describes how failures of one kind of key are throttled
+type Policy struct {
+	// FreeAttempts failures are allowed without delay
+	FreeAttempts int
+	// BaseDelay is the delay after the first failure beyond FreeAttempts; it doubles with every further failure
+	BaseDelay time.Duration
+	// MaxAttempts failures lock the key for Lockout
+	MaxAttempts int
+	Lockout     time.Duration
+	// Window is how long failures are remembered after the last one
+	Window time.Duration
+}
+
+// Delay returns how long to block after the given number of failures
+func (p Policy) Delay(failures int) time.Duration {
+	if p.MaxAttempts > 0 && failures >= p.MaxAttempts {
+		return p.Lockout
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.Lockout {
+			return p.Lockout
+		}
+	}
+
+	return delay
+}
+
+// Limiter applies a policy to keys in a store
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// NewLimiter creates a limiter; prefix separates its keys from other limiters
+func NewLimiter(store Store, prefix string, policy Policy) *Limiter {
+	return &Limiter{store: store, prefix: prefix, policy: policy}
+}
+
+// Blocked returns how long the key stays blocked, zero if requests may proceed
+func (l *Limiter) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	return l.store.LockedFor(ctx, l.prefix+":lock:"+key)
+}
+
+// Fail records a failure and blocks the key as the policy says. It returns
+// the new block duration.
+func (l *Limiter) Fail(ctx context.Context, key string) (time.Duration, error) {
+	failures, err := l.store.Incr(ctx, l.prefix+":fail:"+key, l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := l.policy.Delay(int(failures))
+	if delay > 0 {
+		if err := l.store.Lock(ctx, l.prefix+":lock:"+key, delay); err != nil {
+			return 0, err
+		}
+	}
+
+	return delay, nil
+}
+
+// Reset forgets the failures of the key
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, l.prefix+":fail:"+key)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"franchise-saas-backend/internal/models"
+)
+
+// memoryData holds the tables of the in-memory store
+type memoryData struct {
+	mu         sync.RWMutex
+	users      map[string]models.User
+	tenants    map[string]models.Tenant
+	sessions   map[string]models.Session
+	userTokens map[string]models.UserToken
+	recovery   map[string]models.RecoveryCode
+	attempts   map[string]models.LoginAttempt
+	invites    map[string]models.Invitation
+	checklists map[string]models.Checklist
+	tasks      map[string]memoryTask
+	audit      map[string]models.AuditEntry
+	roles      map[string]models.Role
+	apiKeys    map[string]models.APIKey
+	storage    map[string]int64 // bytes taken by the files of each tenant
+	plans      map[string]models.FeatureSettings
+	domains    map[string]models.TenantDomain
+	templates  map[string]models.ChecklistTemplate
+	versions   map[string]models.ChecklistTemplateVersion // keyed by template ID and version
+	holidays   map[string]models.Holiday                  // keyed by tenant ID and date
+	runs       map[string]int                             // checklists created, keyed by tenant ID and date
+}
+
+// memoryTask is a task row together with the checklist it belongs to
+type memoryTask struct {
+	checklistID string
+	task        models.Task
+}
+
+// MemoryStore is a thread-safe Store kept in process memory. It is meant for
+// tests and local development without PostgreSQL.
+type MemoryStore struct {
+	data *memoryData
+	txMu *sync.Mutex
+	inTx bool
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: &memoryData{
+			users:      map[string]models.User{},
+			tenants:    map[string]models.Tenant{},
+			sessions:   map[string]models.Session{},
+			userTokens: map[string]models.UserToken{},
+			recovery:   map[string]models.RecoveryCode{},
+			attempts:   map[string]models.LoginAttempt{},
+			invites:    map[string]models.Invitation{},
+			checklists: map[string]models.Checklist{},
+			tasks:      map[string]memoryTask{},
+			audit:      map[string]models.AuditEntry{},
+			roles:      map[string]models.Role{},
+			apiKeys:    map[string]models.APIKey{},
+			storage:    map[string]int64{},
+			plans:      map[string]models.FeatureSettings{},
+			domains:    map[string]models.TenantDomain{},
+			templates:  map[string]models.ChecklistTemplate{},
+			versions:   map[string]models.ChecklistTemplateVersion{},
+			holidays:   map[string]models.Holiday{},
+			runs:       map[string]int{},
+		},
+		txMu: &sync.Mutex{},
+	}
+}
+
+func (s *MemoryStore) Users() UserRepository           { return &memUserRepository{data: s.data} }
+func (s *MemoryStore) Tenants() TenantRepository       { return &memTenantRepository{data: s.data} }
+func (s *MemoryStore) Sessions() SessionRepository     { return &memSessionRepository{data: s.data} }
+func (s *MemoryStore) UserTokens() UserTokenRepository { return &memUserTokenRepository{data: s.data} }
+func (s *MemoryStore) RecoveryCodes() RecoveryCodeRepository {
+	return &memRecoveryCodeRepository{data: s.data}
+}
+func (s *MemoryStore) LoginAttempts() LoginAttemptRepository {
+	return &memLoginAttemptRepository{data: s.data}
+}
+func (s *MemoryStore) Invitations() InvitationRepository {
+	return &memInvitationRepository{data: s.data}
+}
+func (s *MemoryStore) Checklists() ChecklistRepository { return &memChecklistRepository{data: s.data} }
+func (s *MemoryStore) Tasks() TaskRepository           { return &memTaskRepository{data: s.data} }
+func (s *MemoryStore) Audit() AuditRepository          { return &memAuditRepository{data: s.data} }
+func (s *MemoryStore) Roles() RoleRepository           { return &memRoleRepository{data: s.data} }
+func (s *MemoryStore) APIKeys() APIKeyRepository       { return &memAPIKeyRepository{data: s.data} }
+func (s *MemoryStore) Plans() PlanRepository           { return &memPlanRepository{data: s.data} }
+func (s *MemoryStore) Domains() DomainRepository       { return &memDomainRepository{data: s.data} }
+func (s *MemoryStore) Templates() ChecklistTemplateRepository {
+	return &memChecklistTemplateRepository{data: s.data}
+}
+func (s *MemoryStore) Holidays() HolidayRepository { return &memHolidayRepository{data: s.data} }
+func (s *MemoryStore) ChecklistRuns() ChecklistRunRepository {
+	return &memChecklistRunRepository{data: s.data}
+}
+
+// WithTx serialises transactions and restores a snapshot of all tables when
+// fn fails. Writes made outside of a transaction while it runs are lost on
+// rollback, which is acceptable for tests.
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	snapshot := s.data.snapshot()
+	if err := fn(&MemoryStore{data: s.data, txMu: s.txMu, inTx: true}); err != nil {
+		s.data.restore(snapshot)
+		return err
+	}
+
+	return nil
+}
+
+// snapshot copies every table
+func (d *memoryData) snapshot() *memoryData {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return &memoryData{
+		users:      maps.Clone(d.users),
+		tenants:    maps.Clone(d.tenants),
+		sessions:   maps.Clone(d.sessions),
+		userTokens: maps.Clone(d.userTokens),
+		recovery:   maps.Clone(d.recovery),
+		attempts:   maps.Clone(d.attempts),
+		invites:    maps.Clone(d.invites),
+		checklists: maps.Clone(d.checklists),
+		tasks:      maps.Clone(d.tasks),
+		audit:      maps.Clone(d.audit),
+		roles:      maps.Clone(d.roles),
+		apiKeys:    maps.Clone(d.apiKeys),
+		storage:    maps.Clone(d.storage),
+		plans:      maps.Clone(d.plans),
+		domains:    maps.Clone(d.domains),
+		templates:  maps.Clone(d.templates),
+		versions:   maps.Clone(d.versions),
+		holidays:   maps.Clone(d.holidays),
+		runs:       maps.Clone(d.runs),
+	}
+}
+
+// restore replaces every table with the snapshot
+func (d *memoryData) restore(snapshot *memoryData) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.users = snapshot.users
+	d.tenants = snapshot.tenants
+	d.sessions = snapshot.sessions
+	d.userTokens = snapshot.userTokens
+	d.recovery = snapshot.recovery
+	d.attempts = snapshot.attempts
+	d.invites = snapshot.invites
+	d.checklists = snapshot.checklists
+	d.tasks = snapshot.tasks
+	d.audit = snapshot.audit
+	d.roles = snapshot.roles
+	d.apiKeys = snapshot.apiKeys
+	d.storage = snapshot.storage
+	d.plans = snapshot.plans
+	d.domains = snapshot.domains
+	d.templates = snapshot.templates
+	d.versions = snapshot.versions
+	d.holidays = snapshot.holidays
+	d.runs = snapshot.runs
+}
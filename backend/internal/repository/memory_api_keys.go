@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memAPIKeyRepository struct {
+	data *memoryData
+}
+
+func (r *memAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for _, existing := range r.data.apiKeys {
+		if existing.ID == key.ID || existing.KeyHash == key.KeyHash {
+			return ErrDuplicate
+		}
+	}
+
+	key.CreatedAt = time.Now()
+	stored := *key
+	stored.Scopes = slices.Clone(key.Scopes)
+	r.data.apiKeys[key.ID] = stored
+
+	return nil
+}
+
+func (r *memAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, key := range r.data.apiKeys {
+		if key.KeyHash == keyHash {
+			key.Scopes = slices.Clone(key.Scopes)
+			return &key, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memAPIKeyRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, key := range r.data.apiKeys {
+		if key.TenantID == tenantID {
+			key.Scopes = slices.Clone(key.Scopes)
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *memAPIKeyRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key, ok := r.data.apiKeys[id]
+	if !ok || key.TenantID != tenantID || key.RevokedAt != nil {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	r.data.apiKeys[id] = key
+
+	return nil
+}
+
+func (r *memAPIKeyRepository) TouchLastUsed(ctx context.Context, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key, ok := r.data.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(now.Add(-time.Minute)) {
+		key.LastUsedAt = &now
+		r.data.apiKeys[id] = key
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memAuditRepository struct {
+	data *memoryData
+}
+
+func (r *memAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.audit[entry.ID]; exists {
+		return ErrDuplicate
+	}
+
+	entry.CreatedAt = time.Now()
+	r.data.audit[entry.ID] = *entry
+
+	return nil
+}
+
+func (r *memAuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for _, entry := range r.data.audit {
+		if filter.ActorID != "" && entry.ActorID != filter.ActorID {
+			continue
+		}
+		if filter.TenantID != "" && entry.TenantID != filter.TenantID {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(entries) {
+		return []models.AuditEntry{}, nil
+	}
+	entries = entries[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(entries) {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memChecklistRepository struct {
+	data *memoryData
+}
+
+func (r *memChecklistRepository) ListByUser(ctx context.Context, tenantID, userID string, limit, offset int) ([]models.Checklist, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	checklists := []models.Checklist{}
+	for _, checklist := range r.data.checklists {
+		if checklist.TenantID == tenantID && checklist.UserID == userID {
+			checklists = append(checklists, checklist)
+		}
+	}
+
+	sort.Slice(checklists, func(i, j int) bool {
+		if !checklists[i].Date.Equal(checklists[j].Date) {
+			return checklists[i].Date.After(checklists[j].Date)
+		}
+		return checklists[i].CreatedAt.After(checklists[j].CreatedAt)
+	})
+
+	if offset >= len(checklists) {
+		return []models.Checklist{}, nil
+	}
+	checklists = checklists[offset:]
+	if limit > 0 && limit < len(checklists) {
+		checklists = checklists[:limit]
+	}
+
+	return checklists, nil
+}
+
+func (r *memChecklistRepository) GetByID(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	checklist, ok := r.data.checklists[id]
+	if !ok || checklist.UserID != userID || checklist.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	return &checklist, nil
+}
+
+func (r *memChecklistRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.Checklist, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	checklist, ok := r.data.checklists[id]
+	if !ok || checklist.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	return &checklist, nil
+}
+
+func (r *memChecklistRepository) GetForUpdate(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error) {
+	return r.GetByID(ctx, tenantID, id, userID)
+}
+
+func (r *memChecklistRepository) Create(ctx context.Context, checklist *models.Checklist) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.checklists[checklist.ID]; exists {
+		return ErrDuplicate
+	}
+
+	// Mirrors UNIQUE(tenant_id, user_id, date)
+	for _, c := range r.data.checklists {
+		if c.TenantID == checklist.TenantID && c.UserID == checklist.UserID && c.Date.Equal(checklist.Date) {
+			return ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+	checklist.CreatedAt = now
+	checklist.UpdatedAt = now
+
+	stored := *checklist
+	stored.Tasks = nil
+	r.data.checklists[checklist.ID] = stored
+
+	return nil
+}
+
+func (r *memChecklistRepository) Update(ctx context.Context, checklist *models.Checklist) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.checklists[checklist.ID]
+	if !ok || stored.UserID != checklist.UserID || stored.TenantID != checklist.TenantID {
+		return ErrNotFound
+	}
+
+	stored.Title = checklist.Title
+	stored.Description = checklist.Description
+	stored.Status = checklist.Status
+	stored.KPIScore = checklist.KPIScore
+	stored.UpdatedAt = time.Now()
+	r.data.checklists[checklist.ID] = stored
+
+	checklist.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memChecklistRepository) Delete(ctx context.Context, tenantID, id, userID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	checklist, ok := r.data.checklists[id]
+	if !ok || checklist.UserID != userID || checklist.TenantID != tenantID {
+		return ErrNotFound
+	}
+
+	delete(r.data.checklists, id)
+	for taskID, t := range r.data.tasks {
+		if t.checklistID == id {
+			delete(r.data.tasks, taskID)
+		}
+	}
+
+	return nil
+}
+
+func (r *memChecklistRepository) ListUserIDsByDate(ctx context.Context, tenantID string, date time.Time) ([]string, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	userIDs := []string{}
+	for _, checklist := range r.data.checklists {
+		if checklist.TenantID == tenantID && checklist.Date.Equal(date) {
+			userIDs = append(userIDs, checklist.UserID)
+		}
+	}
+
+	return userIDs, nil
+}
+
+type memTaskRepository struct {
+	data *memoryData
+}
+
+func (r *memTaskRepository) ListByChecklist(ctx context.Context, checklistID string) ([]models.Task, error) {
+	byChecklist, err := r.ListByChecklists(ctx, []string{checklistID})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := byChecklist[checklistID]
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return tasks, nil
+}
+
+func (r *memTaskRepository) ListByChecklists(ctx context.Context, checklistIDs []string) (map[string][]models.Task, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	result := make(map[string][]models.Task, len(checklistIDs))
+	for _, t := range r.data.tasks {
+		if slices.Contains(checklistIDs, t.checklistID) {
+			result[t.checklistID] = append(result[t.checklistID], t.task)
+		}
+	}
+
+	for _, tasks := range result {
+		sort.Slice(tasks, func(i, j int) bool {
+			if tasks[i].Order != tasks[j].Order {
+				return tasks[i].Order < tasks[j].Order
+			}
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		})
+	}
+
+	return result, nil
+}
+
+func (r *memTaskRepository) Create(ctx context.Context, checklistID string, task *models.Task) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.tasks[task.ID]; exists {
+		return ErrDuplicate
+	}
+
+	now := time.Now()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	r.data.tasks[task.ID] = memoryTask{checklistID: checklistID, task: *task}
+
+	return nil
+}
+
+func (r *memTaskRepository) Update(ctx context.Context, checklistID string, task *models.Task) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.tasks[task.ID]
+	if !ok || stored.checklistID != checklistID {
+		return ErrNotFound
+	}
+
+	task.CreatedAt = stored.task.CreatedAt
+	task.UpdatedAt = time.Now()
+	task.VerifiedAt = stored.task.VerifiedAt
+	task.VerifiedBy = stored.task.VerifiedBy
+	task.ReviewComment = stored.task.ReviewComment
+	r.data.tasks[task.ID] = memoryTask{checklistID: checklistID, task: *task}
+
+	return nil
+}
+
+func (r *memTaskRepository) Review(ctx context.Context, checklistID string, task *models.Task) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.tasks[task.ID]
+	if !ok || stored.checklistID != checklistID {
+		return ErrNotFound
+	}
+
+	stored.task.VerifiedAt = task.VerifiedAt
+	stored.task.VerifiedBy = task.VerifiedBy
+	stored.task.ReviewComment = task.ReviewComment
+	stored.task.UpdatedAt = time.Now()
+	r.data.tasks[task.ID] = stored
+
+	task.UpdatedAt = stored.task.UpdatedAt
+	return nil
+}
+
+func (r *memTaskRepository) DeleteExcept(ctx context.Context, checklistID string, keepIDs []string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for id, t := range r.data.tasks {
+		if t.checklistID == checklistID && !slices.Contains(keepIDs, id) {
+			delete(r.data.tasks, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *memTaskRepository) CompleteAll(ctx context.Context, checklistID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.data.tasks {
+		if t.checklistID != checklistID || t.task.Status == "completed" {
+			continue
+		}
+
+		t.task.Status = "completed"
+		if t.task.CompletedAt == nil {
+			t.task.CompletedAt = &now
+		}
+		t.task.UpdatedAt = now
+		r.data.tasks[id] = t
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memDomainRepository struct {
+	data *memoryData
+}
+
+func (r *memDomainRepository) Create(ctx context.Context, domain *models.TenantDomain) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for _, existing := range r.data.domains {
+		if existing.ID == domain.ID || (existing.TenantID == domain.TenantID && existing.Domain == domain.Domain) {
+			return ErrDuplicate
+		}
+	}
+
+	domain.CreatedAt = time.Now()
+	r.data.domains[domain.ID] = *domain
+
+	return nil
+}
+
+func (r *memDomainRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.TenantDomain, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	domain, ok := r.data.domains[id]
+	if !ok || domain.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	return &domain, nil
+}
+
+func (r *memDomainRepository) GetVerified(ctx context.Context, name string) (*models.TenantDomain, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, domain := range r.data.domains {
+		if domain.Domain == name && domain.IsVerified() {
+			return &domain, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memDomainRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.TenantDomain, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	domains := []models.TenantDomain{}
+	for _, domain := range r.data.domains {
+		if domain.TenantID == tenantID {
+			domains = append(domains, domain)
+		}
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Domain < domains[j].Domain
+	})
+
+	return domains, nil
+}
+
+func (r *memDomainRepository) MarkVerified(ctx context.Context, tenantID, id string, at time.Time) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	domain, ok := r.data.domains[id]
+	if !ok || domain.TenantID != tenantID {
+		return ErrNotFound
+	}
+
+	for _, other := range r.data.domains {
+		if other.ID != id && other.Domain == domain.Domain && other.IsVerified() {
+			return ErrDuplicate
+		}
+	}
+
+	domain.VerifiedAt = &at
+	r.data.domains[id] = domain
+
+	return nil
+}
+
+func (r *memDomainRepository) Delete(ctx context.Context, tenantID, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	domain, ok := r.data.domains[id]
+	if !ok || domain.TenantID != tenantID {
+		return ErrNotFound
+	}
+	delete(r.data.domains, id)
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memHolidayRepository struct {
+	data *memoryData
+}
+
+func (r *memHolidayRepository) Create(ctx context.Context, holiday *models.Holiday) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := dayKey(holiday.TenantID, holiday.Date)
+	if _, exists := r.data.holidays[key]; exists {
+		return ErrDuplicate
+	}
+
+	holiday.CreatedAt = time.Now()
+	r.data.holidays[key] = *holiday
+
+	return nil
+}
+
+func (r *memHolidayRepository) ListByTenant(ctx context.Context, tenantID string, from, to time.Time) ([]models.Holiday, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	holidays := []models.Holiday{}
+	for _, holiday := range r.data.holidays {
+		if holiday.TenantID == tenantID && !holiday.Date.Before(from) && !holiday.Date.After(to) {
+			holidays = append(holidays, holiday)
+		}
+	}
+
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+
+	return holidays, nil
+}
+
+func (r *memHolidayRepository) IsHoliday(ctx context.Context, tenantID string, date time.Time) (bool, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	_, exists := r.data.holidays[dayKey(tenantID, date)]
+	return exists, nil
+}
+
+func (r *memHolidayRepository) Delete(ctx context.Context, tenantID string, date time.Time) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := dayKey(tenantID, date)
+	if _, exists := r.data.holidays[key]; !exists {
+		return ErrNotFound
+	}
+
+	delete(r.data.holidays, key)
+	return nil
+}
+
+type memChecklistRunRepository struct {
+	data *memoryData
+}
+
+func (r *memChecklistRunRepository) Claim(ctx context.Context, tenantID string, date time.Time) (bool, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := dayKey(tenantID, date)
+	if _, exists := r.data.runs[key]; exists {
+		return false, nil
+	}
+
+	r.data.runs[key] = 0
+	return true, nil
+}
+
+func (r *memChecklistRunRepository) Finish(ctx context.Context, tenantID string, date time.Time, created int) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	key := dayKey(tenantID, date)
+	if _, exists := r.data.runs[key]; !exists {
+		return ErrNotFound
+	}
+
+	r.data.runs[key] = created
+	return nil
+}
+
+// dayKey identifies a calendar date of a tenant
+func dayKey(tenantID string, date time.Time) string {
+	return tenantID + "/" + date.Format(time.DateOnly)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memInvitationRepository struct {
+	data *memoryData
+}
+
+func (r *memInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for _, existing := range r.data.invites {
+		if existing.ID == invitation.ID || existing.TokenHash == invitation.TokenHash {
+			return ErrDuplicate
+		}
+		if existing.TenantID == invitation.TenantID && strings.EqualFold(existing.Email, invitation.Email) && existing.IsOpen() {
+			return ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+	r.data.invites[invitation.ID] = *invitation
+
+	return nil
+}
+
+func (r *memInvitationRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Invitation, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	invitation, ok := r.data.invites[id]
+	if !ok || invitation.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	return &invitation, nil
+}
+
+func (r *memInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, invitation := range r.data.invites {
+		if invitation.TokenHash == tokenHash {
+			return &invitation, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memInvitationRepository) GetOpenByEmail(ctx context.Context, tenantID, email string) (*models.Invitation, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, invitation := range r.data.invites {
+		if invitation.TenantID == tenantID && strings.EqualFold(invitation.Email, email) && invitation.IsOpen() {
+			return &invitation, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memInvitationRepository) ListOpen(ctx context.Context, tenantID string) ([]models.Invitation, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, invitation := range r.data.invites {
+		if invitation.TenantID == tenantID && invitation.IsOpen() {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+
+	return invitations, nil
+}
+
+func (r *memInvitationRepository) UpdateToken(ctx context.Context, invitation *models.Invitation) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.invites[invitation.ID]
+	if !ok || !stored.IsOpen() {
+		return ErrNotFound
+	}
+
+	stored.TokenHash = invitation.TokenHash
+	stored.ExpiresAt = invitation.ExpiresAt
+	stored.UpdatedAt = time.Now()
+	r.data.invites[invitation.ID] = stored
+
+	invitation.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memInvitationRepository) MarkAccepted(ctx context.Context, id, userID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.invites[id]
+	if !ok || !stored.IsOpen() {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	stored.AcceptedAt = &now
+	stored.AcceptedUserID = userID
+	stored.UpdatedAt = now
+	r.data.invites[id] = stored
+
+	return nil
+}
+
+func (r *memInvitationRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.invites[id]
+	if !ok || stored.TenantID != tenantID || !stored.IsOpen() {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	stored.RevokedAt = &now
+	stored.UpdatedAt = now
+	r.data.invites[id] = stored
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memLoginAttemptRepository struct {
+	data *memoryData
+}
+
+func (r *memLoginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.attempts[attempt.ID]; exists {
+		return ErrDuplicate
+	}
+
+	attempt.CreatedAt = time.Now()
+	r.data.attempts[attempt.ID] = *attempt
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memPlanRepository struct {
+	data *memoryData
+}
+
+func (r *memPlanRepository) GetFeatures(ctx context.Context, plan string) (*models.FeatureSettings, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	features, ok := r.data.plans[plan]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &features, nil
+}
+
+func (r *memPlanRepository) SetFeatures(ctx context.Context, plan string, features models.FeatureSettings) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.data.plans[plan] = features
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type memRecoveryCodeRepository struct {
+	data *memoryData
+}
+
+func (r *memRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID string, codeHashes []string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.deleteForUser(userID)
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		id := uuid.New().String()
+		r.data.recovery[id] = models.RecoveryCode{ID: id, UserID: userID, CodeHash: hash, CreatedAt: now}
+	}
+
+	return nil
+}
+
+func (r *memRecoveryCodeRepository) Use(ctx context.Context, userID, codeHash string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for id, code := range r.data.recovery {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			r.data.recovery[id] = code
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *memRecoveryCodeRepository) CountUnused(ctx context.Context, userID string) (int, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	count := 0
+	for _, code := range r.data.recovery {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *memRecoveryCodeRepository) DeleteForUser(ctx context.Context, userID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.deleteForUser(userID)
+	return nil
+}
+
+// deleteForUser removes the codes of the user; the caller holds the write lock
+func (r *memRecoveryCodeRepository) deleteForUser(userID string) {
+	for id, code := range r.data.recovery {
+		if code.UserID == userID {
+			delete(r.data.recovery, id)
+		}
+	}
+}
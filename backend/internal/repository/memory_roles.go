@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memRoleRepository struct {
+	data *memoryData
+}
+
+func (r *memRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for _, existing := range r.data.roles {
+		if existing.ID == role.ID || (existing.TenantID == role.TenantID && existing.Name == role.Name) {
+			return ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	r.data.roles[role.ID] = cloneRole(*role)
+
+	return nil
+}
+
+func (r *memRoleRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Role, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	role, ok := r.data.roles[id]
+	if !ok || role.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	role = cloneRole(role)
+	return &role, nil
+}
+
+func (r *memRoleRepository) GetByName(ctx context.Context, tenantID, name string) (*models.Role, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, role := range r.data.roles {
+		if role.TenantID == tenantID && role.Name == name {
+			role = cloneRole(role)
+			return &role, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memRoleRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.Role, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	roles := []models.Role{}
+	for _, role := range r.data.roles {
+		if role.TenantID == tenantID {
+			roles = append(roles, cloneRole(role))
+		}
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].BuiltIn != roles[j].BuiltIn {
+			return roles[i].BuiltIn
+		}
+		if !roles[i].CreatedAt.Equal(roles[j].CreatedAt) {
+			return roles[i].CreatedAt.Before(roles[j].CreatedAt)
+		}
+		return roles[i].Name < roles[j].Name
+	})
+
+	return roles, nil
+}
+
+func (r *memRoleRepository) Update(ctx context.Context, role *models.Role) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.roles[role.ID]
+	if !ok || stored.TenantID != role.TenantID {
+		return ErrNotFound
+	}
+
+	stored.Description = role.Description
+	stored.Permissions = slices.Clone(role.Permissions)
+	stored.UpdatedAt = time.Now()
+	r.data.roles[role.ID] = stored
+
+	role.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memRoleRepository) Delete(ctx context.Context, tenantID, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	role, ok := r.data.roles[id]
+	if !ok || role.TenantID != tenantID || role.BuiltIn {
+		return ErrNotFound
+	}
+
+	delete(r.data.roles, id)
+	return nil
+}
+
+func (r *memRoleRepository) IsInUse(ctx context.Context, tenantID, name string) (bool, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, user := range r.data.users {
+		if user.TenantID == tenantID && user.Role == name {
+			return true, nil
+		}
+	}
+	for _, invitation := range r.data.invites {
+		if invitation.TenantID == tenantID && invitation.Role == name && invitation.IsOpen() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// cloneRole copies the role so that callers cannot change the stored permissions
+func cloneRole(role models.Role) models.Role {
+	role.Permissions = slices.Clone(role.Permissions)
+	return role
+}
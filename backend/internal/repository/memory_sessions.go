@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memSessionRepository struct {
+	data *memoryData
+}
+
+func (r *memSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.sessions[session.ID]; exists {
+		return ErrDuplicate
+	}
+	for _, s := range r.data.sessions {
+		if s.RefreshToken == session.RefreshToken {
+			return ErrDuplicate
+		}
+	}
+
+	session.CreatedAt = time.Now()
+	r.data.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (r *memSessionRepository) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, session := range r.data.sessions {
+		if session.RefreshToken == refreshTokenHash {
+			return &session, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memSessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	now := time.Now()
+	sessions := []models.Session{}
+	for _, session := range r.data.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.RotatedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return lastUsed(sessions[i]).After(lastUsed(sessions[j]))
+	})
+
+	return sessions, nil
+}
+
+func (r *memSessionRepository) MarkRotated(ctx context.Context, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	session, ok := r.data.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	session.RotatedAt = &now
+	r.data.sessions[id] = session
+
+	return nil
+}
+
+func (r *memSessionRepository) IsFamilyActive(ctx context.Context, userID, familyID string) (bool, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, session := range r.data.sessions {
+		if session.UserID == userID && session.FamilyID == familyID && session.RevokedAt == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memSessionRepository) RevokeFamily(ctx context.Context, userID, familyID, reason string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.revokeWhere(reason, func(s models.Session) bool {
+		return s.UserID == userID && s.FamilyID == familyID
+	})
+	return nil
+}
+
+func (r *memSessionRepository) RevokeAllForUser(ctx context.Context, userID, reason string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.revokeWhere(reason, func(s models.Session) bool {
+		return s.UserID == userID
+	})
+	return nil
+}
+
+func (r *memSessionRepository) RevokeAllForTenant(ctx context.Context, tenantID, reason string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.revokeWhere(reason, func(s models.Session) bool {
+		return r.data.users[s.UserID].TenantID == tenantID
+	})
+	return nil
+}
+
+func (r *memSessionRepository) RevokeAllExcept(ctx context.Context, userID, familyID, reason string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	r.revokeWhere(reason, func(s models.Session) bool {
+		return s.UserID == userID && s.FamilyID != familyID
+	})
+	return nil
+}
+
+// revokeWhere revokes matching sessions; the caller holds the write lock
+func (r *memSessionRepository) revokeWhere(reason string, match func(models.Session) bool) {
+	now := time.Now()
+	for id, session := range r.data.sessions {
+		if session.RevokedAt == nil && match(session) {
+			session.RevokedAt = &now
+			session.RevokedReason = reason
+			r.data.sessions[id] = session
+		}
+	}
+}
+
+func lastUsed(s models.Session) time.Time {
+	if s.LastUsedAt != nil {
+		return *s.LastUsedAt
+	}
+	return s.CreatedAt
+}
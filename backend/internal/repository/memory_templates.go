@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memChecklistTemplateRepository struct {
+	data *memoryData
+}
+
+func (r *memChecklistTemplateRepository) Create(ctx context.Context, template *models.ChecklistTemplate) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.templates[template.ID]; exists {
+		return ErrDuplicate
+	}
+
+	now := time.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	r.data.templates[template.ID] = r.row(template)
+	r.addVersion(template, template.CreatedBy, now)
+
+	return nil
+}
+
+func (r *memChecklistTemplateRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	template, ok := r.data.templates[id]
+	if !ok || template.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	template = r.withContent(template)
+	return &template, nil
+}
+
+func (r *memChecklistTemplateRepository) GetForUpdate(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error) {
+	return r.GetInTenant(ctx, tenantID, id)
+}
+
+func (r *memChecklistTemplateRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.ChecklistTemplate, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	templates := []models.ChecklistTemplate{}
+	for _, template := range r.data.templates {
+		if template.TenantID == tenantID {
+			templates = append(templates, r.withContent(template))
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].CreatedAt.Before(templates[j].CreatedAt)
+	})
+
+	return templates, nil
+}
+
+func (r *memChecklistTemplateRepository) Update(ctx context.Context, template *models.ChecklistTemplate) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.templates[template.ID]
+	if !ok || stored.TenantID != template.TenantID {
+		return ErrNotFound
+	}
+
+	stored.Version = template.Version
+	stored.Assignment = template.Assignment
+	stored.Assignment.DealerIDs = slices.Clone(template.Assignment.DealerIDs)
+	stored.Recurrence = template.Recurrence
+	stored.UpdatedAt = time.Now()
+	r.data.templates[template.ID] = stored
+
+	template.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memChecklistTemplateRepository) AddVersion(ctx context.Context, template *models.ChecklistTemplate, userID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.templates[template.ID]; !ok {
+		return ErrNotFound
+	}
+	if _, exists := r.data.versions[versionKey(template.ID, template.Version)]; exists {
+		return ErrDuplicate
+	}
+
+	r.addVersion(template, userID, time.Now())
+	return nil
+}
+
+func (r *memChecklistTemplateRepository) ListVersions(ctx context.Context, tenantID, id string) ([]models.ChecklistTemplateVersion, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	versions := []models.ChecklistTemplateVersion{}
+	if template, ok := r.data.templates[id]; !ok || template.TenantID != tenantID {
+		return versions, nil
+	}
+
+	for _, version := range r.data.versions {
+		if version.TemplateID == id {
+			versions = append(versions, version)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+
+	return versions, nil
+}
+
+func (r *memChecklistTemplateRepository) Delete(ctx context.Context, tenantID, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	template, ok := r.data.templates[id]
+	if !ok || template.TenantID != tenantID {
+		return ErrNotFound
+	}
+
+	delete(r.data.templates, id)
+	for key, version := range r.data.versions {
+		if version.TemplateID == id {
+			delete(r.data.versions, key)
+		}
+	}
+
+	// Mirrors ON DELETE SET NULL of checklists.template_id
+	for key, checklist := range r.data.checklists {
+		if checklist.TemplateID == id {
+			checklist.TemplateID = ""
+			r.data.checklists[key] = checklist
+		}
+	}
+
+	return nil
+}
+
+// row keeps the columns of checklist_templates; the content lives in the versions
+func (r *memChecklistTemplateRepository) row(template *models.ChecklistTemplate) models.ChecklistTemplate {
+	row := *template
+	row.Name = ""
+	row.Description = ""
+	row.Tasks = nil
+	row.Assignment.DealerIDs = slices.Clone(template.Assignment.DealerIDs)
+	return row
+}
+
+// withContent fills in the content of the template's current version; the
+// caller holds the lock
+func (r *memChecklistTemplateRepository) withContent(template models.ChecklistTemplate) models.ChecklistTemplate {
+	version := r.data.versions[versionKey(template.ID, template.Version)]
+	template.Name = version.Name
+	template.Description = version.Description
+	template.Tasks = slices.Clone(version.Tasks)
+	template.Assignment.DealerIDs = slices.Clone(template.Assignment.DealerIDs)
+	return template
+}
+
+// addVersion records the content of the template; the caller holds the lock
+func (r *memChecklistTemplateRepository) addVersion(template *models.ChecklistTemplate, userID string, at time.Time) {
+	r.data.versions[versionKey(template.ID, template.Version)] = models.ChecklistTemplateVersion{
+		TemplateID:  template.ID,
+		Version:     template.Version,
+		Name:        template.Name,
+		Description: template.Description,
+		Tasks:       slices.Clone(template.Tasks),
+		CreatedBy:   userID,
+		CreatedAt:   at,
+	}
+}
+
+func versionKey(templateID string, version int) string {
+	return fmt.Sprintf("%s/%d", templateID, version)
+}
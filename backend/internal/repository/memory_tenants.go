@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memTenantRepository struct {
+	data *memoryData
+}
+
+func (r *memTenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.tenants[tenant.ID]; exists {
+		return ErrDuplicate
+	}
+	for _, existing := range r.data.tenants {
+		if existing.Slug == tenant.Slug {
+			return ErrDuplicate
+		}
+	}
+
+	if len(tenant.Settings) == 0 {
+		tenant.Settings = []byte(`{}`)
+	}
+
+	now := time.Now()
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+
+	stored := *tenant
+	stored.Settings = append([]byte(nil), tenant.Settings...)
+	r.data.tenants[tenant.ID] = stored
+
+	return nil
+}
+
+func (r *memTenantRepository) GetByID(ctx context.Context, id string) (*models.Tenant, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	tenant, ok := r.data.tenants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	tenant.Settings = append([]byte(nil), tenant.Settings...)
+	return &tenant, nil
+}
+
+func (r *memTenantRepository) GetBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, tenant := range r.data.tenants {
+		if tenant.Slug == slug {
+			tenant.Settings = append([]byte(nil), tenant.Settings...)
+			return &tenant, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memTenantRepository) GetForUpdate(ctx context.Context, id string) (*models.Tenant, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *memTenantRepository) List(ctx context.Context, filter models.TenantFilter) ([]models.Tenant, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	tenants := r.filter(filter)
+
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].CreatedAt.After(tenants[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(tenants) {
+		return []models.Tenant{}, nil
+	}
+	tenants = tenants[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(tenants) {
+		tenants = tenants[:filter.Limit]
+	}
+
+	return tenants, nil
+}
+
+func (r *memTenantRepository) Count(ctx context.Context, filter models.TenantFilter) (int, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	return len(r.filter(filter)), nil
+}
+
+func (r *memTenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.tenants[tenant.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.Name = tenant.Name
+	stored.City = tenant.City
+	stored.Plan = tenant.Plan
+	stored.Settings = append([]byte(nil), tenant.Settings...)
+	stored.UpdatedAt = time.Now()
+	r.data.tenants[tenant.ID] = stored
+
+	tenant.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memTenantRepository) GetStorageUsed(ctx context.Context, id string) (int64, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	if _, ok := r.data.tenants[id]; !ok {
+		return 0, ErrNotFound
+	}
+
+	return r.data.storage[id], nil
+}
+
+func (r *memTenantRepository) SetSuspended(ctx context.Context, id string, suspended bool) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.tenants[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if !suspended {
+		stored.SuspendedAt = nil
+	} else if stored.SuspendedAt == nil {
+		now := time.Now()
+		stored.SuspendedAt = &now
+	}
+	r.data.tenants[id] = stored
+
+	return nil
+}
+
+// Delete mirrors the ON DELETE CASCADE foreign keys of the schema
+func (r *memTenantRepository) Delete(ctx context.Context, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, ok := r.data.tenants[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.data.tenants, id)
+
+	users := map[string]bool{}
+	for userID, user := range r.data.users {
+		if user.TenantID == id {
+			users[userID] = true
+			delete(r.data.users, userID)
+		}
+	}
+
+	for key, session := range r.data.sessions {
+		if users[session.UserID] {
+			delete(r.data.sessions, key)
+		}
+	}
+	for key, token := range r.data.userTokens {
+		if users[token.UserID] {
+			delete(r.data.userTokens, key)
+		}
+	}
+	for key, code := range r.data.recovery {
+		if users[code.UserID] {
+			delete(r.data.recovery, key)
+		}
+	}
+	for key, attempt := range r.data.attempts {
+		if users[attempt.UserID] {
+			attempt.UserID = ""
+			r.data.attempts[key] = attempt
+		}
+	}
+	for key, invitation := range r.data.invites {
+		if invitation.TenantID == id {
+			delete(r.data.invites, key)
+		}
+	}
+	for key, checklist := range r.data.checklists {
+		if checklist.TenantID == id {
+			delete(r.data.checklists, key)
+		}
+	}
+	for key, task := range r.data.tasks {
+		if _, ok := r.data.checklists[task.checklistID]; !ok {
+			delete(r.data.tasks, key)
+		}
+	}
+	for key, role := range r.data.roles {
+		if role.TenantID == id {
+			delete(r.data.roles, key)
+		}
+	}
+	for key, domain := range r.data.domains {
+		if domain.TenantID == id {
+			delete(r.data.domains, key)
+		}
+	}
+	for key, template := range r.data.templates {
+		if template.TenantID == id {
+			delete(r.data.templates, key)
+		}
+	}
+	for key, version := range r.data.versions {
+		if _, ok := r.data.templates[version.TemplateID]; !ok {
+			delete(r.data.versions, key)
+		}
+	}
+	for key, holiday := range r.data.holidays {
+		if holiday.TenantID == id {
+			delete(r.data.holidays, key)
+		}
+	}
+	for key := range r.data.runs {
+		if strings.HasPrefix(key, id+"/") {
+			delete(r.data.runs, key)
+		}
+	}
+	for key, apiKey := range r.data.apiKeys {
+		if apiKey.TenantID == id {
+			delete(r.data.apiKeys, key)
+		}
+	}
+	delete(r.data.storage, id)
+
+	return nil
+}
+
+// filter returns the tenants matching filter; the caller holds the lock
+func (r *memTenantRepository) filter(filter models.TenantFilter) []models.Tenant {
+	search := strings.ToLower(filter.Search)
+
+	tenants := []models.Tenant{}
+	for _, tenant := range r.data.tenants {
+		if search != "" && !strings.Contains(strings.ToLower(tenant.Name), search) && !strings.Contains(tenant.Slug, search) {
+			continue
+		}
+		if filter.Plan != "" && tenant.Plan != filter.Plan {
+			continue
+		}
+		if filter.Status == "active" && tenant.IsSuspended() || filter.Status == "suspended" && !tenant.IsSuspended() {
+			continue
+		}
+
+		tenant.Settings = append([]byte(nil), tenant.Settings...)
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants
+}
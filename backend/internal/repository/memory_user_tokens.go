@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memUserTokenRepository struct {
+	data *memoryData
+}
+
+func (r *memUserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.userTokens[token.ID]; exists {
+		return ErrDuplicate
+	}
+	for _, t := range r.data.userTokens {
+		if t.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	token.CreatedAt = time.Now()
+	r.data.userTokens[token.ID] = *token
+
+	return nil
+}
+
+func (r *memUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.UserToken, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, token := range r.data.userTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memUserTokenRepository) MarkUsed(ctx context.Context, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	token, ok := r.data.userTokens[id]
+	if !ok || token.UsedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	r.data.userTokens[id] = token
+
+	return nil
+}
+
+func (r *memUserTokenRepository) IncrementAttempts(ctx context.Context, id string) (int, error) {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	token, ok := r.data.userTokens[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	token.Attempts++
+	r.data.userTokens[id] = token
+
+	return token.Attempts, nil
+}
+
+func (r *memUserTokenRepository) InvalidateForUser(ctx context.Context, userID, purpose string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.data.userTokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.data.userTokens[id] = token
+		}
+	}
+
+	return nil
+}
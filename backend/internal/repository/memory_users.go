@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type memUserRepository struct {
+	data *memoryData
+}
+
+func (r *memUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	if _, exists := r.data.users[user.ID]; exists {
+		return ErrDuplicate
+	}
+	for _, u := range r.data.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			return ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.data.users[user.ID] = *user
+
+	return nil
+}
+
+func (r *memUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	user, ok := r.data.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (r *memUserRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	user, ok := r.data.users[id]
+	if !ok || user.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (r *memUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	for _, user := range r.data.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.Phone = user.Phone
+	stored.Avatar = user.Avatar
+	if stored.Role != user.Role || stored.IsActive != user.IsActive {
+		stored.TokenVersion++
+	}
+	stored.Role = user.Role
+	stored.IsActive = user.IsActive
+	stored.EmailVerified = user.EmailVerified
+	stored.City = user.City
+	stored.UpdatedAt = time.Now()
+	r.data.users[user.ID] = stored
+
+	user.UpdatedAt = stored.UpdatedAt
+	user.TokenVersion = stored.TokenVersion
+	return nil
+}
+
+func (r *memUserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.Password = passwordHash
+	stored.TokenVersion++
+	stored.UpdatedAt = time.Now()
+	r.data.users[id] = stored
+
+	return nil
+}
+
+func (r *memUserRepository) GetTokenVersion(ctx context.Context, id string) (int, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	stored, ok := r.data.users[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	return stored.TokenVersion, nil
+}
+
+func (r *memUserRepository) UpdateTwoFactor(ctx context.Context, user *models.User) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.TOTPSecret = user.TOTPSecret
+	stored.TwoFactorEnabled = user.TwoFactorEnabled
+	stored.TOTPLastCounter = user.TOTPLastCounter
+	stored.UpdatedAt = time.Now()
+	r.data.users[user.ID] = stored
+
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *memUserRepository) AdvanceTOTPCounter(ctx context.Context, id string, counter int64) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	stored, ok := r.data.users[id]
+	if !ok || stored.TOTPLastCounter >= counter {
+		return ErrNotFound
+	}
+
+	stored.TOTPLastCounter = counter
+	r.data.users[id] = stored
+
+	return nil
+}
+
+func (r *memUserRepository) ListByTenant(ctx context.Context, tenantID, role string) ([]models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range r.data.users {
+		if user.TenantID == tenantID && (role == "" || user.Role == role) {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+func (r *memUserRepository) CountActiveByRole(ctx context.Context, tenantID string) (map[string]int, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, user := range r.data.users {
+		if user.TenantID == tenantID && user.IsActive {
+			counts[user.Role]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *memUserRepository) ListByManager(ctx context.Context, tenantID, managerID string) ([]models.User, error) {
+	r.data.mu.RLock()
+	defer r.data.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range r.data.users {
+		if user.TenantID == tenantID && user.ManagerID == managerID {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+func (r *memUserRepository) AssignDealers(ctx context.Context, tenantID, managerID string, dealerIDs []string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	now := time.Now()
+	for id, user := range r.data.users {
+		if user.TenantID != tenantID {
+			continue
+		}
+
+		assigned := slices.Contains(dealerIDs, id)
+		if !assigned && user.ManagerID != managerID {
+			continue
+		}
+
+		user.ManagerID = ""
+		if assigned {
+			user.ManagerID = managerID
+		}
+		user.UpdatedAt = now
+		r.data.users[id] = user
+	}
+
+	return nil
+}
+
+func (r *memUserRepository) BumpTokenVersion(ctx context.Context, id string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	user, ok := r.data.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.TokenVersion++
+	r.data.users[id] = user
+
+	return nil
+}
+
+func (r *memUserRepository) BumpTokenVersions(ctx context.Context, tenantID string) error {
+	r.data.mu.Lock()
+	defer r.data.mu.Unlock()
+
+	for id, user := range r.data.users {
+		if user.TenantID == tenantID {
+			user.TokenVersion++
+			r.data.users[id] = user
+		}
+	}
+
+	return nil
+}
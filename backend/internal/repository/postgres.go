@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is implemented by both *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PostgresStore implements Store on top of a pgx connection pool. Statements
+// made with a context from WithTenant run with app.tenant_id set, so the
+// row-level security policies limit them to that tenant.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+	db   querier
+}
+
+// NewPostgresStore creates a store that runs queries on the given pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool, db: scopedPool{pool: pool}}
+}
+
+func (s *PostgresStore) Users() UserRepository           { return &pgUserRepository{db: s.db} }
+func (s *PostgresStore) Tenants() TenantRepository       { return &pgTenantRepository{db: s.db} }
+func (s *PostgresStore) Sessions() SessionRepository     { return &pgSessionRepository{db: s.db} }
+func (s *PostgresStore) UserTokens() UserTokenRepository { return &pgUserTokenRepository{db: s.db} }
+func (s *PostgresStore) RecoveryCodes() RecoveryCodeRepository {
+	return &pgRecoveryCodeRepository{db: s.db}
+}
+func (s *PostgresStore) LoginAttempts() LoginAttemptRepository {
+	return &pgLoginAttemptRepository{db: s.db}
+}
+func (s *PostgresStore) Invitations() InvitationRepository {
+	return &pgInvitationRepository{db: s.db}
+}
+func (s *PostgresStore) Checklists() ChecklistRepository { return &pgChecklistRepository{db: s.db} }
+func (s *PostgresStore) Tasks() TaskRepository           { return &pgTaskRepository{db: s.db} }
+func (s *PostgresStore) Audit() AuditRepository          { return &pgAuditRepository{db: s.db} }
+func (s *PostgresStore) Roles() RoleRepository           { return &pgRoleRepository{db: s.db} }
+func (s *PostgresStore) APIKeys() APIKeyRepository       { return &pgAPIKeyRepository{db: s.db} }
+func (s *PostgresStore) Plans() PlanRepository           { return &pgPlanRepository{db: s.db} }
+func (s *PostgresStore) Domains() DomainRepository       { return &pgDomainRepository{db: s.db} }
+func (s *PostgresStore) Templates() ChecklistTemplateRepository {
+	return &pgChecklistTemplateRepository{db: s.db}
+}
+func (s *PostgresStore) Holidays() HolidayRepository { return &pgHolidayRepository{db: s.db} }
+func (s *PostgresStore) ChecklistRuns() ChecklistRunRepository {
+	return &pgChecklistRunRepository{db: s.db}
+}
+
+// WithTx runs fn inside a transaction. Nested calls reuse the outer transaction.
+func (s *PostgresStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if tx, ok := s.db.(pgx.Tx); ok {
+		return fn(&PostgresStore{db: tx})
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if tenantID, ok := TenantFromContext(ctx); ok {
+			if err := setTenant(ctx, tx, tenantID); err != nil {
+				return err
+			}
+		}
+		return fn(&PostgresStore{db: tx})
+	})
+}
+
+// setTenant limits the rest of the transaction to the rows of the tenant
+func setTenant(ctx context.Context, tx pgx.Tx, tenantID string) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID)
+	return err
+}
+
+// scopedPool runs statements outside of a transaction on the pool. When the
+// context carries a tenant, each statement gets a short transaction of its
+// own because app.tenant_id is set per transaction.
+type scopedPool struct {
+	pool *pgxpool.Pool
+}
+
+func (p scopedPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if _, ok := TenantFromContext(ctx); !ok {
+		return p.pool.Exec(ctx, sql, args...)
+	}
+
+	var tag pgconn.CommandTag
+	err := p.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		tag, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+func (p scopedPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return p.pool.Query(ctx, sql, args...)
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setTenant(ctx, tx, tenantID); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return &scopedRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+func (p scopedPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if _, ok := TenantFromContext(ctx); !ok {
+		return p.pool.QueryRow(ctx, sql, args...)
+	}
+
+	return scopedRow{pool: p, ctx: ctx, sql: sql, args: args}
+}
+
+func (p scopedPool) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tenantID, _ := TenantFromContext(ctx)
+
+	return pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		if err := setTenant(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// scopedRow runs the statement when it is scanned, like pgx.Row
+type scopedRow struct {
+	pool scopedPool
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r scopedRow) Scan(dest ...any) error {
+	return r.pool.inTx(r.ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
+
+// scopedRows ends the transaction of the statement once all rows are read or
+// the rows are closed. A failed commit is reported by Err.
+type scopedRows struct {
+	pgx.Rows
+	ctx  context.Context
+	tx   pgx.Tx
+	done bool
+	err  error
+}
+
+func (r *scopedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.finish()
+	return false
+}
+
+func (r *scopedRows) Close() {
+	r.finish()
+}
+
+func (r *scopedRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+func (r *scopedRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	r.Rows.Close()
+	if r.Rows.Err() != nil {
+		_ = r.tx.Rollback(r.ctx)
+		return
+	}
+	r.err = r.tx.Commit(r.ctx)
+}
+
+// mapError converts driver errors into repository errors
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+
+	return err
+}
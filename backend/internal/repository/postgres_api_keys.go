@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `id, tenant_id, name, prefix, key_hash, scopes, COALESCE(created_by::text, ''),
+	expires_at, last_used_at, revoked_at, created_at`
+
+type pgAPIKeyRepository struct {
+	db querier
+}
+
+func (r *pgAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (id, tenant_id, name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, $8)
+		RETURNING created_at`,
+		key.ID, key.TenantID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedBy, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pgx.CollectExactlyOneRow(rows, scanAPIKey)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &key, nil
+}
+
+func (r *pgAPIKeyRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanAPIKey)
+}
+
+func (r *pgAPIKeyRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL`, id, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgAPIKeyRepository) TouchLastUsed(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE api_keys
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`, id)
+	return mapError(err)
+}
+
+func scanAPIKey(row pgx.CollectableRow) (models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(&k.ID, &k.TenantID, &k.Name, &k.Prefix, &k.KeyHash, &k.Scopes, &k.CreatedBy,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+	return k, err
+}
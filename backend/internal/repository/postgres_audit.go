@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const auditColumns = `id, actor_id, user_id, tenant_id, action, COALESCE(method, ''), COALESCE(path, ''), COALESCE(status, 0),
+	COALESCE(reason, ''), COALESCE(HOST(ip_address), ''), COALESCE(user_agent, ''), created_at`
+
+type pgAuditRepository struct {
+	db querier
+}
+
+func (r *pgAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO audit_log (id, actor_id, user_id, tenant_id, action, method, path, status, reason, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, ''), NULLIF($10, '')::inet, NULLIF($11, ''))
+		RETURNING created_at`,
+		entry.ID, entry.ActorID, entry.UserID, entry.TenantID, entry.Action, entry.Method, entry.Path,
+		entry.Status, entry.Reason, entry.IPAddress, entry.UserAgent,
+	).Scan(&entry.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgAuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+
+	if filter.ActorID != "" {
+		args = append(args, filter.ActorID)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if filter.TenantID != "" {
+		args = append(args, filter.TenantID)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT `+auditColumns+`
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AuditEntry, error) {
+		var e models.AuditEntry
+		err := row.Scan(&e.ID, &e.ActorID, &e.UserID, &e.TenantID, &e.Action, &e.Method, &e.Path, &e.Status,
+			&e.Reason, &e.IPAddress, &e.UserAgent, &e.CreatedAt)
+		return e, err
+	})
+}
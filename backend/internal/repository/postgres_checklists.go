@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const checklistColumns = `id, title, COALESCE(description, ''), user_id, tenant_id, date, status, kpi_score, created_at, updated_at,
+	COALESCE(template_id::text, ''), COALESCE(template_version, 0)`
+
+const taskColumns = `id, checklist_id, title, COALESCE(description, ''), COALESCE(category, ''), COALESCE(priority, 'medium'),
+	status, position, deadline, completed_at, created_at, updated_at,
+	verified_at, COALESCE(verified_by::text, ''), COALESCE(review_comment, ''), COALESCE(verification, '')`
+
+type pgChecklistRepository struct {
+	db querier
+}
+
+func (r *pgChecklistRepository) ListByUser(ctx context.Context, tenantID, userID string, limit, offset int) ([]models.Checklist, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+checklistColumns+`
+		FROM checklists
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY date DESC, created_at DESC
+		LIMIT $3 OFFSET $4`, tenantID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanChecklist)
+}
+
+func (r *pgChecklistRepository) GetByID(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error) {
+	return r.getOne(ctx, `SELECT `+checklistColumns+` FROM checklists WHERE id = $1 AND user_id = $2 AND tenant_id = $3`, id, userID, tenantID)
+}
+
+func (r *pgChecklistRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.Checklist, error) {
+	return r.getOne(ctx, `SELECT `+checklistColumns+` FROM checklists WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+}
+
+func (r *pgChecklistRepository) GetForUpdate(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error) {
+	return r.getOne(ctx, `SELECT `+checklistColumns+` FROM checklists WHERE id = $1 AND user_id = $2 AND tenant_id = $3 FOR UPDATE`, id, userID, tenantID)
+}
+
+func (r *pgChecklistRepository) Create(ctx context.Context, checklist *models.Checklist) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO checklists (id, tenant_id, user_id, date, title, description, status, kpi_score, template_id, template_version)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NULLIF($9, '')::uuid, NULLIF($10, 0))
+		RETURNING created_at, updated_at`,
+		checklist.ID, checklist.TenantID, checklist.UserID, checklist.Date,
+		checklist.Title, checklist.Description, checklist.Status, checklist.KPIScore,
+		checklist.TemplateID, checklist.TemplateVersion,
+	).Scan(&checklist.CreatedAt, &checklist.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgChecklistRepository) Update(ctx context.Context, checklist *models.Checklist) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE checklists
+		SET title = $1, description = NULLIF($2, ''), status = $3, kpi_score = $4
+		WHERE id = $5 AND user_id = $6 AND tenant_id = $7
+		RETURNING updated_at`,
+		checklist.Title, checklist.Description, checklist.Status, checklist.KPIScore, checklist.ID, checklist.UserID, checklist.TenantID,
+	).Scan(&checklist.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgChecklistRepository) Delete(ctx context.Context, tenantID, id, userID string) error {
+	// Tasks are removed by ON DELETE CASCADE
+	tag, err := r.db.Exec(ctx, `DELETE FROM checklists WHERE id = $1 AND user_id = $2 AND tenant_id = $3`, id, userID, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgChecklistRepository) ListUserIDsByDate(ctx context.Context, tenantID string, date time.Time) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT user_id::text FROM checklists WHERE tenant_id = $1 AND date = $2`, tenantID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *pgChecklistRepository) getOne(ctx context.Context, query string, args ...any) (*models.Checklist, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist, err := pgx.CollectExactlyOneRow(rows, scanChecklist)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &checklist, nil
+}
+
+type pgTaskRepository struct {
+	db querier
+}
+
+func (r *pgTaskRepository) ListByChecklist(ctx context.Context, checklistID string) ([]models.Task, error) {
+	byChecklist, err := r.ListByChecklists(ctx, []string{checklistID})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := byChecklist[checklistID]
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return tasks, nil
+}
+
+func (r *pgTaskRepository) ListByChecklists(ctx context.Context, checklistIDs []string) (map[string][]models.Task, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+taskColumns+`
+		FROM checklist_tasks
+		WHERE checklist_id = ANY($1)
+		ORDER BY position, created_at`, checklistIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]models.Task, len(checklistIDs))
+	for rows.Next() {
+		var t models.Task
+		var checklistID string
+		err := rows.Scan(&t.ID, &checklistID, &t.Title, &t.Description, &t.Category, &t.Priority,
+			&t.Status, &t.Order, &t.Deadline, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt,
+			&t.VerifiedAt, &t.VerifiedBy, &t.ReviewComment, &t.Verification)
+		if err != nil {
+			return nil, err
+		}
+		result[checklistID] = append(result[checklistID], t)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *pgTaskRepository) Create(ctx context.Context, checklistID string, task *models.Task) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO checklist_tasks (id, checklist_id, title, description, category, priority, status, position, deadline, completed_at, verification)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, NULLIF($11, ''))
+		RETURNING created_at, updated_at`,
+		task.ID, checklistID, task.Title, task.Description, task.Category,
+		task.Priority, task.Status, task.Order, task.Deadline, task.CompletedAt, task.Verification,
+	).Scan(&task.CreatedAt, &task.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgTaskRepository) Update(ctx context.Context, checklistID string, task *models.Task) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE checklist_tasks
+		SET title = $1, description = NULLIF($2, ''), category = NULLIF($3, ''), priority = $4,
+			status = $5, position = $6, deadline = $7, completed_at = $8, verification = NULLIF($9, '')
+		WHERE id = $10 AND checklist_id = $11
+		RETURNING created_at, updated_at`,
+		task.Title, task.Description, task.Category, task.Priority,
+		task.Status, task.Order, task.Deadline, task.CompletedAt, task.Verification, task.ID, checklistID,
+	).Scan(&task.CreatedAt, &task.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgTaskRepository) Review(ctx context.Context, checklistID string, task *models.Task) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE checklist_tasks
+		SET verified_at = $1, verified_by = NULLIF($2, '')::uuid, review_comment = NULLIF($3, '')
+		WHERE id = $4 AND checklist_id = $5
+		RETURNING updated_at`,
+		task.VerifiedAt, task.VerifiedBy, task.ReviewComment, task.ID, checklistID,
+	).Scan(&task.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgTaskRepository) DeleteExcept(ctx context.Context, checklistID string, keepIDs []string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM checklist_tasks WHERE checklist_id = $1 AND NOT (id = ANY($2))`, checklistID, keepIDs)
+	return mapError(err)
+}
+
+func (r *pgTaskRepository) CompleteAll(ctx context.Context, checklistID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE checklist_tasks
+		SET status = 'completed', completed_at = COALESCE(completed_at, CURRENT_TIMESTAMP)
+		WHERE checklist_id = $1 AND status <> 'completed'`, checklistID)
+	return mapError(err)
+}
+
+func scanChecklist(row pgx.CollectableRow) (models.Checklist, error) {
+	var c models.Checklist
+	err := row.Scan(&c.ID, &c.Title, &c.Description, &c.UserID, &c.TenantID, &c.Date,
+		&c.Status, &c.KPIScore, &c.CreatedAt, &c.UpdatedAt,
+		&c.TemplateID, &c.TemplateVersion)
+	return c, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const domainColumns = `id, tenant_id, domain, verification_token, verified_at, created_at`
+
+type pgDomainRepository struct {
+	db querier
+}
+
+func (r *pgDomainRepository) Create(ctx context.Context, domain *models.TenantDomain) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO tenant_domains (id, tenant_id, domain, verification_token)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`,
+		domain.ID, domain.TenantID, domain.Domain, domain.VerificationToken,
+	).Scan(&domain.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgDomainRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.TenantDomain, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+domainColumns+`
+		FROM tenant_domains
+		WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := pgx.CollectExactlyOneRow(rows, scanDomain)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &domain, nil
+}
+
+func (r *pgDomainRepository) GetVerified(ctx context.Context, name string) (*models.TenantDomain, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+domainColumns+`
+		FROM tenant_domains
+		WHERE domain = $1 AND verified_at IS NOT NULL`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := pgx.CollectExactlyOneRow(rows, scanDomain)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &domain, nil
+}
+
+func (r *pgDomainRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.TenantDomain, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+domainColumns+`
+		FROM tenant_domains
+		WHERE tenant_id = $1
+		ORDER BY domain`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanDomain)
+}
+
+func (r *pgDomainRepository) MarkVerified(ctx context.Context, tenantID, id string, at time.Time) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE tenant_domains
+		SET verified_at = $3
+		WHERE id = $1 AND tenant_id = $2`, id, tenantID, at)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgDomainRepository) Delete(ctx context.Context, tenantID, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenant_domains WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanDomain(row pgx.CollectableRow) (models.TenantDomain, error) {
+	var d models.TenantDomain
+	err := row.Scan(&d.ID, &d.TenantID, &d.Domain, &d.VerificationToken, &d.VerifiedAt, &d.CreatedAt)
+	return d, err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type pgHolidayRepository struct {
+	db querier
+}
+
+func (r *pgHolidayRepository) Create(ctx context.Context, holiday *models.Holiday) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO tenant_holidays (tenant_id, date, name)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING created_at`,
+		holiday.TenantID, holiday.Date, holiday.Name,
+	).Scan(&holiday.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgHolidayRepository) ListByTenant(ctx context.Context, tenantID string, from, to time.Time) ([]models.Holiday, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT tenant_id, date, COALESCE(name, ''), created_at
+		FROM tenant_holidays
+		WHERE tenant_id = $1 AND date BETWEEN $2 AND $3
+		ORDER BY date`, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Holiday, error) {
+		var h models.Holiday
+		err := row.Scan(&h.TenantID, &h.Date, &h.Name, &h.CreatedAt)
+		return h, err
+	})
+}
+
+func (r *pgHolidayRepository) IsHoliday(ctx context.Context, tenantID string, date time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tenant_holidays WHERE tenant_id = $1 AND date = $2)`, tenantID, date).Scan(&exists)
+	return exists, mapError(err)
+}
+
+func (r *pgHolidayRepository) Delete(ctx context.Context, tenantID string, date time.Time) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenant_holidays WHERE tenant_id = $1 AND date = $2`, tenantID, date)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type pgChecklistRunRepository struct {
+	db querier
+}
+
+func (r *pgChecklistRunRepository) Claim(ctx context.Context, tenantID string, date time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO checklist_generation_runs (tenant_id, date)
+		VALUES ($1, $2)
+		ON CONFLICT (tenant_id, date) DO NOTHING`, tenantID, date)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *pgChecklistRunRepository) Finish(ctx context.Context, tenantID string, date time.Time, created int) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE checklist_generation_runs
+		SET checklists_created = $1
+		WHERE tenant_id = $2 AND date = $3`, created, tenantID, date)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const invitationColumns = `id, tenant_id, email, role, COALESCE(manager_id::text, ''), COALESCE(first_name, ''), COALESCE(last_name, ''),
+	COALESCE(invited_by::text, ''), token_hash, expires_at, accepted_at, COALESCE(accepted_user_id::text, ''), revoked_at,
+	created_at, updated_at`
+
+type pgInvitationRepository struct {
+	db querier
+}
+
+func (r *pgInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO invitations (id, tenant_id, email, role, manager_id, first_name, last_name, invited_by, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, '')::uuid, $9, $10)
+		RETURNING created_at, updated_at`,
+		invitation.ID, invitation.TenantID, invitation.Email, invitation.Role, invitation.ManagerID,
+		invitation.FirstName, invitation.LastName, invitation.InvitedBy, invitation.TokenHash, invitation.ExpiresAt,
+	).Scan(&invitation.CreatedAt, &invitation.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgInvitationRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Invitation, error) {
+	return r.getOne(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+}
+
+func (r *pgInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	return r.getOne(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE token_hash = $1 FOR UPDATE`, tokenHash)
+}
+
+func (r *pgInvitationRepository) GetOpenByEmail(ctx context.Context, tenantID, email string) (*models.Invitation, error) {
+	return r.getOne(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations
+		WHERE tenant_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND revoked_at IS NULL`,
+		tenantID, email)
+}
+
+func (r *pgInvitationRepository) ListOpen(ctx context.Context, tenantID string) ([]models.Invitation, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invitationColumns+`
+		FROM invitations
+		WHERE tenant_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanInvitation)
+}
+
+func (r *pgInvitationRepository) UpdateToken(ctx context.Context, invitation *models.Invitation) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE invitations
+		SET token_hash = $1, expires_at = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING updated_at`,
+		invitation.TokenHash, invitation.ExpiresAt, invitation.ID,
+	).Scan(&invitation.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgInvitationRepository) MarkAccepted(ctx context.Context, id, userID string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE invitations
+		SET accepted_at = CURRENT_TIMESTAMP, accepted_user_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`, userID, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgInvitationRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE invitations
+		SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`, id, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgInvitationRepository) getOne(ctx context.Context, query string, args ...any) (*models.Invitation, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := pgx.CollectExactlyOneRow(rows, scanInvitation)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &invitation, nil
+}
+
+func scanInvitation(row pgx.CollectableRow) (models.Invitation, error) {
+	var i models.Invitation
+	err := row.Scan(&i.ID, &i.TenantID, &i.Email, &i.Role, &i.ManagerID, &i.FirstName, &i.LastName,
+		&i.InvitedBy, &i.TokenHash, &i.ExpiresAt, &i.AcceptedAt, &i.AcceptedUserID, &i.RevokedAt,
+		&i.CreatedAt, &i.UpdatedAt)
+	return i, err
+}
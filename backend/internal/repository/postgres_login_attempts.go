@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type pgLoginAttemptRepository struct {
+	db querier
+}
+
+func (r *pgLoginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO login_attempts (id, user_id, email, ip_address, user_agent, success, failure_reason)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, NULLIF($4, '')::inet, NULLIF($5, ''), $6, NULLIF($7, ''))
+		RETURNING created_at`,
+		attempt.ID, attempt.UserID, attempt.Email, attempt.IPAddress, attempt.UserAgent, attempt.Success, attempt.FailureReason,
+	).Scan(&attempt.CreatedAt)
+	return mapError(err)
+}
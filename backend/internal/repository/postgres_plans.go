@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"franchise-saas-backend/internal/models"
+)
+
+type pgPlanRepository struct {
+	db querier
+}
+
+func (r *pgPlanRepository) GetFeatures(ctx context.Context, plan string) (*models.FeatureSettings, error) {
+	var raw []byte
+	if err := r.db.QueryRow(ctx, `SELECT features FROM plan_features WHERE plan = $1`, plan).Scan(&raw); err != nil {
+		return nil, mapError(err)
+	}
+
+	var features models.FeatureSettings
+	if err := json.Unmarshal(raw, &features); err != nil {
+		return nil, err
+	}
+
+	return &features, nil
+}
+
+func (r *pgPlanRepository) SetFeatures(ctx context.Context, plan string, features models.FeatureSettings) error {
+	raw, err := json.Marshal(features)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO plan_features (plan, features)
+		VALUES ($1, $2)
+		ON CONFLICT (plan) DO UPDATE SET features = EXCLUDED.features`, plan, raw)
+	return mapError(err)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+)
+
+type pgRecoveryCodeRepository struct {
+	db querier
+}
+
+func (r *pgRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID string, codeHashes []string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return mapError(err)
+	}
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_recovery_codes (user_id, code_hash)
+		SELECT $1, UNNEST($2::text[])`, userID, codeHashes)
+	return mapError(err)
+}
+
+func (r *pgRecoveryCodeRepository) Use(ctx context.Context, userID, codeHash string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgRecoveryCodeRepository) CountUnused(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	return count, mapError(err)
+}
+
+func (r *pgRecoveryCodeRepository) DeleteForUser(ctx context.Context, userID string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+	return mapError(err)
+}
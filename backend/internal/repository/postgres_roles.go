@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const roleColumns = `id, tenant_id, name, COALESCE(description, ''), permissions, built_in, created_at, updated_at`
+
+type pgRoleRepository struct {
+	db querier
+}
+
+func (r *pgRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO roles (id, tenant_id, name, description, permissions, built_in)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING created_at, updated_at`,
+		role.ID, role.TenantID, role.Name, role.Description, role.Permissions, role.BuiltIn,
+	).Scan(&role.CreatedAt, &role.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgRoleRepository) GetByID(ctx context.Context, tenantID, id string) (*models.Role, error) {
+	return r.getOne(ctx, `SELECT `+roleColumns+` FROM roles WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+}
+
+func (r *pgRoleRepository) GetByName(ctx context.Context, tenantID, name string) (*models.Role, error) {
+	return r.getOne(ctx, `SELECT `+roleColumns+` FROM roles WHERE name = $1 AND tenant_id = $2`, name, tenantID)
+}
+
+func (r *pgRoleRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.Role, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+roleColumns+`
+		FROM roles
+		WHERE tenant_id = $1
+		ORDER BY built_in DESC, created_at, name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanRole)
+}
+
+func (r *pgRoleRepository) Update(ctx context.Context, role *models.Role) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE roles
+		SET description = NULLIF($1, ''), permissions = $2
+		WHERE id = $3 AND tenant_id = $4
+		RETURNING updated_at`,
+		role.Description, role.Permissions, role.ID, role.TenantID,
+	).Scan(&role.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgRoleRepository) Delete(ctx context.Context, tenantID, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM roles WHERE id = $1 AND tenant_id = $2 AND NOT built_in`, id, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgRoleRepository) IsInUse(ctx context.Context, tenantID, name string) (bool, error) {
+	var inUse bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE tenant_id = $1 AND role = $2)
+			OR EXISTS (SELECT 1 FROM invitations WHERE tenant_id = $1 AND role = $2 AND accepted_at IS NULL AND revoked_at IS NULL)`,
+		tenantID, name).Scan(&inUse)
+	return inUse, mapError(err)
+}
+
+func (r *pgRoleRepository) getOne(ctx context.Context, query string, args ...any) (*models.Role, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := pgx.CollectExactlyOneRow(rows, scanRole)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &role, nil
+}
+
+func scanRole(row pgx.CollectableRow) (models.Role, error) {
+	var role models.Role
+	err := row.Scan(&role.ID, &role.TenantID, &role.Name, &role.Description, &role.Permissions,
+		&role.BuiltIn, &role.CreatedAt, &role.UpdatedAt)
+	return role, err
+}
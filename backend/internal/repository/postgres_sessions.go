@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = `id, user_id, family_id, refresh_token, expires_at, authenticated_at, last_used_at, rotated_at,
+	revoked_at, COALESCE(revoked_reason, ''), created_at, COALESCE(HOST(ip_address), ''), COALESCE(user_agent, '')`
+
+type pgSessionRepository struct {
+	db querier
+}
+
+func (r *pgSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO sessions (id, user_id, family_id, refresh_token, expires_at, authenticated_at, last_used_at, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, NULLIF($9, ''))
+		RETURNING created_at`,
+		session.ID, session.UserID, session.FamilyID, session.RefreshToken, session.ExpiresAt,
+		session.AuthenticatedAt, session.LastUsedAt, session.IPAddress, session.UserAgent,
+	).Scan(&session.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgSessionRepository) GetByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE refresh_token = $1 FOR UPDATE`, refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := pgx.CollectExactlyOneRow(rows, scanSession)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &session, nil
+}
+
+func (r *pgSessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY COALESCE(last_used_at, created_at) DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanSession)
+}
+
+func (r *pgSessionRepository) MarkRotated(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `UPDATE sessions SET rotated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	return mapError(err)
+}
+
+func (r *pgSessionRepository) IsFamilyActive(ctx context.Context, userID, familyID string) (bool, error) {
+	var active bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL
+		)`, userID, familyID).Scan(&active)
+	return active, mapError(err)
+}
+
+func (r *pgSessionRepository) RevokeFamily(ctx context.Context, userID, familyID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $3
+		WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`, userID, familyID, reason)
+	return mapError(err)
+}
+
+func (r *pgSessionRepository) RevokeAllForUser(ctx context.Context, userID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID, reason)
+	return mapError(err)
+}
+
+func (r *pgSessionRepository) RevokeAllForTenant(ctx context.Context, tenantID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+		WHERE revoked_at IS NULL AND user_id IN (SELECT id FROM users WHERE tenant_id = $1)`, tenantID, reason)
+	return mapError(err)
+}
+
+func (r *pgSessionRepository) RevokeAllExcept(ctx context.Context, userID, familyID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $3
+		WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`, userID, familyID, reason)
+	return mapError(err)
+}
+
+func scanSession(row pgx.CollectableRow) (models.Session, error) {
+	var s models.Session
+	err := row.Scan(&s.ID, &s.UserID, &s.FamilyID, &s.RefreshToken, &s.ExpiresAt, &s.AuthenticatedAt, &s.LastUsedAt,
+		&s.RotatedAt, &s.RevokedAt, &s.RevokedReason, &s.CreatedAt, &s.IPAddress, &s.UserAgent)
+	return s, err
+}
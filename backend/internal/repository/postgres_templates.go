@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Templates are read together with the content of their current version
+const templateColumns = `t.id, t.tenant_id, v.name, COALESCE(v.description, ''), t.version, v.tasks, t.assignment,
+	COALESCE(t.created_by::text, ''), t.created_at, t.updated_at, t.recurrence`
+
+const templateFrom = `checklist_templates t
+	JOIN checklist_template_versions v ON v.template_id = t.id AND v.version = t.version`
+
+type pgChecklistTemplateRepository struct {
+	db querier
+}
+
+func (r *pgChecklistTemplateRepository) Create(ctx context.Context, template *models.ChecklistTemplate) error {
+	assignment, recurrence, err := marshalSchedule(template)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO checklist_templates (id, tenant_id, version, assignment, recurrence, created_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid)
+		RETURNING created_at, updated_at`,
+		template.ID, template.TenantID, template.Version, assignment, recurrence, template.CreatedBy,
+	).Scan(&template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return mapError(err)
+	}
+
+	return r.AddVersion(ctx, template, template.CreatedBy)
+}
+
+func (r *pgChecklistTemplateRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error) {
+	return r.getOne(ctx, `SELECT `+templateColumns+` FROM `+templateFrom+` WHERE t.id = $1 AND t.tenant_id = $2`, id, tenantID)
+}
+
+func (r *pgChecklistTemplateRepository) GetForUpdate(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error) {
+	return r.getOne(ctx, `SELECT `+templateColumns+` FROM `+templateFrom+` WHERE t.id = $1 AND t.tenant_id = $2 FOR UPDATE OF t`, id, tenantID)
+}
+
+func (r *pgChecklistTemplateRepository) ListByTenant(ctx context.Context, tenantID string) ([]models.ChecklistTemplate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+templateColumns+`
+		FROM `+templateFrom+`
+		WHERE t.tenant_id = $1
+		ORDER BY v.name, t.created_at`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanTemplate)
+}
+
+func (r *pgChecklistTemplateRepository) Update(ctx context.Context, template *models.ChecklistTemplate) error {
+	assignment, recurrence, err := marshalSchedule(template)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		UPDATE checklist_templates
+		SET version = $1, assignment = $2, recurrence = $3
+		WHERE id = $4 AND tenant_id = $5
+		RETURNING updated_at`,
+		template.Version, assignment, recurrence, template.ID, template.TenantID,
+	).Scan(&template.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgChecklistTemplateRepository) AddVersion(ctx context.Context, template *models.ChecklistTemplate, userID string) error {
+	tasks, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO checklist_template_versions (template_id, version, name, description, tasks, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, '')::uuid)`,
+		template.ID, template.Version, template.Name, template.Description, tasks, userID)
+	return mapError(err)
+}
+
+func (r *pgChecklistTemplateRepository) ListVersions(ctx context.Context, tenantID, id string) ([]models.ChecklistTemplateVersion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT v.template_id, v.version, v.name, COALESCE(v.description, ''), v.tasks, COALESCE(v.created_by::text, ''), v.created_at
+		FROM checklist_template_versions v
+		JOIN checklist_templates t ON t.id = v.template_id
+		WHERE v.template_id = $1 AND t.tenant_id = $2
+		ORDER BY v.version DESC`, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.ChecklistTemplateVersion, error) {
+		var v models.ChecklistTemplateVersion
+		var tasks []byte
+		if err := row.Scan(&v.TemplateID, &v.Version, &v.Name, &v.Description, &tasks, &v.CreatedBy, &v.CreatedAt); err != nil {
+			return v, err
+		}
+		return v, json.Unmarshal(tasks, &v.Tasks)
+	})
+}
+
+func (r *pgChecklistTemplateRepository) Delete(ctx context.Context, tenantID, id string) error {
+	// Versions are removed by ON DELETE CASCADE, checklists lose the reference
+	tag, err := r.db.Exec(ctx, `DELETE FROM checklist_templates WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgChecklistTemplateRepository) getOne(ctx context.Context, query string, args ...any) (*models.ChecklistTemplate, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := pgx.CollectExactlyOneRow(rows, scanTemplate)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &template, nil
+}
+
+func scanTemplate(row pgx.CollectableRow) (models.ChecklistTemplate, error) {
+	var t models.ChecklistTemplate
+	var tasks, assignment, recurrence []byte
+	err := row.Scan(&t.ID, &t.TenantID, &t.Name, &t.Description, &t.Version, &tasks, &assignment,
+		&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &recurrence)
+	if err != nil {
+		return t, err
+	}
+
+	if err := json.Unmarshal(tasks, &t.Tasks); err != nil {
+		return t, err
+	}
+	if recurrence != nil {
+		if err := json.Unmarshal(recurrence, &t.Recurrence); err != nil {
+			return t, err
+		}
+	}
+	return t, json.Unmarshal(assignment, &t.Assignment)
+}
+
+// marshalSchedule encodes the assignment and the recurrence of the template;
+// a template without recurrence stores NULL
+func marshalSchedule(template *models.ChecklistTemplate) (assignment, recurrence []byte, err error) {
+	if assignment, err = json.Marshal(template.Assignment); err != nil {
+		return nil, nil, err
+	}
+	if template.Recurrence != nil {
+		if recurrence, err = json.Marshal(template.Recurrence); err != nil {
+			return nil, nil, err
+		}
+	}
+	return assignment, recurrence, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const tenantColumns = `id, name, slug, city, plan, settings, created_at, updated_at, suspended_at`
+
+type pgTenantRepository struct {
+	db querier
+}
+
+func (r *pgTenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	if len(tenant.Settings) == 0 {
+		tenant.Settings = []byte(`{}`)
+	}
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO tenants (id, name, slug, city, plan, settings)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`,
+		tenant.ID, tenant.Name, tenant.Slug, tenant.City, tenant.Plan, tenant.Settings,
+	).Scan(&tenant.CreatedAt, &tenant.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgTenantRepository) GetByID(ctx context.Context, id string) (*models.Tenant, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := pgx.CollectExactlyOneRow(rows, scanTenant)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &tenant, nil
+}
+
+func (r *pgTenantRepository) GetBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE slug = $1`, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := pgx.CollectExactlyOneRow(rows, scanTenant)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &tenant, nil
+}
+
+func (r *pgTenantRepository) GetForUpdate(ctx context.Context, id string) (*models.Tenant, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := pgx.CollectExactlyOneRow(rows, scanTenant)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &tenant, nil
+}
+
+func (r *pgTenantRepository) List(ctx context.Context, filter models.TenantFilter) ([]models.Tenant, error) {
+	where, args := tenantFilterSQL(filter)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT `+tenantColumns+`
+		FROM tenants
+		%s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanTenant)
+}
+
+func (r *pgTenantRepository) Count(ctx context.Context, filter models.TenantFilter) (int, error) {
+	where, args := tenantFilterSQL(filter)
+
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM tenants `+where, args...).Scan(&count)
+	return count, mapError(err)
+}
+
+func (r *pgTenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE tenants
+		SET name = $1, city = $2, plan = $3, settings = $4
+		WHERE id = $5
+		RETURNING updated_at`,
+		tenant.Name, tenant.City, tenant.Plan, tenant.Settings, tenant.ID,
+	).Scan(&tenant.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgTenantRepository) GetStorageUsed(ctx context.Context, id string) (int64, error) {
+	var used int64
+	err := r.db.QueryRow(ctx, `SELECT storage_used_bytes FROM tenants WHERE id = $1`, id).Scan(&used)
+	return used, mapError(err)
+}
+
+func (r *pgTenantRepository) SetSuspended(ctx context.Context, id string, suspended bool) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE tenants
+		SET suspended_at = CASE WHEN $1 THEN COALESCE(suspended_at, CURRENT_TIMESTAMP) END
+		WHERE id = $2`, suspended, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgTenantRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// tenantFilterSQL builds the WHERE clause shared by List and Count
+func tenantFilterSQL(filter models.TenantFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR slug ILIKE $%d)", len(args), len(args)))
+	}
+	if filter.Plan != "" {
+		args = append(args, filter.Plan)
+		conditions = append(conditions, fmt.Sprintf("plan = $%d", len(args)))
+	}
+	switch filter.Status {
+	case "active":
+		conditions = append(conditions, "suspended_at IS NULL")
+	case "suspended":
+		conditions = append(conditions, "suspended_at IS NOT NULL")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func scanTenant(row pgx.CollectableRow) (models.Tenant, error) {
+	var t models.Tenant
+	err := row.Scan(&t.ID, &t.Name, &t.Slug, &t.City, &t.Plan, &t.Settings, &t.CreatedAt, &t.UpdatedAt, &t.SuspendedAt)
+	return t, err
+}
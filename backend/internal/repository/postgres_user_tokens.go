@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userTokenColumns = `id, user_id, purpose, token_hash, expires_at, used_at, attempts, created_at`
+
+type pgUserTokenRepository struct {
+	db querier
+}
+
+func (r *pgUserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+	return mapError(err)
+}
+
+func (r *pgUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.UserToken, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+userTokenColumns+` FROM user_tokens WHERE token_hash = $1 FOR UPDATE`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := pgx.CollectExactlyOneRow(rows, scanUserToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &token, nil
+}
+
+func (r *pgUserTokenRepository) MarkUsed(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL`, id)
+	return mapError(err)
+}
+
+func (r *pgUserTokenRepository) IncrementAttempts(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(ctx, `UPDATE user_tokens SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`, id).Scan(&attempts)
+	return attempts, mapError(err)
+}
+
+func (r *pgUserTokenRepository) InvalidateForUser(ctx context.Context, userID, purpose string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE user_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, purpose)
+	return mapError(err)
+}
+
+func scanUserToken(row pgx.CollectableRow) (models.UserToken, error) {
+	var t models.UserToken
+	err := row.Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.Attempts, &t.CreatedAt)
+	return t, err
+}
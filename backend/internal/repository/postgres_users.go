@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+
+	"franchise-saas-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userColumns = `id, email, password_hash, role, tenant_id, COALESCE(manager_id::text, ''), COALESCE(first_name, ''), COALESCE(last_name, ''),
+	COALESCE(phone, ''), COALESCE(avatar, ''), COALESCE(is_active, TRUE), COALESCE(email_verified, FALSE), created_at, updated_at,
+	two_factor_enabled, COALESCE(totp_secret, ''), totp_last_counter, token_version, COALESCE(city, '')`
+
+type pgUserRepository struct {
+	db querier
+}
+
+func (r *pgUserRepository) Create(ctx context.Context, user *models.User) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO users (id, email, password_hash, role, tenant_id, first_name, last_name, phone, avatar, is_active, email_verified, manager_id, city)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10, $11, NULLIF($12, '')::uuid, NULLIF($13, ''))
+		RETURNING created_at, updated_at`,
+		user.ID, user.Email, user.Password, user.Role, user.TenantID, user.FirstName, user.LastName,
+		user.Phone, user.Avatar, user.IsActive, user.EmailVerified, user.ManagerID, user.City,
+	).Scan(&user.CreatedAt, &user.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return r.getOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+}
+
+func (r *pgUserRepository) GetInTenant(ctx context.Context, tenantID, id string) (*models.User, error) {
+	return r.getOne(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+}
+
+func (r *pgUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.getOne(ctx, `SELECT `+userColumns+` FROM users WHERE LOWER(email) = LOWER($1)`, email)
+}
+
+func (r *pgUserRepository) Update(ctx context.Context, user *models.User) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE users
+		SET first_name = NULLIF($1, ''), last_name = NULLIF($2, ''), phone = NULLIF($3, ''), avatar = NULLIF($4, ''),
+			role = $5, is_active = $6, email_verified = $7, city = NULLIF($8, ''),
+			token_version = token_version + CASE WHEN role <> $5 OR is_active <> $6 THEN 1 ELSE 0 END
+		WHERE id = $9
+		RETURNING updated_at, token_version`,
+		user.FirstName, user.LastName, user.Phone, user.Avatar, user.Role, user.IsActive, user.EmailVerified, user.City, user.ID,
+	).Scan(&user.UpdatedAt, &user.TokenVersion)
+	return mapError(err)
+}
+
+func (r *pgUserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE users SET password_hash = $1, token_version = token_version + 1 WHERE id = $2`, passwordHash, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgUserRepository) GetTokenVersion(ctx context.Context, id string) (int, error) {
+	var version int
+	err := r.db.QueryRow(ctx, `SELECT token_version FROM users WHERE id = $1`, id).Scan(&version)
+	return version, mapError(err)
+}
+
+func (r *pgUserRepository) UpdateTwoFactor(ctx context.Context, user *models.User) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE users
+		SET totp_secret = NULLIF($1, ''), two_factor_enabled = $2, totp_last_counter = $3
+		WHERE id = $4
+		RETURNING updated_at`,
+		user.TOTPSecret, user.TwoFactorEnabled, user.TOTPLastCounter, user.ID,
+	).Scan(&user.UpdatedAt)
+	return mapError(err)
+}
+
+func (r *pgUserRepository) AdvanceTOTPCounter(ctx context.Context, id string, counter int64) error {
+	tag, err := r.db.Exec(ctx, `UPDATE users SET totp_last_counter = $1 WHERE id = $2 AND totp_last_counter < $1`, counter, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgUserRepository) ListByTenant(ctx context.Context, tenantID, role string) ([]models.User, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE tenant_id = $1 AND ($2 = '' OR role = $2)
+		ORDER BY created_at`, tenantID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanUser)
+}
+
+func (r *pgUserRepository) CountActiveByRole(ctx context.Context, tenantID string) (map[string]int, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT role, COUNT(*)
+		FROM users
+		WHERE tenant_id = $1 AND is_active
+		GROUP BY role`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var role string
+		var count int
+		if err := rows.Scan(&role, &count); err != nil {
+			return nil, err
+		}
+		counts[role] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *pgUserRepository) ListByManager(ctx context.Context, tenantID, managerID string) ([]models.User, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE tenant_id = $1 AND manager_id = $2
+		ORDER BY created_at`, tenantID, managerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanUser)
+}
+
+func (r *pgUserRepository) AssignDealers(ctx context.Context, tenantID, managerID string, dealerIDs []string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE users
+		SET manager_id = CASE WHEN id = ANY($3::uuid[]) THEN $2::uuid END
+		WHERE tenant_id = $1 AND (manager_id = $2 OR id = ANY($3::uuid[]))`,
+		tenantID, managerID, dealerIDs)
+	return mapError(err)
+}
+
+func (r *pgUserRepository) BumpTokenVersion(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE users SET token_version = token_version + 1 WHERE id = $1`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgUserRepository) BumpTokenVersions(ctx context.Context, tenantID string) error {
+	_, err := r.db.Exec(ctx, `UPDATE users SET token_version = token_version + 1 WHERE tenant_id = $1`, tenantID)
+	return mapError(err)
+}
+
+func (r *pgUserRepository) getOne(ctx context.Context, query string, args ...any) (*models.User, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := pgx.CollectExactlyOneRow(rows, scanUser)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &user, nil
+}
+
+func scanUser(row pgx.CollectableRow) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Email, &u.Password, &u.Role, &u.TenantID, &u.ManagerID, &u.FirstName, &u.LastName,
+		&u.Phone, &u.Avatar, &u.IsActive, &u.EmailVerified, &u.CreatedAt, &u.UpdatedAt,
+		&u.TwoFactorEnabled, &u.TOTPSecret, &u.TOTPLastCounter, &u.TokenVersion, &u.City)
+	return u, err
+}
@@ -0,0 +1,291 @@
+// Package repository defines typed data access for the services together with
+// PostgreSQL and in-memory implementations.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write violates a uniqueness constraint
+	ErrDuplicate = errors.New("record already exists")
+)
+
+// Store groups all repositories and allows running them in one transaction
+type Store interface {
+	Users() UserRepository
+	Tenants() TenantRepository
+	Sessions() SessionRepository
+	UserTokens() UserTokenRepository
+	RecoveryCodes() RecoveryCodeRepository
+	LoginAttempts() LoginAttemptRepository
+	Invitations() InvitationRepository
+	Checklists() ChecklistRepository
+	Tasks() TaskRepository
+	Audit() AuditRepository
+	Roles() RoleRepository
+	APIKeys() APIKeyRepository
+	Plans() PlanRepository
+	Domains() DomainRepository
+	Templates() ChecklistTemplateRepository
+	Holidays() HolidayRepository
+	ChecklistRuns() ChecklistRunRepository
+
+	// WithTx runs fn with a store bound to a single transaction. The
+	// transaction is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// UserRepository provides access to users
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	// GetInTenant is GetByID that returns ErrNotFound for users of other tenants
+	GetInTenant(ctx context.Context, tenantID, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Update saves profile fields, role and status flags; the password is left
+	// untouched. Changing the role or the active flag bumps the token version.
+	Update(ctx context.Context, user *models.User) error
+	// UpdatePassword sets the password hash and bumps the token version
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+	GetTokenVersion(ctx context.Context, id string) (int, error)
+	// UpdateTwoFactor saves the TOTP secret, the enabled flag and the last used time step
+	UpdateTwoFactor(ctx context.Context, user *models.User) error
+	// AdvanceTOTPCounter records a used time step; it returns ErrNotFound if
+	// the step is not newer than the last one, i.e. the code was already used
+	AdvanceTOTPCounter(ctx context.Context, id string, counter int64) error
+	ListByTenant(ctx context.Context, tenantID, role string) ([]models.User, error)
+	// CountActiveByRole returns the number of active users of the tenant per role
+	CountActiveByRole(ctx context.Context, tenantID string) (map[string]int, error)
+	// ListByManager returns the users of the tenant who report to the manager
+	ListByManager(ctx context.Context, tenantID, managerID string) ([]models.User, error)
+	// AssignDealers makes the manager the manager of exactly the given users
+	// of the tenant; users no longer listed are left without a manager
+	AssignDealers(ctx context.Context, tenantID, managerID string, dealerIDs []string) error
+	// BumpTokenVersion invalidates the access tokens of the user
+	BumpTokenVersion(ctx context.Context, id string) error
+	// BumpTokenVersions invalidates the access tokens of every user of the tenant
+	BumpTokenVersions(ctx context.Context, tenantID string) error
+}
+
+// TenantRepository provides access to franchise networks
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *models.Tenant) error
+	GetByID(ctx context.Context, id string) (*models.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	// GetForUpdate loads the tenant and locks it until the transaction ends
+	GetForUpdate(ctx context.Context, id string) (*models.Tenant, error)
+	List(ctx context.Context, filter models.TenantFilter) ([]models.Tenant, error)
+	Count(ctx context.Context, filter models.TenantFilter) (int, error)
+	// Update saves name, city, plan and settings
+	Update(ctx context.Context, tenant *models.Tenant) error
+	// SetSuspended suspends the tenant now or lifts the suspension
+	SetSuspended(ctx context.Context, id string, suspended bool) error
+	// GetStorageUsed returns the bytes taken by the tenant's files
+	GetStorageUsed(ctx context.Context, id string) (int64, error)
+	// Delete removes the tenant together with all of its data
+	Delete(ctx context.Context, id string) error
+}
+
+// SessionRepository provides access to refresh token sessions
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	// GetByRefreshToken looks a session up by the hash of its refresh token
+	// and locks it until the transaction ends
+	GetByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error)
+	// ListActiveByUser returns the current, unexpired session of every login of the user
+	ListActiveByUser(ctx context.Context, userID string) ([]models.Session, error)
+	// MarkRotated records that the session's refresh token has been exchanged
+	MarkRotated(ctx context.Context, id string) error
+	// IsFamilyActive reports whether one login of the user has a session that is not revoked
+	IsFamilyActive(ctx context.Context, userID, familyID string) (bool, error)
+	// RevokeFamily revokes every session of one login of the user
+	RevokeFamily(ctx context.Context, userID, familyID, reason string) error
+	// RevokeAllForUser revokes every session of the user
+	RevokeAllForUser(ctx context.Context, userID, reason string) error
+	// RevokeAllForTenant revokes every session of every user of the tenant
+	RevokeAllForTenant(ctx context.Context, tenantID, reason string) error
+	// RevokeAllExcept revokes every session of the user except those of the given login
+	RevokeAllExcept(ctx context.Context, userID, familyID, reason string) error
+}
+
+// UserTokenRepository provides access to single-use email tokens
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	// GetByHash returns the token with the given hash and locks it inside a transaction
+	GetByHash(ctx context.Context, tokenHash string) (*models.UserToken, error)
+	MarkUsed(ctx context.Context, id string) error
+	// IncrementAttempts records a failed attempt and returns the new count
+	IncrementAttempts(ctx context.Context, id string) (int, error)
+	// InvalidateForUser marks every unused token of the user with the given purpose as used
+	InvalidateForUser(ctx context.Context, userID, purpose string) error
+}
+
+// RecoveryCodeRepository provides access to two-factor recovery codes
+type RecoveryCodeRepository interface {
+	// ReplaceForUser deletes the existing codes of the user and stores the new hashes
+	ReplaceForUser(ctx context.Context, userID string, codeHashes []string) error
+	// Use marks an unused code as used; it returns ErrNotFound if there is none
+	Use(ctx context.Context, userID, codeHash string) error
+	CountUnused(ctx context.Context, userID string) (int, error)
+	DeleteForUser(ctx context.Context, userID string) error
+}
+
+// LoginAttemptRepository records sign-in attempts
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *models.LoginAttempt) error
+}
+
+// InvitationRepository manages invitations into a tenant
+type InvitationRepository interface {
+	// Create returns ErrDuplicate if the tenant already has an open invitation for the email
+	Create(ctx context.Context, invitation *models.Invitation) error
+	GetByID(ctx context.Context, tenantID, id string) (*models.Invitation, error)
+	// GetByTokenHash locks the invitation for the rest of the transaction
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	// GetOpenByEmail returns the invitation for the email that was neither accepted nor revoked
+	GetOpenByEmail(ctx context.Context, tenantID, email string) (*models.Invitation, error)
+	// ListOpen returns the invitations that were neither accepted nor revoked, newest first
+	ListOpen(ctx context.Context, tenantID string) ([]models.Invitation, error)
+	// UpdateToken replaces the link token and expiry of an open invitation
+	UpdateToken(ctx context.Context, invitation *models.Invitation) error
+	MarkAccepted(ctx context.Context, id, userID string) error
+	// Revoke returns ErrNotFound if the invitation is not open
+	Revoke(ctx context.Context, tenantID, id string) error
+}
+
+// ChecklistRepository provides access to checklists without their tasks. Every
+// lookup is limited to one tenant; checklists of other tenants are not found.
+type ChecklistRepository interface {
+	ListByUser(ctx context.Context, tenantID, userID string, limit, offset int) ([]models.Checklist, error)
+	GetByID(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error)
+	// GetInTenant is GetByID for any user of the tenant, for reviewers
+	GetInTenant(ctx context.Context, tenantID, id string) (*models.Checklist, error)
+	// GetForUpdate is GetByID that also locks the row until the transaction ends
+	GetForUpdate(ctx context.Context, tenantID, id, userID string) (*models.Checklist, error)
+	Create(ctx context.Context, checklist *models.Checklist) error
+	// Update matches the checklist by ID, user and tenant
+	Update(ctx context.Context, checklist *models.Checklist) error
+	Delete(ctx context.Context, tenantID, id, userID string) error
+	// ListUserIDsByDate returns the users of the tenant that have a checklist for the date
+	ListUserIDsByDate(ctx context.Context, tenantID string, date time.Time) ([]string, error)
+}
+
+// TaskRepository provides access to the tasks of checklists
+type TaskRepository interface {
+	ListByChecklist(ctx context.Context, checklistID string) ([]models.Task, error)
+	ListByChecklists(ctx context.Context, checklistIDs []string) (map[string][]models.Task, error)
+	Create(ctx context.Context, checklistID string, task *models.Task) error
+	// Update saves the fields the owner controls; the review is left untouched
+	Update(ctx context.Context, checklistID string, task *models.Task) error
+	// Review saves the verification and the review comment of the task
+	Review(ctx context.Context, checklistID string, task *models.Task) error
+	// DeleteExcept removes every task of the checklist whose ID is not in keepIDs
+	DeleteExcept(ctx context.Context, checklistID string, keepIDs []string) error
+	// CompleteAll marks every unfinished task of the checklist as completed
+	CompleteAll(ctx context.Context, checklistID string) error
+}
+
+// RoleRepository provides access to the roles of tenants
+type RoleRepository interface {
+	// Create returns ErrDuplicate if the tenant already has a role with the name
+	Create(ctx context.Context, role *models.Role) error
+	GetByID(ctx context.Context, tenantID, id string) (*models.Role, error)
+	GetByName(ctx context.Context, tenantID, name string) (*models.Role, error)
+	// ListByTenant returns the built-in roles first
+	ListByTenant(ctx context.Context, tenantID string) ([]models.Role, error)
+	// Update saves the description and permissions
+	Update(ctx context.Context, role *models.Role) error
+	// Delete removes a custom role; built-in roles are reported as ErrNotFound
+	Delete(ctx context.Context, tenantID, id string) error
+	// IsInUse reports whether a user or an open invitation of the tenant has the role
+	IsInUse(ctx context.Context, tenantID, name string) (bool, error)
+}
+
+// PlanRepository stores the modules superadmins switched on or off for a plan
+type PlanRepository interface {
+	// GetFeatures returns ErrNotFound if the modules of the plan were never changed
+	GetFeatures(ctx context.Context, plan string) (*models.FeatureSettings, error)
+	SetFeatures(ctx context.Context, plan string, features models.FeatureSettings) error
+}
+
+// APIKeyRepository provides access to the API keys of tenants
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// GetByHash looks a key up by the hash of its secret, in any tenant
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// ListByTenant returns every key of the tenant, newest first
+	ListByTenant(ctx context.Context, tenantID string) ([]models.APIKey, error)
+	// Revoke returns ErrNotFound if the key does not exist or is already revoked
+	Revoke(ctx context.Context, tenantID, id string) error
+	// TouchLastUsed records that the key was used; it writes at most once a minute
+	TouchLastUsed(ctx context.Context, id string) error
+}
+
+// DomainRepository provides access to the custom domains of tenants
+type DomainRepository interface {
+	// Create returns ErrDuplicate if the tenant already added the domain
+	Create(ctx context.Context, domain *models.TenantDomain) error
+	GetInTenant(ctx context.Context, tenantID, id string) (*models.TenantDomain, error)
+	// GetVerified looks up the tenant that verified the domain, in any tenant
+	GetVerified(ctx context.Context, name string) (*models.TenantDomain, error)
+	// ListByTenant returns the domains of the tenant ordered by name
+	ListByTenant(ctx context.Context, tenantID string) ([]models.TenantDomain, error)
+	// MarkVerified returns ErrDuplicate if another tenant verified the domain first
+	MarkVerified(ctx context.Context, tenantID, id string, at time.Time) error
+	Delete(ctx context.Context, tenantID, id string) error
+}
+
+// ChecklistTemplateRepository provides access to checklist templates and
+// their versions
+type ChecklistTemplateRepository interface {
+	// Create saves the template together with its content as the first version
+	Create(ctx context.Context, template *models.ChecklistTemplate) error
+	GetInTenant(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error)
+	// GetForUpdate is GetInTenant that locks the template until the transaction ends
+	GetForUpdate(ctx context.Context, tenantID, id string) (*models.ChecklistTemplate, error)
+	// ListByTenant returns the templates of the tenant ordered by name
+	ListByTenant(ctx context.Context, tenantID string) ([]models.ChecklistTemplate, error)
+	// Update saves the version number, the assignment and the recurrence
+	Update(ctx context.Context, template *models.ChecklistTemplate) error
+	// AddVersion saves the content of the template as its current version
+	AddVersion(ctx context.Context, template *models.ChecklistTemplate, userID string) error
+	// ListVersions returns the versions of the template, newest first
+	ListVersions(ctx context.Context, tenantID, id string) ([]models.ChecklistTemplateVersion, error)
+	// Delete removes the template; checklists made from it are kept
+	Delete(ctx context.Context, tenantID, id string) error
+}
+
+// HolidayRepository provides access to the holiday calendars of tenants
+type HolidayRepository interface {
+	// Create returns ErrDuplicate if the date already is a holiday
+	Create(ctx context.Context, holiday *models.Holiday) error
+	// ListByTenant returns the holidays between from and to inclusive, by date
+	ListByTenant(ctx context.Context, tenantID string, from, to time.Time) ([]models.Holiday, error)
+	IsHoliday(ctx context.Context, tenantID string, date time.Time) (bool, error)
+	Delete(ctx context.Context, tenantID string, date time.Time) error
+}
+
+// ChecklistRunRepository records the days the scheduler made the checklists
+// of a tenant for
+type ChecklistRunRepository interface {
+	// Claim records the run for the day and reports whether it was not yet
+	// recorded. A concurrent claim of the same day waits for the transaction
+	// of the first one.
+	Claim(ctx context.Context, tenantID string, date time.Time) (bool, error)
+	// Finish saves the number of checklists the run created
+	Finish(ctx context.Context, tenantID string, date time.Time, created int) error
+}
+
+// AuditRepository records actions taken as another user or in another tenant
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	// List returns the matching entries, newest first
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
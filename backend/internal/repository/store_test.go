@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"franchise-saas-backend/internal/database"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/migrations"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDatabaseURL names the variable with the DSN of a PostgreSQL database
+// the contract tests may migrate and write to; without it they run against
+// the in-memory store only
+const testDatabaseURL = "FRANCHISE_TEST_DATABASE_URL"
+
+// forEachStore runs the test against every store implementation
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		test(t, NewPostgresStore(testPool(t)))
+	})
+}
+
+// testPool connects to the test database and applies the migrations; the
+// test is skipped when no database is configured
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseURL)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURL)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := database.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return pool
+}
+
+// createTenant adds a tenant that is removed with all of its data when the test ends
+func createTenant(t *testing.T, store Store) *models.Tenant {
+	t.Helper()
+
+	id := uuid.NewString()
+	tenant := &models.Tenant{ID: id, Name: "Network " + id[:8], Slug: "test-" + id[:8], Plan: models.PlanStart}
+	if err := store.Tenants().Create(context.Background(), tenant); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.Tenants().Delete(context.Background(), id); err != nil {
+			t.Errorf("delete tenant: %v", err)
+		}
+	})
+
+	return tenant
+}
+
+// createUser adds an active user to the tenant
+func createUser(t *testing.T, store Store, tenantID, role string) *models.User {
+	t.Helper()
+
+	id := uuid.NewString()
+	user := &models.User{
+		ID:       id,
+		Email:    id[:8] + "@example.com",
+		Password: "hash",
+		Role:     role,
+		TenantID: tenantID,
+		IsActive: true,
+	}
+	if err := store.Users().Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return user
+}
+
+func TestUsers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+		other := createTenant(t, store)
+		user := createUser(t, store, tenant.ID, models.RoleDealer)
+
+		duplicate := *user
+		duplicate.ID = uuid.NewString()
+		if err := store.Users().Create(ctx, &duplicate); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("create with a taken email: got %v, want ErrDuplicate", err)
+		}
+
+		if _, err := store.Users().GetByID(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get unknown user: got %v, want ErrNotFound", err)
+		}
+		if _, err := store.Users().GetInTenant(ctx, other.ID, user.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get user in another tenant: got %v, want ErrNotFound", err)
+		}
+
+		found, err := store.Users().GetByEmail(ctx, strings.ToUpper(user.Email))
+		if err != nil || found.ID != user.ID {
+			t.Fatalf("get by email: got %v, %v", found, err)
+		}
+
+		version := found.TokenVersion
+		found.FirstName = "Anna"
+		if err := store.Users().Update(ctx, found); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if found.TokenVersion != version {
+			t.Errorf("profile change bumped the token version to %d", found.TokenVersion)
+		}
+
+		found.Role = models.RoleManager
+		if err := store.Users().Update(ctx, found); err != nil {
+			t.Fatalf("update role: %v", err)
+		}
+		current, err := store.Users().GetTokenVersion(ctx, user.ID)
+		if err != nil || current != version+1 {
+			t.Errorf("token version after role change: got %d, %v, want %d", current, err, version+1)
+		}
+
+		found.IsActive = false
+		if err := store.Users().Update(ctx, found); err != nil {
+			t.Fatalf("deactivate: %v", err)
+		}
+		if found.TokenVersion != version+2 {
+			t.Errorf("token version after deactivation: got %d, want %d", found.TokenVersion, version+2)
+		}
+
+		if err := store.Users().BumpTokenVersion(ctx, user.ID); err != nil {
+			t.Fatalf("bump token version: %v", err)
+		}
+		if current, err := store.Users().GetTokenVersion(ctx, user.ID); err != nil || current != version+3 {
+			t.Errorf("token version after bump: got %d, %v, want %d", current, err, version+3)
+		}
+		if err := store.Users().BumpTokenVersion(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("bump token version of unknown user: got %v, want ErrNotFound", err)
+		}
+
+		if err := store.Users().UpdatePassword(ctx, uuid.NewString(), "hash"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("update password of unknown user: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestWithTx(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+
+		failure := errors.New("rollback")
+		var rolledBack *models.User
+		err := store.WithTx(ctx, func(tx Store) error {
+			rolledBack = createUser(t, tx, tenant.ID, models.RoleDealer)
+			if _, err := tx.Users().GetByID(ctx, rolledBack.ID); err != nil {
+				t.Errorf("the transaction does not see its own write: %v", err)
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("WithTx returned %v, want the error of fn", err)
+		}
+		if _, err := store.Users().GetByID(ctx, rolledBack.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("user created in a rolled back transaction: got %v, want ErrNotFound", err)
+		}
+
+		var committed *models.User
+		err = store.WithTx(ctx, func(tx Store) error {
+			return tx.WithTx(ctx, func(nested Store) error {
+				committed = createUser(t, nested, tenant.ID, models.RoleDealer)
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+		if _, err := store.Users().GetByID(ctx, committed.ID); err != nil {
+			t.Errorf("user created in a committed transaction: %v", err)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+		user := createUser(t, store, tenant.ID, models.RoleDealer)
+
+		now := time.Now()
+		newSession := func(family string) *models.Session {
+			session := &models.Session{
+				ID:              uuid.NewString(),
+				UserID:          user.ID,
+				RefreshToken:    uuid.NewString(),
+				ExpiresAt:       now.Add(time.Hour),
+				AuthenticatedAt: now,
+			}
+			session.FamilyID = session.ID
+			if family != "" {
+				session.FamilyID = family
+			}
+			if err := store.Sessions().Create(ctx, session); err != nil {
+				t.Fatalf("create session: %v", err)
+			}
+			return session
+		}
+
+		first := newSession("")
+		second := newSession("")
+		if err := store.Sessions().MarkRotated(ctx, first.ID); err != nil {
+			t.Fatalf("mark rotated: %v", err)
+		}
+		rotated := newSession(first.FamilyID)
+
+		found, err := store.Sessions().GetByRefreshToken(ctx, first.RefreshToken)
+		if err != nil || found.RotatedAt == nil {
+			t.Fatalf("get rotated session: got %+v, %v", found, err)
+		}
+		if _, err := store.Sessions().GetByRefreshToken(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get unknown refresh token: got %v, want ErrNotFound", err)
+		}
+
+		active, err := store.Sessions().ListActiveByUser(ctx, user.ID)
+		if err != nil || len(active) != 2 {
+			t.Fatalf("active sessions: got %d, %v, want 2", len(active), err)
+		}
+
+		if active, err := store.Sessions().IsFamilyActive(ctx, user.ID, first.FamilyID); err != nil || !active {
+			t.Errorf("rotated login active: got %v, %v, want true", active, err)
+		}
+		if err := store.Sessions().RevokeFamily(ctx, user.ID, rotated.FamilyID, models.SessionRevokedLogout); err != nil {
+			t.Fatalf("revoke family: %v", err)
+		}
+		if active, err := store.Sessions().IsFamilyActive(ctx, user.ID, first.FamilyID); err != nil || active {
+			t.Errorf("revoked login active: got %v, %v, want false", active, err)
+		}
+		active, err = store.Sessions().ListActiveByUser(ctx, user.ID)
+		if err != nil || len(active) != 1 || active[0].ID != second.ID {
+			t.Errorf("active sessions after revoking a login: got %+v, %v", active, err)
+		}
+	})
+}
+
+func TestChecklistsAreScopedToTenant(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+		other := createTenant(t, store)
+		user := createUser(t, store, tenant.ID, models.RoleDealer)
+
+		date := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+		checklist := &models.Checklist{
+			ID:       uuid.NewString(),
+			Title:    "Opening",
+			UserID:   user.ID,
+			TenantID: tenant.ID,
+			Date:     date,
+			Status:   "pending",
+		}
+		if err := store.Checklists().Create(ctx, checklist); err != nil {
+			t.Fatalf("create checklist: %v", err)
+		}
+
+		if _, err := store.Checklists().GetByID(ctx, tenant.ID, checklist.ID, user.ID); err != nil {
+			t.Errorf("get checklist: %v", err)
+		}
+		if _, err := store.Checklists().GetInTenant(ctx, other.ID, checklist.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("get checklist in another tenant: got %v, want ErrNotFound", err)
+		}
+		if err := store.Checklists().Delete(ctx, other.ID, checklist.ID, user.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("delete checklist in another tenant: got %v, want ErrNotFound", err)
+		}
+
+		users, err := store.Checklists().ListUserIDsByDate(ctx, tenant.ID, date)
+		if err != nil || len(users) != 1 || users[0] != user.ID {
+			t.Errorf("users with a checklist on the date: got %v, %v", users, err)
+		}
+	})
+}
+
+// TestRowLevelSecurity checks the second line of defence: once app.tenant_id
+// is set, a query without any tenant condition only sees that tenant's rows
+func TestRowLevelSecurity(t *testing.T) {
+	pool := testPool(t)
+	store := NewPostgresStore(pool)
+	ctx := context.Background()
+	tenant := createTenant(t, store)
+	other := createTenant(t, store)
+	user := createUser(t, store, tenant.ID, models.RoleDealer)
+	stranger := createUser(t, store, other.ID, models.RoleDealer)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Superusers and roles with BYPASSRLS ignore the policies, so the query
+	// runs as a plain role that disappears with the transaction
+	var bypass bool
+	if err := tx.QueryRow(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass); err != nil {
+		t.Fatalf("check role: %v", err)
+	}
+	if bypass {
+		role := "rls_test_" + uuid.NewString()[:8]
+		for _, statement := range []string{
+			`CREATE ROLE ` + role + ` NOLOGIN`,
+			`GRANT SELECT ON users TO ` + role,
+			`SET LOCAL ROLE ` + role,
+		} {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				t.Fatalf("%s: %v", statement, err)
+			}
+		}
+	}
+
+	countUsers := func() int {
+		t.Helper()
+		var count int
+		err := tx.QueryRow(ctx, `SELECT count(*) FROM users WHERE id = ANY($1::uuid[])`, []string{user.ID, stranger.ID}).Scan(&count)
+		if err != nil {
+			t.Fatalf("count users: %v", err)
+		}
+		return count
+	}
+
+	if count := countUsers(); count != 2 {
+		t.Fatalf("users visible without a tenant: got %d, want 2", count)
+	}
+	if _, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenant.ID); err != nil {
+		t.Fatalf("set tenant: %v", err)
+	}
+	if count := countUsers(); count != 1 {
+		t.Fatalf("users visible in the tenant: got %d, want 1", count)
+	}
+}
+
+func TestChecklistRuns(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+		date := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+		claimed, err := store.ChecklistRuns().Claim(ctx, tenant.ID, date)
+		if err != nil || !claimed {
+			t.Fatalf("first claim: got %v, %v, want true", claimed, err)
+		}
+		claimed, err = store.ChecklistRuns().Claim(ctx, tenant.ID, date)
+		if err != nil || claimed {
+			t.Errorf("second claim: got %v, %v, want false", claimed, err)
+		}
+		if err := store.ChecklistRuns().Finish(ctx, tenant.ID, date, 3); err != nil {
+			t.Errorf("finish: %v", err)
+		}
+	})
+}
+
+func TestHolidays(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		tenant := createTenant(t, store)
+		date := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		if err := store.Holidays().Create(ctx, &models.Holiday{TenantID: tenant.ID, Date: date, Name: "New Year"}); err != nil {
+			t.Fatalf("create holiday: %v", err)
+		}
+		if err := store.Holidays().Create(ctx, &models.Holiday{TenantID: tenant.ID, Date: date}); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("create holiday twice: got %v, want ErrDuplicate", err)
+		}
+
+		holiday, err := store.Holidays().IsHoliday(ctx, tenant.ID, date)
+		if err != nil || !holiday {
+			t.Errorf("is holiday: got %v, %v, want true", holiday, err)
+		}
+
+		if err := store.Holidays().Delete(ctx, tenant.ID, date); err != nil {
+			t.Fatalf("delete holiday: %v", err)
+		}
+		if err := store.Holidays().Delete(ctx, tenant.ID, date); !errors.Is(err, ErrNotFound) {
+			t.Errorf("delete holiday twice: got %v, want ErrNotFound", err)
+		}
+	})
+}
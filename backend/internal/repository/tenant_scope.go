@@ -0,0 +1,25 @@
+package repository
+
+import "context"
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose PostgreSQL statements only see rows of
+// the given tenant. It is the second line of defence behind the explicit
+// tenant conditions of the repositories: the row-level security policies
+// read the tenant from the app.tenant_id setting of the transaction.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// WithoutTenant lifts the restriction of WithTenant for the few lookups that
+// must see every tenant, such as checking that an email is not yet taken
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, "")
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/mailer"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidUserToken is returned for unknown, used or expired email tokens
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified is returned when asking to verify an already verified email
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+// ForgotPassword emails a password reset link if an active user has this
+// email. It reports success either way so that emails cannot be enumerated.
+// Requests are throttled per email and per IP address with a ThrottledError.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string, client ClientInfo) error {
+	email = normalizeEmail(email)
+	if err := s.limiter.requestPasswordReset(ctx, email, client.IPAddress); err != nil {
+		return err
+	}
+
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	ttl := passwordResetTTL()
+	token, err := s.issueUserToken(ctx, user.ID, models.TokenPurposePasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+
+	sendMail(ctx, s.mailer, mailer.TemplatePasswordReset, client.Language, user.Email, map[string]any{
+		"Name":    user.FirstName,
+		"Link":    appLink("/reset-password", token),
+		"Minutes": int(ttl.Minutes()),
+	})
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out of every device
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return s.store.WithTx(ctx, func(tx repository.Store) error {
+		user, err := consumeUserToken(ctx, tx, token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		// Checked after the token so that the policy can compare with the email;
+		// returning an error rolls back the token use
+		if err := s.policy.Validate(newPassword, user.Email); err != nil {
+			return fmt.Errorf("%w: %v", ErrWeakPassword, err)
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		if err := tx.Users().UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+			return err
+		}
+
+		// Following the emailed link proves the address belongs to the user
+		if !user.EmailVerified {
+			user.EmailVerified = true
+			if err := tx.Users().Update(ctx, user); err != nil {
+				return err
+			}
+		}
+
+		return tx.Sessions().RevokeAllForUser(ctx, user.ID, models.SessionRevokedPasswordReset)
+	})
+}
+
+// VerifyEmail marks the email of the token owner as verified
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	return s.store.WithTx(ctx, func(tx repository.Store) error {
+		user, err := consumeUserToken(ctx, tx, token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if user.EmailVerified {
+			return nil
+		}
+
+		user.EmailVerified = true
+		return tx.Users().Update(ctx, user)
+	})
+}
+
+// ResendEmailVerification sends a new verification link to the user;
+// previously sent links stop working
+func (s *AuthService) ResendEmailVerification(ctx context.Context, userID, lang string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	return s.sendEmailVerification(ctx, user, lang)
+}
+
+// sendEmailVerification issues a verification token and emails the link
+func (s *AuthService) sendEmailVerification(ctx context.Context, user *models.User, lang string) error {
+	ttl := emailVerificationTTL()
+	token, err := s.issueUserToken(ctx, user.ID, models.TokenPurposeEmailVerification, ttl)
+	if err != nil {
+		return err
+	}
+
+	sendMail(ctx, s.mailer, mailer.TemplateEmailVerification, lang, user.Email, map[string]any{
+		"Name":  user.FirstName,
+		"Link":  appLink("/verify-email", token),
+		"Hours": int(ttl.Hours()),
+	})
+
+	return nil
+}
+
+// issueUserToken stores a new single-use token and returns its plain value.
+// Older unused tokens of the same purpose are invalidated.
+func (s *AuthService) issueUserToken(ctx context.Context, userID, purpose string, ttl time.Duration) (string, error) {
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.UserTokens().InvalidateForUser(ctx, userID, purpose); err != nil {
+			return err
+		}
+
+		return tx.UserTokens().Create(ctx, &models.UserToken{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s token: %w", purpose, err)
+	}
+
+	return token, nil
+}
+
+// sendMail renders and sends a message. Delivery failures are logged rather
+// than returned: the caller's operation has already succeeded and the user
+// can ask for the email again.
+func sendMail(ctx context.Context, m mailer.Mailer, template, lang, to string, data map[string]any) {
+	msg, err := mailer.Render(template, lang, to, data)
+	if err != nil {
+		log.Printf("failed to render %s email: %v", template, err)
+		return
+	}
+
+	if err := m.Send(ctx, msg); err != nil {
+		log.Printf("failed to send %s email to %s: %v", template, to, err)
+	}
+}
+
+// consumeUserToken checks a token of the given purpose, marks it used and
+// returns its owner
+func consumeUserToken(ctx context.Context, tx repository.Store, token, purpose string) (*models.User, error) {
+	userToken, err := tx.UserTokens().GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if userToken.Purpose != purpose || userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	user, err := tx.Users().GetByID(ctx, userToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrInvalidUserToken
+	}
+
+	if err := tx.UserTokens().MarkUsed(ctx, userToken.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// appLink builds a frontend link carrying a token
+func appLink(path, token string) string {
+	base := strings.TrimRight(viper.GetString("app_url"), "/")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// passwordResetTTL returns how long a password reset link stays valid
+func passwordResetTTL() time.Duration {
+	minutes := viper.GetInt("password_reset_expiration_minutes")
+	if minutes <= 0 {
+		minutes = 60
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// emailVerificationTTL returns how long an email verification link stays valid
+func emailVerificationTTL() time.Duration {
+	hours := viper.GetInt("email_verification_expiration_hours")
+	if hours <= 0 {
+		hours = 48
+	}
+	return time.Duration(hours) * time.Hour
+}
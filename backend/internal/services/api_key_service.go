@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned when the key does not exist in the caller's tenant or is already revoked
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrInvalidAPIKey is returned for scopes a key cannot have and expiry dates in the past
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrScopeNotHeld is returned when a key would be granted a permission its creator does not hold
+	ErrScopeNotHeld = errors.New("an API key cannot have permissions its creator does not hold")
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear to tell keys apart
+const apiKeyPrefixLength = 12
+
+// APIKeyService manages the API keys of a tenant and authenticates requests made with them
+type APIKeyService struct {
+	store repository.Store
+	roles *RoleService
+}
+
+func NewAPIKeyService(store repository.Store, roles *RoleService) *APIKeyService {
+	return &APIKeyService{store: store, roles: roles}
+}
+
+// CreateAPIKey issues a key. The key can only be granted permissions the role
+// of its creator holds. The response is the only place the key appears; it
+// cannot be recovered later.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, tenantID, createdBy, creatorRole string, req models.APIKeyCreateRequest) (*models.APIKeyCreateResponse, error) {
+	held, err := s.roles.ResolvePermissions(ctx, tenantID, creatorRole)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !models.IsAPIKeyScope(scope) {
+			return nil, fmt.Errorf("%w: scope %q cannot be granted to an API key; allowed scopes are %s",
+				ErrInvalidAPIKey, scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+		if !slices.Contains(held, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrScopeNotHeld, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	secret, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	key := models.APIKeyPrefix + secret
+
+	apiKey := models.APIKey{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:apiKeyPrefixLength],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.store.APIKeys().Create(ctx, &apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &models.APIKeyCreateResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys returns the keys of the tenant, including revoked and expired ones
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, tenantID string) ([]models.APIKey, error) {
+	keys, err := s.store.APIKeys().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from working; requests made with it are rejected at once
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, tenantID, keyID string) error {
+	if _, err := uuid.Parse(keyID); err != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	if err := s.store.APIKeys().Revoke(ctx, tenantID, keyID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the key a request was made with. It returns nil
+// when the key is unknown, revoked or expired, or its tenant is suspended.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, nil
+	}
+
+	apiKey, err := s.store.APIKeys().GetByHash(ctx, hashToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if !apiKey.IsActive(time.Now()) {
+		return nil, nil
+	}
+
+	if _, err := activeTenant(ctx, s.store, apiKey.TenantID); err != nil {
+		if errors.Is(err, ErrTenantSuspended) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := s.store.APIKeys().TouchLastUsed(ctx, apiKey.ID); err != nil {
+		return nil, fmt.Errorf("failed to record API key use: %w", err)
+	}
+
+	return apiKey, nil
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// AuditService records and lists actions a superadmin takes as another user
+// or inside another tenant
+type AuditService struct {
+	store repository.Store
+}
+
+func NewAuditService(store repository.Store) *AuditService {
+	return &AuditService{store: store}
+}
+
+// RecordAction stores an audit entry
+func (s *AuditService) RecordAction(ctx context.Context, entry models.AuditEntry) error {
+	entry.ID = uuid.New().String()
+
+	if err := s.store.Audit().Create(ctx, &entry); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditLog returns a page of the audit log, newest first
+func (s *AuditService) ListAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries, err := s.store.Audit().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	return entries, nil
+}
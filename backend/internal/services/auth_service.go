@@ -1,147 +1,469 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"franchise-saas-backend/internal/mailer"
 	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/password"
+	"franchise-saas-backend/internal/repository"
+	"franchise-saas-backend/internal/tokens"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrEmailTaken is returned when registering with an email that is already used
+	ErrEmailTaken = errors.New("a user with this email already exists")
+	// ErrInvalidCredentials is returned when the email or password is wrong
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrUserInactive is returned when a deactivated user tries to sign in
+	ErrUserInactive = errors.New("user account is deactivated")
+	// ErrWeakPassword is returned when the password does not meet the password policy
+	ErrWeakPassword = errors.New("password does not meet the requirements")
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, all sessions of this login were revoked")
+)
+
+// ClientInfo describes the device a request comes from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	// Language is the preferred language for emails, "ru" or "en"
+	Language string
+	// HostTenantID is the tenant whose custom domain the request was made to;
+	// empty on the platform's own domain
+	HostTenantID string
+}
+
+// dummyPasswordHash is compared against when the user does not exist so that
+// unknown emails take as long to reject as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
 type AuthService struct {
-	db interface{}
-}
-
-func NewAuthService(db interface{}) *AuthService {
-	return &AuthService{db: db}
-}
-
-// CreateUser creates a new user in the database
-func (s *AuthService) CreateUser(user *models.User) (*models.User, error) {
-	// In a real implementation, you would interact with the database here
-	// For now, we'll simulate the operation
-	
-	// Simulate saving to database
-	simulatedUser := *user
-	simulatedUser.CreatedAt = time.Now()
-	simulatedUser.UpdatedAt = time.Now()
-	
-	return &simulatedUser, nil
-}
-
-// GetUserByEmail retrieves a user by their email address
-func (s *AuthService) GetUserByEmail(email string) (*models.User, error) {
-	// In a real implementation, you would query the database
-	// For now, we'll simulate a lookup
-	
-	// Simulate checking if user exists
-	if email == "existing@example.com" {
-		return &models.User{
-			ID:        uuid.New().String(),
-			Email:     email,
-			Password:  "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjdQcVrRzwwIWKXNw2vE.9YJdLQj3u", // bcrypt hash for "password123"
-			Role:      "dealer",
-			TenantID:  "tenant-1",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}, nil
-	}
-	
-	return nil, nil
+	store   repository.Store
+	mailer  mailer.Mailer
+	policy  *password.Policy
+	limiter *LoginLimiter
+	issuer  *tokens.Issuer
+
+	sessions *ttlCache[bool] // whether each login is still active; revocations through this process clear it
 }
 
-// GenerateTokens creates JWT tokens for a user
-func (s *AuthService) GenerateTokens(userID, email, role, tenantID string) (string, string, error) {
-	// Create access token
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  userID,
-		"email":    email,
-		"role":     role,
-		"tenant_id": tenantID,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24 hours
-		"iat":      time.Now().Unix(),
-	})
+func NewAuthService(store repository.Store, mailer mailer.Mailer, policy *password.Policy, limiter *LoginLimiter, issuer *tokens.Issuer) *AuthService {
+	return &AuthService{
+		store:    store,
+		mailer:   mailer,
+		policy:   policy,
+		limiter:  limiter,
+		issuer:   issuer,
+		sessions: newTTLCache[bool](cacheTTL("session_cache_seconds"), cacheSize),
+	}
+}
+
+// Register signs up a franchiser: the new franchise network and its first
+// user are created together, and the user is signed in
+func (s *AuthService) Register(ctx context.Context, req *models.UserRegisterRequest, client ClientInfo) (*models.AuthResponse, error) {
+	if err := s.policy.Validate(req.Password, req.Email); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Email:     normalizeEmail(req.Email),
+		Password:  string(hashedPassword),
+		Role:      "franchiser",
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+		IsActive:  true,
+	}
 
-	// Create refresh token
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  userID,
-		"email":    email,
-		"role":     role,
-		"tenant_id": tenantID,
-		"exp":      time.Now().Add(time.Hour * 24 * 30).Unix(), // 30 days
-		"iat":      time.Now().Unix(),
+	var result *models.AuthResponse
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		tenant, err := provisionTenant(ctx, tx, req.TenantName, req.TenantSlug, req.City, models.PlanStart)
+		if err != nil {
+			return err
+		}
+
+		user.TenantID = tenant.ID
+		if err := tx.Users().Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return ErrEmailTaken
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		result, err = s.authResponse(ctx, tx, user, client)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The account is usable right away; a failed email can be resent later
+	if err := s.sendEmailVerification(ctx, user, client.Language); err != nil {
+		log.Printf("failed to start email verification for user %s: %v", user.ID, err)
+	}
+
+	return result, nil
+}
+
+// Login checks the credentials and issues a new token pair. When the user
+// has 2FA enabled, or the tenant requires it, a challenge is returned instead
+// and the login is completed by VerifyTwoFactor.
+func (s *AuthService) Login(ctx context.Context, req *models.UserLoginRequest, client ClientInfo) (*models.AuthResponse, *models.TwoFactorChallenge, error) {
+	email := normalizeEmail(req.Email)
+
+	// Blocked attempts are rejected before the password is even checked
+	if err := s.limiter.check(ctx, email, client.IPAddress); err != nil {
+		s.recordLoginAttempt(ctx, email, nil, client, models.LoginFailedThrottled)
+		return nil, nil, err
+	}
+
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			s.loginFailed(ctx, email, nil, client, models.LoginFailedInvalidCredentials)
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
 
-	// Sign tokens with secret
-	accessSecret := viper.GetString("jwt_secret")
-	if accessSecret == "" {
-		accessSecret = "default_secret_key_for_development"
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.loginFailed(ctx, email, user, client, models.LoginFailedInvalidCredentials)
+		return nil, nil, ErrInvalidCredentials
 	}
 
-	accessTokenString, err := accessToken.SignedString([]byte(accessSecret))
+	// A network's domain only signs in its own users; superadmins may sign in anywhere
+	if client.HostTenantID != "" && user.TenantID != client.HostTenantID && user.Role != models.RoleSuperadmin {
+		s.recordLoginAttempt(ctx, email, user, client, models.LoginFailedWrongDomain)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Only reveal the account status to someone who knows the password
+	if !user.IsActive {
+		s.recordLoginAttempt(ctx, email, user, client, models.LoginFailedUserInactive)
+		return nil, nil, ErrUserInactive
+	}
+
+	if _, err := activeTenant(ctx, s.store, user.TenantID); err != nil {
+		if errors.Is(err, ErrTenantSuspended) {
+			s.recordLoginAttempt(ctx, email, user, client, models.LoginFailedTenantSuspended)
+		}
+		return nil, nil, err
+	}
+
+	required, err := s.twoFactorRequired(ctx, user)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to sign access token: %w", err)
+		return nil, nil, err
+	}
+
+	// The attempt is recorded once the second factor is checked
+	if user.TwoFactorEnabled || required {
+		challenge, err := s.startChallenge(ctx, user)
+		return nil, challenge, err
 	}
 
-	refreshTokenString, err := refreshToken.SignedString([]byte(accessSecret))
+	result, err := s.authResponse(ctx, s.store, user, client)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
+		return nil, nil, err
 	}
 
-	return accessTokenString, refreshTokenString, nil
+	s.loginSucceeded(ctx, user, client)
+	return result, nil, nil
 }
 
-// RefreshTokens validates the refresh token and generates new tokens
-func (s *AuthService) RefreshTokens(refreshToken string) (string, string, error) {
-	// Parse and validate the refresh token
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// token is consumed; presenting it again revokes the whole login.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*models.TokenResponse, error) {
+	var result *models.TokenResponse
+	var reused *models.Session
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		session, err := tx.Sessions().GetByRefreshToken(ctx, hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
 		}
 
-		// Return the secret key
-		secret := viper.GetString("jwt_secret")
-		if secret == "" {
-			secret = "default_secret_key_for_development"
+		if session.RevokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		// A rotated token should never come back: assume it was stolen and
+		// end the login for both the attacker and the legitimate client
+		if session.RotatedAt != nil {
+			reused = session
+			return tx.Sessions().RevokeFamily(ctx, session.UserID, session.FamilyID, models.SessionRevokedReuseDetected)
+		}
+
+		if time.Now().After(session.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		user, err := tx.Users().GetByID(ctx, session.UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
 		}
-		return []byte(secret), nil
-	})
 
+		if !user.IsActive {
+			return ErrUserInactive
+		}
+
+		if err := tx.Sessions().MarkRotated(ctx, session.ID); err != nil {
+			return err
+		}
+
+		result, err = s.startSession(ctx, tx, user, session, client)
+		return err
+	})
 	if err != nil {
-		return "", "", fmt.Errorf("invalid refresh token: %w", err)
+		return nil, err
 	}
 
-	// Extract claims if token is valid
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Extract user information from claims
-		userID, ok := claims["user_id"].(string)
-		if !ok {
-			return "", "", errors.New("invalid token claims: user_id")
+	if reused != nil {
+		s.sessions.invalidate(reused.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return result, nil
+}
+
+// Logout revokes the login the access token belongs to
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	return s.revokeSession(ctx, userID, sessionID, models.SessionRevokedLogout)
+}
+
+// LogoutAll revokes every login of the user together with their access tokens
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	return s.revokeAllSessions(ctx, userID, models.SessionRevokedLogoutAll)
+}
+
+// ValidateSession returns tokens.ErrTokenRevoked when the login an access
+// token belongs to was revoked. Results are cached for a short time; logins
+// revoked through this process are rejected at once.
+func (s *AuthService) ValidateSession(ctx context.Context, userID, sessionID string) error {
+	active, ok := s.sessions.get(sessionID)
+	if !ok {
+		generation := s.sessions.current()
+
+		var err error
+		if active, err = s.store.Sessions().IsFamilyActive(ctx, userID, sessionID); err != nil {
+			return fmt.Errorf("failed to check session: %w", err)
 		}
+		s.sessions.put(sessionID, active, generation)
+	}
 
-		email, ok := claims["email"].(string)
-		if !ok {
-			return "", "", errors.New("invalid token claims: email")
+	if !active {
+		return tokens.ErrTokenRevoked
+	}
+	return nil
+}
+
+// forgetSessions drops every cached session check. Sessions are cached by
+// login, so revocations spanning many users clear the whole cache.
+func (s *AuthService) forgetSessions() {
+	s.sessions.invalidateAll()
+}
+
+// revokeSession revokes one login of the user; its access tokens stop working
+func (s *AuthService) revokeSession(ctx context.Context, userID, sessionID, reason string) error {
+	err := s.store.Sessions().RevokeFamily(ctx, userID, sessionID, reason)
+	s.sessions.invalidate(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// revokeAllSessions revokes every login of the user and bumps the token
+// version, so that their access tokens stop working as well
+func (s *AuthService) revokeAllSessions(ctx context.Context, userID, reason string) error {
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Users().BumpTokenVersion(ctx, userID); err != nil {
+			return err
 		}
+		return tx.Sessions().RevokeAllForUser(ctx, userID, reason)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserByID retrieves a user by their ID
+func (s *AuthService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
 
-		role, ok := claims["role"].(string)
-		if !ok {
-			return "", "", errors.New("invalid token claims: role")
+	user, err := s.store.Users().GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
 
-		tenantID, ok := claims["tenant_id"].(string)
-		if !ok {
-			return "", "", errors.New("invalid token claims: tenant_id")
+	return user, nil
+}
+
+// authResponse starts a new login for the user, attaches their tenant and
+// strips the password hash
+func (s *AuthService) authResponse(ctx context.Context, store repository.Store, user *models.User, client ClientInfo) (*models.AuthResponse, error) {
+	tenant, err := activeTenant(ctx, store, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.startSession(ctx, store, user, nil, client)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+
+	return &models.AuthResponse{
+		User:         *user,
+		Tenant:       tenant,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// renewSession continues one login of the user with a new token pair after
+// the user's access tokens were revoked. The login's current refresh token is
+// consumed. Nil is returned when the login has no current session. It must run
+// inside a transaction.
+func (s *AuthService) renewSession(ctx context.Context, store repository.Store, user *models.User, familyID string, client ClientInfo) (*models.TokenResponse, error) {
+	sessions, err := store.Sessions().ListActiveByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for i := range sessions {
+		if sessions[i].FamilyID != familyID {
+			continue
+		}
+		if err := store.Sessions().MarkRotated(ctx, sessions[i].ID); err != nil {
+			return nil, err
 		}
+		return s.startSession(ctx, store, user, &sessions[i], client)
+	}
+
+	return nil, nil
+}
+
+// startSession stores a new refresh token and issues the token pair. When
+// previous is set the new session continues that login, otherwise a new
+// login is started.
+func (s *AuthService) startSession(ctx context.Context, store repository.Store, user *models.User, previous *models.Session, client ClientInfo) (*models.TokenResponse, error) {
+	refreshToken, refreshTokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:              uuid.New().String(),
+		UserID:          user.ID,
+		RefreshToken:    refreshTokenHash,
+		ExpiresAt:       now.Add(refreshTokenTTL()),
+		AuthenticatedAt: now,
+		IPAddress:       client.IPAddress,
+		UserAgent:       client.UserAgent,
+	}
+	session.FamilyID = session.ID
 
-		// Generate new tokens
-		return s.GenerateTokens(userID, email, role, tenantID)
+	if previous != nil {
+		session.FamilyID = previous.FamilyID
+		session.AuthenticatedAt = previous.AuthenticatedAt
+		session.LastUsedAt = &now
 	}
 
-	return "", "", errors.New("invalid refresh token")
-}
\ No newline at end of file
+	if err := store.Sessions().Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	token, err := s.GenerateTokens(user, session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// refreshTokenTTL returns how long a refresh token stays valid
+func refreshTokenTTL() time.Duration {
+	days := viper.GetInt("refresh_token_expiration_days")
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// normalizeEmail makes email lookups case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// GenerateTokens creates an access token for the user bound to the given session
+func (s *AuthService) GenerateTokens(user *models.User, sessionID string) (string, error) {
+	accessToken, err := s.issuer.IssueAccess(tokens.Claims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		TenantID:     user.TenantID,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return accessToken, nil
+}
+
+// ValidateTokenVersion returns tokens.ErrTokenRevoked when the user changed
+// the password or role after the token was issued, or no longer exists
+func (s *AuthService) ValidateTokenVersion(ctx context.Context, userID string, version int) error {
+	current, err := s.store.Users().GetTokenVersion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return tokens.ErrTokenRevoked
+		}
+		return fmt.Errorf("failed to get token version: %w", err)
+	}
+
+	if version != current {
+		return tokens.ErrTokenRevoked
+	}
+	return nil
+}
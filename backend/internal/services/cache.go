@@ -0,0 +1,127 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// cacheSize is the number of entries a cache keeps by default
+const cacheSize = 10_000
+
+// ttlCache keeps values for a fixed time so that middleware running on every
+// request does not hit the database each time. Changes made through this
+// process clear the entry at once; other replicas pick them up when the
+// entry expires. The cache holds at most size entries and evicts the least
+// recently used one when it is full. A zero ttl disables the cache: nothing
+// is stored and every get misses.
+//
+// Callers read generation before they load a value and pass it to put: a
+// value loaded while an invalidation ran may be stale and is not stored.
+type ttlCache[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	size       int
+	entries    map[string]*list.Element // of *cacheEntry[V]
+	recent     *list.List               // most recently used first
+	generation uint64                   // bumped by every invalidation
+}
+
+type cacheEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration, size int) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, size: max(size, 1), entries: map[string]*list.Element{}, recent: list.New()}
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := element.Value.(*cacheEntry[V])
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+
+	c.recent.MoveToFront(element)
+	return entry.value, true
+}
+
+// current returns the generation to pass to put
+func (c *ttlCache[V]) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *ttlCache[V]) put(key string, value V, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl == 0 || generation != c.generation {
+		return
+	}
+
+	entry := &cacheEntry[V]{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.recent.MoveToFront(element)
+		return
+	}
+
+	for len(c.entries) >= c.size {
+		c.remove(c.recent.Back())
+	}
+	c.entries[key] = c.recent.PushFront(entry)
+}
+
+func (c *ttlCache[V]) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *ttlCache[V]) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+// remove drops an entry; the caller holds mu
+func (c *ttlCache[V]) remove(element *list.Element) {
+	c.recent.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry[V]).key)
+}
+
+// cacheTTL reads a cache lifetime in seconds from the configuration. An
+// explicit 0 disables the cache; unset or negative values mean 60 seconds.
+func cacheTTL(key string) time.Duration {
+	if !viper.IsSet(key) {
+		return 60 * time.Second
+	}
+	seconds := viper.GetInt(key)
+	if seconds < 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
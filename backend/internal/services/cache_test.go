@@ -0,0 +1,98 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestTTLCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTTLCache[int](time.Minute, 2)
+
+	cache.put("a", 1, cache.current())
+	cache.put("b", 2, cache.current())
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("a is missing")
+	}
+	cache.put("c", 3, cache.current())
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("b was used least recently and should be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("%s is missing", key)
+		}
+	}
+	if len(cache.entries) != 2 || cache.recent.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", len(cache.entries))
+	}
+}
+
+func TestTTLCacheExpires(t *testing.T) {
+	cache := newTTLCache[int](-time.Second, 2)
+
+	cache.put("a", 1, cache.current())
+	if _, ok := cache.get("a"); ok {
+		t.Error("expired entry returned")
+	}
+	if len(cache.entries) != 0 {
+		t.Error("expired entry kept")
+	}
+}
+
+func TestTTLCacheSkipsValuesLoadedBeforeInvalidation(t *testing.T) {
+	cache := newTTLCache[int](time.Minute, 2)
+
+	generation := cache.current()
+	cache.invalidate("a")
+	cache.put("a", 1, generation)
+	if _, ok := cache.get("a"); ok {
+		t.Error("value loaded before the invalidation was stored")
+	}
+
+	cache.put("a", 2, cache.current())
+	if value, ok := cache.get("a"); !ok || value != 2 {
+		t.Errorf("got %d, %v, want 2", value, ok)
+	}
+}
+
+func TestTTLCacheDisabled(t *testing.T) {
+	cache := newTTLCache[int](0, 2)
+
+	cache.put("a", 1, cache.current())
+	if _, ok := cache.get("a"); ok {
+		t.Error("disabled cache returned a value")
+	}
+	if len(cache.entries) != 0 {
+		t.Error("disabled cache kept an entry")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name  string
+		value any
+		want  time.Duration
+	}{
+		{name: "unset", want: time.Minute},
+		{name: "seconds", value: 10, want: 10 * time.Second},
+		{name: "zero disables", value: 0, want: 0},
+		{name: "negative", value: -5, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			if tt.value != nil {
+				viper.Set("test_cache_seconds", tt.value)
+			}
+			if got := cacheTTL("test_cache_seconds"); got != tt.want {
+				t.Fatalf("cacheTTL: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
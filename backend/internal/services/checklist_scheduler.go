@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+)
+
+// schedulerPageSize is the number of tenants the scheduler loads at a time
+const schedulerPageSize = 100
+
+// ChecklistScheduler makes the daily checklists of dealers from the recurring
+// templates of their tenant. Each day of a tenant is generated once: the run
+// is recorded in the same transaction as its checklists, so restarts and
+// other replicas skip days that are done and retry days that failed. Days
+// without a due template are not recorded and are checked again on the next run.
+type ChecklistScheduler struct {
+	store repository.Store
+	now   func() time.Time
+
+	mu   sync.Mutex
+	done map[string]time.Time // last day generated per tenant, to spare the database
+}
+
+func NewChecklistScheduler(store repository.Store) *ChecklistScheduler {
+	return &ChecklistScheduler{
+		store: store,
+		now:   time.Now,
+		done:  map[string]time.Time{},
+	}
+}
+
+// Run generates the checklists that are due at once and then every interval
+// until ctx is done
+func (s *ChecklistScheduler) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			log.Printf("Failed to generate checklists: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce makes today's checklists for every active tenant, where today is
+// taken in the tenant's timezone. Failures of one tenant are logged and do
+// not stop the others.
+func (s *ChecklistScheduler) RunOnce(ctx context.Context) error {
+	filter := models.TenantFilter{Status: "active", Limit: schedulerPageSize}
+	for {
+		tenants, err := s.store.Tenants().List(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		for i := range tenants {
+			if err := s.runTenant(ctx, &tenants[i]); err != nil {
+				log.Printf("Failed to generate checklists of tenant %s: %v", tenants[i].ID, err)
+			}
+		}
+
+		if len(tenants) < filter.Limit {
+			return nil
+		}
+		filter.Offset += filter.Limit
+	}
+}
+
+// runTenant generates today's checklists of the tenant unless this scheduler
+// already did
+func (s *ChecklistScheduler) runTenant(ctx context.Context, tenant *models.Tenant) error {
+	features, err := effectiveFeatures(ctx, s.store, tenant)
+	if err != nil {
+		return err
+	}
+	if !features.Checklist {
+		return nil
+	}
+
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+
+	loc := settingsLocation(settings)
+	date := truncateToDate(s.now().In(loc))
+
+	s.mu.Lock()
+	done := s.done[tenant.ID].Equal(date)
+	s.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	created, done, err := s.GenerateDay(ctx, tenant.ID, date, loc)
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		log.Printf("Generated %d checklists of tenant %s for %s", created, tenant.ID, date.Format(time.DateOnly))
+	}
+
+	if done {
+		s.mu.Lock()
+		s.done[tenant.ID] = date
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// GenerateDay makes the checklists of the tenant's active dealers for the
+// date from the templates that recur on it; task deadlines are taken in loc.
+// Each dealer gets at most one checklist: dealers who already have one for
+// the date are skipped, and of several due templates the first by name that
+// is assigned to the dealer is used. Nothing is made on the tenant's
+// holidays or when the day was generated before.
+//
+// The day is only recorded as generated once a template is due, so a
+// template added later in the day is still used. It returns the number of
+// checklists created and whether the day is done: generated now or before.
+func (s *ChecklistScheduler) GenerateDay(ctx context.Context, tenantID string, date time.Time, loc *time.Location) (int, bool, error) {
+	ctx = repository.WithTenant(ctx, tenantID)
+	date = truncateToDate(date)
+
+	created, done := 0, false
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		templates, err := dueTemplates(ctx, tx, tenantID, date)
+		if err != nil || len(templates) == 0 {
+			return err
+		}
+
+		done = true
+		claimed, err := tx.ChecklistRuns().Claim(ctx, tenantID, date)
+		if err != nil || !claimed {
+			return err
+		}
+
+		dealers, err := listDealers(ctx, tx, NewRoleService(tx), tenantID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := tx.Checklists().ListUserIDsByDate(ctx, tenantID, date)
+		if err != nil {
+			return fmt.Errorf("failed to list checklists: %w", err)
+		}
+
+		for i := range dealers {
+			dealer := &dealers[i]
+			if !dealer.IsActive || slices.Contains(existing, dealer.ID) {
+				continue
+			}
+
+			for j := range templates {
+				if !templates[j].Assignment.Includes(dealer, true) {
+					continue
+				}
+				if _, err := instantiate(ctx, tx, &templates[j], dealer.ID, date, loc); err != nil {
+					return err
+				}
+				created++
+				break
+			}
+		}
+
+		return tx.ChecklistRuns().Finish(ctx, tenantID, date, created)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return created, done, nil
+}
+
+// dueTemplates returns the templates of the tenant that recur on the date,
+// ordered by name; there are none on the tenant's holidays
+func dueTemplates(ctx context.Context, store repository.Store, tenantID string, date time.Time) ([]models.ChecklistTemplate, error) {
+	holiday, err := store.Holidays().IsHoliday(ctx, tenantID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check holidays: %w", err)
+	}
+	if holiday {
+		return nil, nil
+	}
+
+	templates, err := store.Templates().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	due := templates[:0]
+	for _, template := range templates {
+		if template.Recurrence.Due(date) {
+			due = append(due, template)
+		}
+	}
+	return due, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateDayWaitsForDueTemplates(t *testing.T) {
+	store := repository.NewMemoryStore()
+	scheduler := NewChecklistScheduler(store)
+	ctx := context.Background()
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	tenant := &models.Tenant{ID: uuid.NewString(), Name: "Network", Slug: "network", Plan: models.PlanStart}
+	if err := store.Tenants().Create(ctx, tenant); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	roles := append(models.DefaultRoles(tenant.ID), models.Role{TenantID: tenant.ID, Name: "outlet", Permissions: []string{models.PermissionManageChecklists}})
+	for i := range roles {
+		roles[i].ID = uuid.NewString()
+		if err := store.Roles().Create(ctx, &roles[i]); err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+	}
+
+	// Users of a custom dealer role get checklists like dealers, managers do not
+	for _, role := range []string{models.RoleDealer, "outlet", models.RoleManager} {
+		user := &models.User{ID: uuid.NewString(), Email: role + "@example.com", Role: role, TenantID: tenant.ID, IsActive: true}
+		if err := store.Users().Create(ctx, user); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	// Without a due template the day stays open
+	if created, done, err := scheduler.GenerateDay(ctx, tenant.ID, date, time.UTC); err != nil || created != 0 || done {
+		t.Fatalf("GenerateDay without templates: %d, %v, %v", created, done, err)
+	}
+
+	template := &models.ChecklistTemplate{
+		ID:         uuid.NewString(),
+		TenantID:   tenant.ID,
+		Name:       "Opening",
+		Version:    1,
+		Tasks:      []models.TemplateTask{{Title: "Open the shop", Priority: models.TaskPriorityMedium}},
+		Recurrence: &models.TemplateRecurrence{Frequency: models.RecurrenceDaily},
+	}
+	if err := store.Templates().Create(ctx, template); err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	if created, done, err := scheduler.GenerateDay(ctx, tenant.ID, date, time.UTC); err != nil || created != 2 || !done {
+		t.Fatalf("GenerateDay with a template: %d, %v, %v", created, done, err)
+	}
+	if created, done, err := scheduler.GenerateDay(ctx, tenant.ID, date, time.UTC); err != nil || created != 0 || !done {
+		t.Fatalf("GenerateDay again: %d, %v, %v", created, done, err)
+	}
+}
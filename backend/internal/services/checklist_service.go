@@ -1,299 +1,456 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"math/rand"
+	"strings"
 	"time"
 
 	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrChecklistNotFound is returned when a checklist does not exist or belongs to another user or tenant
+	ErrChecklistNotFound = errors.New("checklist not found")
+	// ErrChecklistExists is returned when the user already has a checklist for the requested date
+	ErrChecklistExists = errors.New("checklist for this date already exists")
+	// ErrTaskNotFound is returned when the checklist has no task with the ID
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskNotCompleted is returned when verifying a task that is not completed
+	ErrTaskNotCompleted = errors.New("only completed tasks can be verified")
+)
+
 type ChecklistService struct {
-	db interface{}
+	store repository.Store
+	roles *RoleService
 }
 
-func NewChecklistService(db interface{}) *ChecklistService {
-	return &ChecklistService{db: db}
+func NewChecklistService(store repository.Store, roles *RoleService) *ChecklistService {
+	return &ChecklistService{store: store, roles: roles}
 }
 
 // GetChecklistsByUserID retrieves all checklists for a specific user
-func (s *ChecklistService) GetChecklistsByUserID(userID string, limit, offset int) ([]models.Checklist, error) {
-	// In a real implementation, you would query the database
-	// For now, we'll simulate the retrieval
-	
+func (s *ChecklistService) GetChecklistsByUserID(ctx context.Context, tenantID, userID string, limit, offset int) ([]models.Checklist, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(userID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
-	// Simulate fetching from database
-	// Generate sample checklists
-	checklists := []models.Checklist{}
-	
-	// Create sample checklists
-	for i := 0; i < 5; i++ {
-		id := uuid.New().String()
-		date := time.Now().AddDate(0, 0, -i) // Different dates
-		
-		// Calculate random KPI score
-		kpiScore := float64(rand.Intn(100))
-		
-		// Create sample tasks
-		tasks := []models.Task{
-			{
-				ID:          uuid.New().String(),
-				Title:       "Позвонить клиенту",
-				Description: "Сделать звонок потенциальному клиенту",
-				Status:      getRandomStatus(),
-				Order:       1,
-				CreatedAt:   date,
-				UpdatedAt:   date,
-			},
-			{
-				ID:          uuid.New().String(),
-				Title:       "Опубликовать пост",
-				Description: "Опубликовать рекламный пост в соцсетях",
-				Status:      getRandomStatus(),
-				Order:       2,
-				CreatedAt:   date,
-				UpdatedAt:   date,
-			},
-			{
-				ID:          uuid.New().String(),
-				Title:       "Провести встречу",
-				Description: "Провести встречу с потенциальным партнёром",
-				Status:      getRandomStatus(),
-				Order:       3,
-				CreatedAt:   date,
-				UpdatedAt:   date,
-			},
-		}
-		
-		checklist := models.Checklist{
-			ID:          id,
-			Title:       fmt.Sprintf("Ежедневный чек-лист %s", date.Format("02.01.2006")),
-			Description: fmt.Sprintf("Чек-лист задач на %s", date.Format("02.01.2006")),
-			UserID:      userID,
-			TenantID:    "tenant-1",
-			Status:      calculateStatusFromTasks(tasks),
-			CreatedAt:   date,
-			UpdatedAt:   date,
-			Tasks:       tasks,
-			KPIScore:    kpiScore,
+
+	checklists, err := s.store.Checklists().ListByUser(ctx, tenantID, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checklists: %w", err)
+	}
+
+	if len(checklists) == 0 {
+		return checklists, nil
+	}
+
+	// Load tasks for all checklists in one round trip
+	ids := make([]string, len(checklists))
+	for i := range checklists {
+		ids[i] = checklists[i].ID
+	}
+
+	tasks, err := s.store.Tasks().ListByChecklists(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+
+	for i := range checklists {
+		checklists[i].Tasks = tasks[checklists[i].ID]
+		if checklists[i].Tasks == nil {
+			checklists[i].Tasks = []models.Task{}
 		}
-		
-		checklists = append(checklists, checklist)
 	}
-	
+
 	return checklists, nil
 }
 
 // GetChecklistByID retrieves a specific checklist by its ID
-func (s *ChecklistService) GetChecklistByID(checklistID, userID string) (*models.Checklist, error) {
-	// In a real implementation, you would query the database
-	// For now, we'll simulate the retrieval
-	
+func (s *ChecklistService) GetChecklistByID(ctx context.Context, tenantID, checklistID, userID string) (*models.Checklist, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(checklistID); err != nil {
 		return nil, errors.New("invalid checklist ID format")
 	}
-	
+
 	if _, err := uuid.Parse(userID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
-	// Simulate fetching from database
-	// For demo purposes, we'll create a checklist if it doesn't exist
-	date := time.Now()
-	
-	tasks := []models.Task{
-		{
-			ID:          uuid.New().String(),
-			Title:       "Позвонить клиенту",
-			Description: "Сделать звонок потенциальному клиенту",
-			Status:      "pending",
-			Order:       1,
-			CreatedAt:   date,
-			UpdatedAt:   date,
-		},
-		{
-			ID:          uuid.New().String(),
-			Title:       "Опубликовать пост",
-			Description: "Опубликовать рекламный пост в соцсетях",
-			Status:      "in_progress",
-			Order:       2,
-			CreatedAt:   date,
-			UpdatedAt:   date,
-		},
-		{
-			ID:          uuid.New().String(),
-			Title:       "Провести встречу",
-			Description: "Провести встречу с потенциальным партнёром",
-			Status:      "completed",
-			Order:       3,
-			CreatedAt:   date,
-			UpdatedAt:   date,
-		},
-	}
-	
-	checklist := &models.Checklist{
-		ID:          checklistID,
-		Title:       "Ежедневный чек-лист",
-		Description: "Чек-лист задач на сегодня",
-		UserID:      userID,
-		TenantID:    "tenant-1",
-		Status:      calculateStatusFromTasks(tasks),
-		CreatedAt:   date,
-		UpdatedAt:   date,
-		Tasks:       tasks,
-		KPIScore:    85.0,
-	}
-	
+
+	checklist, err := s.store.Checklists().GetByID(ctx, tenantID, checklistID, userID)
+	if err != nil {
+		return nil, checklistError(err)
+	}
+
+	checklist.Tasks, err = s.store.Tasks().ListByChecklist(ctx, checklist.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+
+	return checklist, nil
+}
+
+// GetDealerChecklists returns the checklists of a dealer the caller oversees.
+// A non-empty managerID limits the dealers to those assigned to that manager.
+func (s *ChecklistService) GetDealerChecklists(ctx context.Context, tenantID, managerID, dealerID string, limit, offset int) ([]models.Checklist, error) {
+	if _, err := visibleDealer(ctx, s.store, s.roles, tenantID, managerID, dealerID); err != nil {
+		return nil, err
+	}
+
+	return s.GetChecklistsByUserID(ctx, tenantID, dealerID, limit, offset)
+}
+
+// GetDealerChecklist returns a checklist of a dealer the caller oversees;
+// checklists of other users are reported as ErrChecklistNotFound
+func (s *ChecklistService) GetDealerChecklist(ctx context.Context, tenantID, managerID, checklistID string) (*models.Checklist, error) {
+	if _, err := uuid.Parse(checklistID); err != nil {
+		return nil, ErrChecklistNotFound
+	}
+
+	checklist, err := s.store.Checklists().GetInTenant(ctx, tenantID, checklistID)
+	if err != nil {
+		return nil, checklistError(err)
+	}
+
+	if _, err := visibleDealer(ctx, s.store, s.roles, tenantID, managerID, checklist.UserID); err != nil {
+		if errors.Is(err, ErrDealerNotFound) {
+			return nil, ErrChecklistNotFound
+		}
+		return nil, err
+	}
+
+	checklist.Tasks, err = s.store.Tasks().ListByChecklist(ctx, checklist.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+
 	return checklist, nil
 }
 
-// CreateChecklist creates a new checklist
-func (s *ChecklistService) CreateChecklist(checklist *models.Checklist) (*models.Checklist, error) {
-	// In a real implementation, you would insert into the database
-	// For now, we'll simulate the creation
-	
+// ReviewTask verifies a task of a dealer's checklist or withdraws the
+// verification, and sets the reviewer's comment
+func (s *ChecklistService) ReviewTask(ctx context.Context, tenantID, managerID, reviewerID, checklistID, taskID string, req models.TaskReviewRequest) (*models.Task, error) {
+	checklist, err := s.GetDealerChecklist(ctx, tenantID, managerID, checklistID)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *models.Task
+	for i := range checklist.Tasks {
+		if checklist.Tasks[i].ID == taskID {
+			task = &checklist.Tasks[i]
+		}
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	if req.Verified != nil {
+		if !*req.Verified {
+			task.VerifiedAt = nil
+			task.VerifiedBy = ""
+		} else if task.Status != "completed" {
+			return nil, ErrTaskNotCompleted
+		} else if task.VerifiedAt == nil {
+			now := time.Now()
+			task.VerifiedAt = &now
+			task.VerifiedBy = reviewerID
+		}
+	}
+	if req.Comment != nil {
+		task.ReviewComment = strings.TrimSpace(*req.Comment)
+	}
+
+	if err := s.store.Tasks().Review(ctx, checklist.ID, task); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to review task: %w", err)
+	}
+
+	return task, nil
+}
+
+// CreateChecklist creates a new checklist together with its tasks
+func (s *ChecklistService) CreateChecklist(ctx context.Context, checklist *models.Checklist) (*models.Checklist, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(checklist.UserID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
+
+	if _, err := uuid.Parse(checklist.TenantID); err != nil {
+		return nil, errors.New("invalid tenant ID format")
+	}
+
+	if checklist.ID == "" {
+		checklist.ID = uuid.New().String()
+	}
+
+	// One checklist per user per day; default to today
+	if checklist.Date.IsZero() {
+		checklist.Date = time.Now()
+	}
+	checklist.Date = truncateToDate(checklist.Date)
+
 	// Set status based on tasks if not set
 	if checklist.Status == "" {
 		checklist.Status = calculateStatusFromTasks(checklist.Tasks)
 	}
-	
+
 	// Calculate KPI score based on task completion
 	checklist.KPIScore = calculateKPIScore(checklist.Tasks)
-	
-	// Update timestamps
-	now := time.Now()
-	checklist.CreatedAt = now
-	checklist.UpdatedAt = now
-	
-	// In a real implementation, you would save to the database here
-	
+
+	if checklist.Tasks == nil {
+		checklist.Tasks = []models.Task{}
+	}
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Checklists().Create(ctx, checklist); err != nil {
+			return err
+		}
+
+		for i := range checklist.Tasks {
+			task := &checklist.Tasks[i]
+			task.ID = uuid.New().String()
+			prepareTask(task, i+1, nil)
+
+			if err := tx.Tasks().Create(ctx, checklist.ID, task); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, checklistError(err)
+	}
+
 	return checklist, nil
 }
 
 // UpdateChecklist updates an existing checklist
-func (s *ChecklistService) UpdateChecklist(checklistID, userID string, req models.ChecklistUpdateRequest) (*models.Checklist, error) {
-	// In a real implementation, you would update the database
-	// For now, we'll simulate the update
-	
+func (s *ChecklistService) UpdateChecklist(ctx context.Context, tenantID, checklistID, userID string, req models.ChecklistUpdateRequest) (*models.Checklist, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(checklistID); err != nil {
 		return nil, errors.New("invalid checklist ID format")
 	}
-	
+
 	if _, err := uuid.Parse(userID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
-	// Fetch existing checklist
-	existingChecklist, err := s.GetChecklistByID(checklistID, userID)
-	if err != nil || existingChecklist == nil {
-		return nil, errors.New("checklist not found")
-	}
-	
-	// Update fields if provided in request
-	if req.Title != "" {
-		existingChecklist.Title = req.Title
-	}
-	if req.Description != "" {
-		existingChecklist.Description = req.Description
-	}
-	if req.Status != "" {
-		existingChecklist.Status = req.Status
-	}
-	if req.Tasks != nil {
-		existingChecklist.Tasks = req.Tasks
-		existingChecklist.Status = calculateStatusFromTasks(req.Tasks)
-		existingChecklist.KPIScore = calculateKPIScore(req.Tasks)
-	}
-	
-	// Update timestamp
-	existingChecklist.UpdatedAt = time.Now()
-	
-	// In a real implementation, you would save to the database here
-	
-	return existingChecklist, nil
+
+	var updated *models.Checklist
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		// Lock the checklist so concurrent updates don't interleave task changes
+		existing, err := tx.Checklists().GetForUpdate(ctx, tenantID, checklistID, userID)
+		if err != nil {
+			return err
+		}
+
+		existing.Tasks, err = tx.Tasks().ListByChecklist(ctx, existing.ID)
+		if err != nil {
+			return err
+		}
+
+		// Update fields if provided in request
+		if req.Title != "" {
+			existing.Title = req.Title
+		}
+		if req.Description != "" {
+			existing.Description = req.Description
+		}
+		if req.Status != "" {
+			existing.Status = req.Status
+		}
+		if req.Tasks != nil {
+			tasks, err := replaceTasks(ctx, tx.Tasks(), existing.ID, existing.Tasks, req.Tasks)
+			if err != nil {
+				return err
+			}
+			existing.Tasks = tasks
+			existing.Status = calculateStatusFromTasks(tasks)
+			existing.KPIScore = calculateKPIScore(tasks)
+		}
+
+		if err := tx.Checklists().Update(ctx, existing); err != nil {
+			return err
+		}
+
+		updated = existing
+		return nil
+	})
+	if err != nil {
+		return nil, checklistError(err)
+	}
+
+	return updated, nil
 }
 
 // DeleteChecklist deletes a checklist by ID
-func (s *ChecklistService) DeleteChecklist(checklistID, userID string) error {
-	// In a real implementation, you would delete from the database
-	// For now, we'll simulate the deletion
-	
+func (s *ChecklistService) DeleteChecklist(ctx context.Context, tenantID, checklistID, userID string) error {
 	// Validate UUID format
 	if _, err := uuid.Parse(checklistID); err != nil {
 		return errors.New("invalid checklist ID format")
 	}
-	
+
 	if _, err := uuid.Parse(userID); err != nil {
 		return errors.New("invalid user ID format")
 	}
-	
-	// Check if checklist exists (by trying to fetch it)
-	_, err := s.GetChecklistByID(checklistID, userID)
-	if err != nil {
-		return errors.New("checklist not found")
+
+	if err := s.store.Checklists().Delete(ctx, tenantID, checklistID, userID); err != nil {
+		return checklistError(err)
 	}
-	
-	// In a real implementation, you would delete from the database here
-	
+
 	return nil
 }
 
-// CompleteChecklist marks a checklist as completed
-func (s *ChecklistService) CompleteChecklist(checklistID, userID string) (*models.Checklist, error) {
-	// In a real implementation, you would update the database
-	// For now, we'll simulate the completion
-	
+// CompleteChecklist marks a checklist and all of its tasks as completed
+func (s *ChecklistService) CompleteChecklist(ctx context.Context, tenantID, checklistID, userID string) (*models.Checklist, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(checklistID); err != nil {
 		return nil, errors.New("invalid checklist ID format")
 	}
-	
+
 	if _, err := uuid.Parse(userID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
-	// Fetch existing checklist
-	existingChecklist, err := s.GetChecklistByID(checklistID, userID)
-	if err != nil || existingChecklist == nil {
-		return nil, errors.New("checklist not found")
-	}
-	
-	// Mark all tasks as completed if not already
-	for i := range existingChecklist.Tasks {
-		if existingChecklist.Tasks[i].Status != "completed" {
-			existingChecklist.Tasks[i].Status = "completed"
-			existingChecklist.Tasks[i].UpdatedAt = time.Now()
+
+	var completed *models.Checklist
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		checklist, err := tx.Checklists().GetForUpdate(ctx, tenantID, checklistID, userID)
+		if err != nil {
+			return err
+		}
+
+		// Mark all tasks as completed if not already
+		if err := tx.Tasks().CompleteAll(ctx, checklist.ID); err != nil {
+			return err
+		}
+
+		checklist.Status = "completed"
+		checklist.KPIScore = 100.0 // Perfect score when completed
+		if err := tx.Checklists().Update(ctx, checklist); err != nil {
+			return err
+		}
+
+		checklist.Tasks, err = tx.Tasks().ListByChecklist(ctx, checklist.ID)
+		if err != nil {
+			return err
+		}
+
+		completed = checklist
+		return nil
+	})
+	if err != nil {
+		return nil, checklistError(err)
+	}
+
+	return completed, nil
+}
+
+// replaceTasks makes the stored task list match the requested one: known tasks
+// are updated in place, unknown ones are inserted and missing ones are deleted
+func replaceTasks(ctx context.Context, repo repository.TaskRepository, checklistID string, current, requested []models.Task) ([]models.Task, error) {
+	known := make(map[string]models.Task, len(current))
+	for _, task := range current {
+		known[task.ID] = task
+	}
+
+	keep := make([]string, 0, len(requested))
+	result := make([]models.Task, 0, len(requested))
+
+	for i, task := range requested {
+		if previous, ok := known[task.ID]; ok {
+			prepareTask(&task, i+1, previous.CompletedAt)
+			keepReview(&task, previous)
+			task.Verification = previous.Verification
+			if err := repo.Update(ctx, checklistID, &task); err != nil {
+				return nil, err
+			}
+			if previous.VerifiedAt != nil && task.VerifiedAt == nil {
+				if err := repo.Review(ctx, checklistID, &task); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			task.ID = uuid.New().String()
+			prepareTask(&task, i+1, nil)
+			if err := repo.Create(ctx, checklistID, &task); err != nil {
+				return nil, err
+			}
 		}
+
+		keep = append(keep, task.ID)
+		result = append(result, task)
 	}
-	
-	// Update checklist status to completed
-	existingChecklist.Status = "completed"
-	existingChecklist.KPIScore = 100.0 // Perfect score when completed
-	existingChecklist.UpdatedAt = time.Now()
-	
-	// In a real implementation, you would save to the database here
-	
-	return existingChecklist, nil
+
+	if err := repo.DeleteExcept(ctx, checklistID, keep); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-// Helper function to randomly assign task statuses (for demo purposes)
-func getRandomStatus() string {
-	statuses := []string{"pending", "in_progress", "completed"}
-	return statuses[rand.Intn(len(statuses))]
+// keepReview carries the manager's review over to the owner's new version of
+// the task; the verification lapses when the task is no longer completed
+func keepReview(task *models.Task, previous models.Task) {
+	task.VerifiedAt = previous.VerifiedAt
+	task.VerifiedBy = previous.VerifiedBy
+	task.ReviewComment = previous.ReviewComment
+
+	if task.Status != "completed" {
+		task.VerifiedAt = nil
+		task.VerifiedBy = ""
+	}
+}
+
+// prepareTask fills defaults before a task is saved. completedAt is the
+// completion time already stored for the task, if any.
+func prepareTask(task *models.Task, position int, completedAt *time.Time) {
+	if task.Status == "" {
+		task.Status = "pending"
+	}
+	if task.Priority == "" {
+		task.Priority = "medium"
+	}
+	task.Order = position
+
+	// Only reviewers set these, through ReviewTask
+	task.VerifiedAt = nil
+	task.VerifiedBy = ""
+	task.ReviewComment = ""
+	// Only templates set this
+	task.Verification = ""
+
+	if task.Status != "completed" {
+		task.CompletedAt = nil
+	} else if completedAt != nil {
+		task.CompletedAt = completedAt
+	} else {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+}
+
+// checklistError maps repository errors to the errors handlers understand
+func checklistError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrChecklistNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return ErrChecklistExists
+	default:
+		return fmt.Errorf("checklist storage error: %w", err)
+	}
+}
+
+// truncateToDate drops the time of day, keeping the calendar date
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
 // Helper function to calculate checklist status based on task statuses
@@ -301,10 +458,10 @@ func calculateStatusFromTasks(tasks []models.Task) string {
 	if len(tasks) == 0 {
 		return "pending"
 	}
-	
+
 	completedCount := 0
 	inProgressCount := 0
-	
+
 	for _, task := range tasks {
 		if task.Status == "completed" {
 			completedCount++
@@ -312,7 +469,7 @@ func calculateStatusFromTasks(tasks []models.Task) string {
 			inProgressCount++
 		}
 	}
-	
+
 	if completedCount == len(tasks) {
 		return "completed"
 	} else if inProgressCount > 0 || completedCount > 0 {
@@ -327,13 +484,13 @@ func calculateKPIScore(tasks []models.Task) float64 {
 	if len(tasks) == 0 {
 		return 0.0
 	}
-	
+
 	completedCount := 0
 	for _, task := range tasks {
 		if task.Status == "completed" {
 			completedCount++
 		}
 	}
-	
+
 	return float64(completedCount) / float64(len(tasks)) * 100.0
-}
\ No newline at end of file
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTemplateNotFound is returned when a template does not exist, belongs
+	// to another tenant or is not assigned to the dealer
+	ErrTemplateNotFound = errors.New("checklist template not found")
+	// ErrInvalidTemplate is returned when a template or its assignment is not valid
+	ErrInvalidTemplate = errors.New("invalid checklist template")
+)
+
+// ChecklistTemplateService manages the checklist templates of a tenant and
+// makes checklists from them
+type ChecklistTemplateService struct {
+	store repository.Store
+	roles *RoleService
+}
+
+func NewChecklistTemplateService(store repository.Store, roles *RoleService) *ChecklistTemplateService {
+	return &ChecklistTemplateService{store: store, roles: roles}
+}
+
+// ListTemplates returns the templates of the tenant. Unless all is set only
+// the templates assigned to the user are returned.
+func (s *ChecklistTemplateService) ListTemplates(ctx context.Context, tenantID, userID string, all bool) ([]models.ChecklistTemplate, error) {
+	templates, err := s.store.Templates().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	if all {
+		return templates, nil
+	}
+
+	// API keys are not users and have no templates assigned
+	assigned := []models.ChecklistTemplate{}
+	user, err := s.store.Users().GetInTenant(ctx, tenantID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return assigned, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	isDealer, err := s.roles.IsDealerRole(ctx, tenantID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		if template.Assignment.Includes(user, isDealer) {
+			assigned = append(assigned, template)
+		}
+	}
+	return assigned, nil
+}
+
+// GetTemplate returns a template of the tenant. Unless all is set, templates
+// not assigned to the user are reported as ErrTemplateNotFound.
+func (s *ChecklistTemplateService) GetTemplate(ctx context.Context, tenantID, userID, templateID string, all bool) (*models.ChecklistTemplate, error) {
+	return s.getTemplate(ctx, s.store, tenantID, userID, templateID, all)
+}
+
+// CreateTemplate creates a template at version 1
+func (s *ChecklistTemplateService) CreateTemplate(ctx context.Context, tenantID, userID string, req models.ChecklistTemplateRequest) (*models.ChecklistTemplate, error) {
+	template := &models.ChecklistTemplate{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Version:   1,
+		CreatedBy: userID,
+	}
+	if err := s.applyRequest(ctx, template, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Templates().Create(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+// UpdateTemplate replaces the content, the assignment and the recurrence of a
+// template. A change to the name, description or tasks creates a new version;
+// checklists made earlier keep pointing at the version they were made from.
+func (s *ChecklistTemplateService) UpdateTemplate(ctx context.Context, tenantID, userID, templateID string, req models.ChecklistTemplateRequest) (*models.ChecklistTemplate, error) {
+	if _, err := uuid.Parse(templateID); err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	var updated *models.ChecklistTemplate
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		template, err := tx.Templates().GetForUpdate(ctx, tenantID, templateID)
+		if err != nil {
+			return templateError(err)
+		}
+
+		previous := *template
+		if err := s.applyRequest(ctx, template, req); err != nil {
+			return err
+		}
+
+		contentChanged := template.Name != previous.Name || template.Description != previous.Description ||
+			!reflect.DeepEqual(template.Tasks, previous.Tasks)
+		if contentChanged {
+			template.Version++
+			if err := tx.Templates().AddVersion(ctx, template, userID); err != nil {
+				return fmt.Errorf("failed to save template version: %w", err)
+			}
+		}
+
+		if err := tx.Templates().Update(ctx, template); err != nil {
+			return templateError(err)
+		}
+
+		updated = template
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// DeleteTemplate removes a template with its versions; checklists made from it are kept
+func (s *ChecklistTemplateService) DeleteTemplate(ctx context.Context, tenantID, templateID string) error {
+	if _, err := uuid.Parse(templateID); err != nil {
+		return ErrTemplateNotFound
+	}
+
+	if err := s.store.Templates().Delete(ctx, tenantID, templateID); err != nil {
+		return templateError(err)
+	}
+	return nil
+}
+
+// ListVersions returns every version of a template, newest first
+func (s *ChecklistTemplateService) ListVersions(ctx context.Context, tenantID, templateID string) ([]models.ChecklistTemplateVersion, error) {
+	if _, err := s.getTemplate(ctx, s.store, tenantID, "", templateID, true); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.store.Templates().ListVersions(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+	return versions, nil
+}
+
+// CreateChecklist makes the user's checklist for a day from the current
+// version of a template. The day defaults to today in the tenant's timezone.
+// Unless all is set, the template must be assigned to the user.
+func (s *ChecklistTemplateService) CreateChecklist(ctx context.Context, tenantID, userID, templateID string, req models.ChecklistFromTemplateRequest, all bool) (*models.Checklist, error) {
+	loc, err := tenantLocation(ctx, s.store, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := time.Now().In(loc)
+	if req.Date != "" {
+		if date, err = time.Parse(time.DateOnly, req.Date); err != nil {
+			return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidTemplate)
+		}
+	}
+
+	var checklist *models.Checklist
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		template, err := s.getTemplate(ctx, tx, tenantID, userID, templateID, all)
+		if err != nil {
+			return err
+		}
+
+		checklist, err = instantiate(ctx, tx, template, userID, date, loc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checklist, nil
+}
+
+// instantiate creates the user's checklist for the calendar date of day from
+// the template. Task deadlines are the template's times of day on that date
+// in loc.
+func instantiate(ctx context.Context, tx repository.Store, template *models.ChecklistTemplate, userID string, day time.Time, loc *time.Location) (*models.Checklist, error) {
+	date := truncateToDate(day)
+
+	checklist := &models.Checklist{
+		ID:              uuid.New().String(),
+		Title:           template.Name,
+		Description:     template.Description,
+		UserID:          userID,
+		TenantID:        template.TenantID,
+		Date:            date,
+		Status:          "pending",
+		Tasks:           make([]models.Task, len(template.Tasks)),
+		TemplateID:      template.ID,
+		TemplateVersion: template.Version,
+	}
+
+	if err := tx.Checklists().Create(ctx, checklist); err != nil {
+		return nil, checklistError(err)
+	}
+
+	for i, item := range template.Tasks {
+		task := &checklist.Tasks[i]
+		task.ID = uuid.New().String()
+		task.Title = item.Title
+		task.Description = item.Description
+		task.Category = item.Category
+		task.Priority = item.Priority
+		prepareTask(task, i+1, nil)
+		task.Verification = item.Verification
+
+		if item.DeadlineOffset != nil {
+			offset := *item.DeadlineOffset
+			deadline := time.Date(date.Year(), date.Month(), date.Day(), offset/60, offset%60, 0, 0, loc)
+			task.Deadline = &deadline
+		}
+
+		if err := tx.Tasks().Create(ctx, checklist.ID, task); err != nil {
+			return nil, checklistError(err)
+		}
+	}
+
+	return checklist, nil
+}
+
+// getTemplate loads a template of the tenant; unless all is set it must be
+// assigned to the user
+func (s *ChecklistTemplateService) getTemplate(ctx context.Context, store repository.Store, tenantID, userID, templateID string, all bool) (*models.ChecklistTemplate, error) {
+	if _, err := uuid.Parse(templateID); err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	template, err := store.Templates().GetInTenant(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, templateError(err)
+	}
+	if all {
+		return template, nil
+	}
+
+	user, err := store.Users().GetInTenant(ctx, tenantID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	isDealer, err := NewRoleService(store).IsDealerRole(ctx, tenantID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !template.Assignment.Includes(user, isDealer) {
+		return nil, ErrTemplateNotFound
+	}
+
+	return template, nil
+}
+
+// applyRequest validates the request and copies it onto the template
+func (s *ChecklistTemplateService) applyRequest(ctx context.Context, template *models.ChecklistTemplate, req models.ChecklistTemplateRequest) error {
+	template.Name = strings.TrimSpace(req.Name)
+	template.Description = strings.TrimSpace(req.Description)
+	if template.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidTemplate)
+	}
+
+	template.Tasks = make([]models.TemplateTask, len(req.Tasks))
+	for i, task := range req.Tasks {
+		task.Title = strings.TrimSpace(task.Title)
+		task.Description = strings.TrimSpace(task.Description)
+		task.Category = strings.TrimSpace(task.Category)
+		if task.Title == "" {
+			return fmt.Errorf("%w: task %d has no title", ErrInvalidTemplate, i+1)
+		}
+		switch task.Priority {
+		case "":
+			task.Priority = models.TaskPriorityMedium
+		case models.TaskPriorityLow, models.TaskPriorityMedium, models.TaskPriorityHigh:
+		default:
+			return fmt.Errorf("%w: task %d has unknown priority %q", ErrInvalidTemplate, i+1, task.Priority)
+		}
+		if task.DeadlineOffset != nil && (*task.DeadlineOffset < 0 || *task.DeadlineOffset > models.MaxDeadlineOffset) {
+			return fmt.Errorf("%w: task %d must be due between 0 and %d minutes after midnight", ErrInvalidTemplate, i+1, models.MaxDeadlineOffset)
+		}
+		template.Tasks[i] = task
+	}
+
+	assignment, err := s.checkAssignment(ctx, template.TenantID, req.Assignment)
+	if err != nil {
+		return err
+	}
+	template.Assignment = assignment
+
+	recurrence, err := checkRecurrence(req.Recurrence)
+	if err != nil {
+		return err
+	}
+	template.Recurrence = recurrence
+
+	return nil
+}
+
+// checkRecurrence normalises a recurrence, keeping only the fields its
+// frequency uses
+func checkRecurrence(recurrence *models.TemplateRecurrence) (*models.TemplateRecurrence, error) {
+	if recurrence == nil {
+		return nil, nil
+	}
+
+	switch recurrence.Frequency {
+	case models.RecurrenceDaily, models.RecurrenceWeekdays:
+		return &models.TemplateRecurrence{Frequency: recurrence.Frequency}, nil
+	case models.RecurrenceWeekly:
+		if recurrence.Weekday < 1 || recurrence.Weekday > 7 {
+			return nil, fmt.Errorf("%w: recurrence.weekday must be 1 (Monday) to 7 (Sunday) for a weekly template", ErrInvalidTemplate)
+		}
+		return &models.TemplateRecurrence{Frequency: recurrence.Frequency, Weekday: recurrence.Weekday}, nil
+	case models.RecurrenceMonthly:
+		if recurrence.MonthDay < 1 || recurrence.MonthDay > 31 {
+			return nil, fmt.Errorf("%w: recurrence.month_day must be 1 to 31 for a monthly template", ErrInvalidTemplate)
+		}
+		return &models.TemplateRecurrence{Frequency: recurrence.Frequency, MonthDay: recurrence.MonthDay}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown recurrence frequency %q", ErrInvalidTemplate, recurrence.Frequency)
+	}
+}
+
+// checkAssignment normalises an assignment and checks that the listed
+// dealers are dealers of the tenant
+func (s *ChecklistTemplateService) checkAssignment(ctx context.Context, tenantID string, assignment models.TemplateAssignment) (models.TemplateAssignment, error) {
+	switch assignment.Scope {
+	case "", models.TemplateScopeAll:
+		return models.TemplateAssignment{Scope: models.TemplateScopeAll}, nil
+
+	case models.TemplateScopeCity:
+		city := strings.TrimSpace(assignment.City)
+		if city == "" {
+			return assignment, fmt.Errorf("%w: assignment.city is required for the city scope", ErrInvalidTemplate)
+		}
+		return models.TemplateAssignment{Scope: models.TemplateScopeCity, City: city}, nil
+
+	case models.TemplateScopeDealers:
+		dealerIDs := slices.Compact(slices.Sorted(slices.Values(assignment.DealerIDs)))
+		if len(dealerIDs) == 0 {
+			return assignment, fmt.Errorf("%w: assignment.dealer_ids is required for the dealers scope", ErrInvalidTemplate)
+		}
+		for _, dealerID := range dealerIDs {
+			if _, err := visibleDealer(ctx, s.store, s.roles, tenantID, "", dealerID); err != nil {
+				if errors.Is(err, ErrDealerNotFound) {
+					return assignment, fmt.Errorf("%w: %s is not a dealer of the network", ErrInvalidTemplate, dealerID)
+				}
+				return assignment, err
+			}
+		}
+		return models.TemplateAssignment{Scope: models.TemplateScopeDealers, DealerIDs: dealerIDs}, nil
+
+	default:
+		return assignment, fmt.Errorf("%w: unknown assignment scope %q", ErrInvalidTemplate, assignment.Scope)
+	}
+}
+
+// templateError maps repository errors to the errors handlers understand
+func templateError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTemplateNotFound
+	}
+	return fmt.Errorf("checklist template storage error: %w", err)
+}
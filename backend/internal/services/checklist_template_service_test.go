@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateTemplateValidatesTasks(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewChecklistTemplateService(store, NewRoleService(store))
+	tenantID := uuid.NewString()
+
+	minutes := func(n int) *int { return &n }
+	tests := []struct {
+		name  string
+		task  models.TemplateTask
+		valid bool
+	}{
+		{"defaults", models.TemplateTask{Title: "Open the shop"}, true},
+		{"last minute of the day", models.TemplateTask{Title: "Close", Priority: models.TaskPriorityHigh, DeadlineOffset: minutes(1439)}, true},
+		{"unknown priority", models.TemplateTask{Title: "Open", Priority: "urgent"}, false},
+		{"negative deadline", models.TemplateTask{Title: "Open", DeadlineOffset: minutes(-1)}, false},
+		{"deadline past midnight", models.TemplateTask{Title: "Open", DeadlineOffset: minutes(1440)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := service.CreateTemplate(context.Background(), tenantID, uuid.NewString(), models.ChecklistTemplateRequest{
+				Name:  "Opening",
+				Tasks: []models.TemplateTask{tt.task},
+			})
+			switch {
+			case tt.valid && err != nil:
+				t.Fatalf("CreateTemplate: %v", err)
+			case tt.valid && template.Tasks[0].Priority == "":
+				t.Fatal("priority not defaulted")
+			case !tt.valid && !errors.Is(err, ErrInvalidTemplate):
+				t.Fatalf("CreateTemplate: got %v, want ErrInvalidTemplate", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+var (
+	// ErrDomainNotFound is returned when the domain does not exist in the caller's tenant
+	ErrDomainNotFound = errors.New("domain not found")
+	// ErrInvalidDomain is returned for malformed domains and domains of the platform itself
+	ErrInvalidDomain = errors.New("invalid domain")
+	// ErrDomainTaken is returned when the tenant already added the domain or another tenant verified it
+	ErrDomainTaken = errors.New("domain is already taken")
+	// ErrDomainNotVerified is returned when the TXT record does not hold the verification token
+	ErrDomainNotVerified = errors.New("verification record not found")
+)
+
+var domainRe = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// TXTResolver looks up DNS TXT records; net.DefaultResolver satisfies it
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DomainService manages the custom domains of tenants and resolves request
+// hosts to the tenant that verified them
+type DomainService struct {
+	store    repository.Store
+	resolver TXTResolver
+	hosts    *ttlCache[string]   // tenant of each verified host
+	unknown  *ttlCache[struct{}] // hosts no tenant verified; kept small, anyone can send any Host
+}
+
+// unknownHostCacheSize is the number of unknown hosts DomainService remembers
+const unknownHostCacheSize = 1_000
+
+func NewDomainService(store repository.Store, resolver TXTResolver) *DomainService {
+	ttl := cacheTTL("domain_cache_seconds")
+	return &DomainService{
+		store:    store,
+		resolver: resolver,
+		hosts:    newTTLCache[string](ttl, cacheSize),
+		unknown:  newTTLCache[struct{}](ttl, unknownHostCacheSize),
+	}
+}
+
+// ListDomains returns the domains of the tenant with their verification records
+func (s *DomainService) ListDomains(ctx context.Context, tenantID string) ([]models.TenantDomainResponse, error) {
+	domains, err := s.store.Domains().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	responses := make([]models.TenantDomainResponse, len(domains))
+	for i := range domains {
+		responses[i] = domainResponse(&domains[i])
+	}
+
+	return responses, nil
+}
+
+// AddDomain registers a domain for the tenant. It resolves to the tenant only
+// after VerifyDomain has found the verification token in DNS.
+func (s *DomainService) AddDomain(ctx context.Context, tenantID string, req models.TenantDomainCreateRequest) (*models.TenantDomainResponse, error) {
+	name := normalizeHost(req.Domain)
+	if !validHost(name) {
+		return nil, fmt.Errorf("%w: %q is not a host name such as app.example.com", ErrInvalidDomain, req.Domain)
+	}
+	if isPlatformHost(name) {
+		return nil, fmt.Errorf("%w: %s belongs to the platform", ErrInvalidDomain, name)
+	}
+
+	// Unverified domains are not exclusive, so nobody can block a domain by adding it first
+	if existing, err := s.store.Domains().GetVerified(ctx, name); err == nil {
+		if existing.TenantID != tenantID {
+			return nil, ErrDomainTaken
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to look up domain: %w", err)
+	}
+
+	token, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := models.TenantDomain{
+		ID:                uuid.New().String(),
+		TenantID:          tenantID,
+		Domain:            name,
+		VerificationToken: token,
+	}
+	if err := s.store.Domains().Create(ctx, &domain); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrDomainTaken
+		}
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+
+	response := domainResponse(&domain)
+	return &response, nil
+}
+
+// VerifyDomain checks the TXT record of the domain and, when it holds the
+// token, lets the domain resolve to the tenant
+func (s *DomainService) VerifyDomain(ctx context.Context, tenantID, id string) (*models.TenantDomainResponse, error) {
+	domain, err := s.getDomain(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.IsVerified() {
+		records, err := s.resolver.LookupTXT(ctx, domain.VerificationRecord())
+		if err != nil {
+			var dnsErr *net.DNSError
+			if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+				return nil, fmt.Errorf("failed to look up %s: %w", domain.VerificationRecord(), err)
+			}
+		}
+		if !slices.Contains(records, domain.VerificationToken) {
+			return nil, fmt.Errorf("%w: create a TXT record %s with the value %s", ErrDomainNotVerified, domain.VerificationRecord(), domain.VerificationToken)
+		}
+
+		now := time.Now()
+		if err := s.store.Domains().MarkVerified(ctx, tenantID, id, now); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return nil, ErrDomainTaken
+			}
+			return nil, fmt.Errorf("failed to verify domain: %w", err)
+		}
+		domain.VerifiedAt = &now
+		s.hosts.invalidate(domain.Domain)
+		s.unknown.invalidate(domain.Domain)
+	}
+
+	response := domainResponse(domain)
+	return &response, nil
+}
+
+// DeleteDomain removes a domain; it stops resolving to the tenant at once
+func (s *DomainService) DeleteDomain(ctx context.Context, tenantID, id string) error {
+	domain, err := s.getDomain(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Domains().Delete(ctx, tenantID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDomainNotFound
+		}
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+
+	s.hosts.invalidate(domain.Domain)
+	return nil
+}
+
+// ResolveHost returns the tenant that verified the host of a request, or an
+// empty string for the platform's own hosts and unknown ones. Hosts that
+// are not valid host names are not looked up.
+func (s *DomainService) ResolveHost(ctx context.Context, host string) (string, error) {
+	name := normalizeHost(host)
+	if name == "" || isPlatformHost(name) || !validHost(name) {
+		return "", nil
+	}
+
+	if tenantID, ok := s.hosts.get(name); ok {
+		return tenantID, nil
+	}
+	if _, ok := s.unknown.get(name); ok {
+		return "", nil
+	}
+
+	hostsGeneration, unknownGeneration := s.hosts.current(), s.unknown.current()
+	domain, err := s.store.Domains().GetVerified(repository.WithoutTenant(ctx), name)
+	switch {
+	case err == nil:
+		s.hosts.put(name, domain.TenantID, hostsGeneration)
+		return domain.TenantID, nil
+	case errors.Is(err, repository.ErrNotFound):
+		s.unknown.put(name, struct{}{}, unknownGeneration)
+		return "", nil
+	default:
+		return "", fmt.Errorf("failed to resolve host %s: %w", name, err)
+	}
+}
+
+// OriginAllowed reports whether a browser on origin may call the API: the
+// origin must be a verified domain, and of the same tenant when the request
+// itself was made to a tenant's domain
+func (s *DomainService) OriginAllowed(ctx context.Context, origin, hostTenantID string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+
+	tenantID, err := s.ResolveHost(ctx, u.Host)
+	if err != nil || tenantID == "" {
+		return false
+	}
+
+	return hostTenantID == "" || tenantID == hostTenantID
+}
+
+// GetBranding returns what the login page of the tenant's domain shows
+func (s *DomainService) GetBranding(ctx context.Context, tenantID string) (*models.PublicBranding, error) {
+	tenant, err := s.store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+	}
+
+	return &models.PublicBranding{Slug: tenant.Slug, BrandingSettings: settings.Branding}, nil
+}
+
+func (s *DomainService) getDomain(ctx context.Context, tenantID, id string) (*models.TenantDomain, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrDomainNotFound
+	}
+
+	domain, err := s.store.Domains().GetInTenant(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	return domain, nil
+}
+
+func domainResponse(domain *models.TenantDomain) models.TenantDomainResponse {
+	return models.TenantDomainResponse{TenantDomain: *domain, VerificationRecord: domain.VerificationRecord()}
+}
+
+// normalizeHost lowercases a host and strips the port and the trailing dot
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// validHost reports whether a normalized host is a host name a tenant could verify
+func validHost(host string) bool {
+	return len(host) <= 253 && domainRe.MatchString(host)
+}
+
+// isPlatformHost reports whether host serves the platform itself and so
+// cannot belong to a tenant
+func isPlatformHost(host string) bool {
+	if host == "localhost" || net.ParseIP(host) != nil {
+		return true
+	}
+
+	if u, err := url.Parse(viper.GetString("app_url")); err == nil && normalizeHost(u.Host) == host {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"franchise-saas-backend/internal/repository"
+)
+
+func TestResolveHostBoundsUnknownHosts(t *testing.T) {
+	service := NewDomainService(repository.NewMemoryStore(), nil)
+	ctx := context.Background()
+
+	for _, host := range []string{"bad_host", "-x-.example.com", "a..b", "example"} {
+		if tenantID, err := service.ResolveHost(ctx, host); err != nil || tenantID != "" {
+			t.Errorf("ResolveHost(%q) = %q, %v", host, tenantID, err)
+		}
+	}
+	if n := len(service.unknown.entries); n != 0 {
+		t.Errorf("%d invalid hosts cached", n)
+	}
+
+	for i := range 2 * unknownHostCacheSize {
+		if _, err := service.ResolveHost(ctx, fmt.Sprintf("h%d.example.com", i)); err != nil {
+			t.Fatalf("ResolveHost: %v", err)
+		}
+	}
+	if n := len(service.unknown.entries); n > unknownHostCacheSize {
+		t.Errorf("%d unknown hosts cached, want at most %d", n, unknownHostCacheSize)
+	}
+	if n := len(service.hosts.entries); n != 0 {
+		t.Errorf("%d unknown hosts cached as verified", n)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+)
+
+var (
+	// ErrHolidayNotFound is returned when the date is not in the holiday calendar
+	ErrHolidayNotFound = errors.New("holiday not found")
+	// ErrHolidayExists is returned when the date already is a holiday
+	ErrHolidayExists = errors.New("the date already is a holiday")
+	// ErrInvalidHoliday is returned for dates that cannot be parsed
+	ErrInvalidHoliday = errors.New("invalid holiday")
+)
+
+// HolidayService manages the holiday calendars of tenants; the checklist
+// scheduler skips their days
+type HolidayService struct {
+	store repository.Store
+}
+
+func NewHolidayService(store repository.Store) *HolidayService {
+	return &HolidayService{store: store}
+}
+
+// ListHolidays returns the holidays of the tenant in the year
+func (s *HolidayService) ListHolidays(ctx context.Context, tenantID string, year int) ([]models.Holiday, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	holidays, err := s.store.Holidays().ListByTenant(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holidays: %w", err)
+	}
+	return holidays, nil
+}
+
+// AddHoliday adds a date to the holiday calendar of the tenant
+func (s *HolidayService) AddHoliday(ctx context.Context, tenantID string, req models.HolidayRequest) (*models.Holiday, error) {
+	date, err := parseHolidayDate(req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	holiday := &models.Holiday{
+		TenantID: tenantID,
+		Date:     date,
+		Name:     strings.TrimSpace(req.Name),
+	}
+	if err := s.store.Holidays().Create(ctx, holiday); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrHolidayExists
+		}
+		return nil, fmt.Errorf("failed to add holiday: %w", err)
+	}
+
+	return holiday, nil
+}
+
+// DeleteHoliday makes the date a working day again
+func (s *HolidayService) DeleteHoliday(ctx context.Context, tenantID, date string) error {
+	day, err := parseHolidayDate(date)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Holidays().Delete(ctx, tenantID, day); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrHolidayNotFound
+		}
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	return nil
+}
+
+// parseHolidayDate parses a YYYY-MM-DD date
+func parseHolidayDate(date string) (time.Time, error) {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: the date must be YYYY-MM-DD", ErrInvalidHoliday)
+	}
+	return day, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/tokens"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// ErrImpersonationForbidden is returned when the target user may not be impersonated
+var ErrImpersonationForbidden = errors.New("superadmins cannot be impersonated")
+
+// Impersonate issues a superadmin a short-lived access token acting as the
+// user, for support. The token carries the superadmin in its act claim, has
+// no refresh token and no session, and stops working when either side
+// changes their password or role. Issuing it is recorded in the audit log.
+func (s *AuthService) Impersonate(ctx context.Context, actorID string, req models.ImpersonationRequest, client ClientInfo) (*models.ImpersonationResponse, error) {
+	actor, err := s.GetUserByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ID == actor.ID || user.Role == models.RoleSuperadmin {
+		return nil, ErrImpersonationForbidden
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	if _, err := activeTenant(ctx, s.store, user.TenantID); err != nil {
+		return nil, err
+	}
+
+	ttl := impersonationTTL()
+	token, err := s.issuer.IssueImpersonation(tokens.Claims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		TenantID:     user.TenantID,
+		TokenVersion: user.TokenVersion,
+		Actor: &tokens.Actor{
+			UserID:       actor.ID,
+			Email:        actor.Email,
+			TokenVersion: actor.TokenVersion,
+		},
+	}, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	entry := &models.AuditEntry{
+		ID:        uuid.New().String(),
+		ActorID:   actor.ID,
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		Action:    models.AuditActionImpersonationStarted,
+		Reason:    req.Reason,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+	}
+	if err := s.store.Audit().Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to record impersonation: %w", err)
+	}
+
+	user.Password = ""
+
+	return &models.ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl),
+		User:      *user,
+	}, nil
+}
+
+// impersonationTTL returns how long an impersonation token stays valid
+func impersonationTTL() time.Duration {
+	minutes := viper.GetInt("impersonation_ttl_minutes")
+	if minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
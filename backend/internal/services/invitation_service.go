@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/mailer"
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvitationNotFound is returned when the invitation does not exist in the caller's tenant or is closed
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationExists is returned when the email already has a pending invitation
+	ErrInvitationExists = errors.New("an invitation for this email is already pending")
+	// ErrInvalidInvitation is returned for unknown, expired, revoked or accepted invitation links
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	// ErrInvalidManager is returned when the manager is not a manager of the tenant or the invitee is not a dealer
+	ErrInvalidManager = errors.New("manager must be a manager of this tenant and can only be set for dealers")
+)
+
+// InvitationService lets franchisers invite dealers and managers into their tenant
+type InvitationService struct {
+	store  repository.Store
+	mailer mailer.Mailer
+	roles  *RoleService
+}
+
+func NewInvitationService(store repository.Store, mailer mailer.Mailer, roles *RoleService) *InvitationService {
+	return &InvitationService{store: store, mailer: mailer, roles: roles}
+}
+
+// CreateInvitation stores an invitation and emails the link to the invitee.
+// The inviter's role must hold every permission of the invited role. An
+// expired invitation for the same email is revoked and replaced.
+func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID, inviterID, inviterRole string, req models.InvitationCreateRequest, lang string) (*models.Invitation, error) {
+	email := normalizeEmail(req.Email)
+
+	if err := checkAssignableRole(ctx, s.roles, tenantID, req.Role, inviterRole); err != nil {
+		return nil, err
+	}
+
+	if req.ManagerID != "" {
+		dealer, err := s.roles.IsDealerRole(ctx, tenantID, req.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !dealer {
+			return nil, ErrInvalidManager
+		}
+		if err := checkManager(ctx, s.store, s.roles, tenantID, req.ManagerID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Emails are unique across tenants, so the check has to see all of them
+	if _, err := s.store.Users().GetByEmail(repository.WithoutTenant(ctx), email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Email:     email,
+		Role:      req.Role,
+		ManagerID: req.ManagerID,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		InvitedBy: inviterID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(invitationTTL()),
+	}
+
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := checkSeat(ctx, tx, tenantID, req.Role, true); err != nil {
+			return err
+		}
+		return storeInvitation(ctx, tx, invitation)
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvitationExists) || errors.Is(err, ErrUserLimitReached) || errors.Is(err, ErrDealerLimitReached) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	s.sendInvitation(ctx, invitation, token, inviterID, lang)
+
+	invitation.Status = invitation.CurrentStatus(time.Now())
+	return invitation, nil
+}
+
+// ListInvitations returns the pending and expired invitations of the tenant
+func (s *InvitationService) ListInvitations(ctx context.Context, tenantID string) ([]models.Invitation, error) {
+	invitations, err := s.store.Invitations().ListOpen(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitations[i].CurrentStatus(now)
+	}
+
+	return invitations, nil
+}
+
+// ResendInvitation issues a new link with a fresh expiry; the previous link
+// stops working. Like creating one, it requires the permissions of the
+// invited role.
+func (s *InvitationService) ResendInvitation(ctx context.Context, tenantID, inviterID, inviterRole, invitationID, lang string) (*models.Invitation, error) {
+	if _, err := uuid.Parse(invitationID); err != nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	invitation, err := s.store.Invitations().GetByID(ctx, tenantID, invitationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	if !invitation.IsOpen() {
+		return nil, ErrInvitationNotFound
+	}
+
+	if err := checkAssignableRole(ctx, s.roles, tenantID, invitation.Role, inviterRole); err != nil {
+		return nil, err
+	}
+
+	// An expired invitation gave up its seat and has to fit in again
+	if invitation.CurrentStatus(time.Now()) == models.InvitationExpired {
+		err := s.store.WithTx(ctx, func(tx repository.Store) error {
+			return checkSeat(ctx, tx, tenantID, invitation.Role, true)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.TokenHash = tokenHash
+	invitation.ExpiresAt = time.Now().Add(invitationTTL())
+
+	if err := s.store.Invitations().UpdateToken(ctx, invitation); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	s.sendInvitation(ctx, invitation, token, inviterID, lang)
+
+	invitation.Status = invitation.CurrentStatus(time.Now())
+	return invitation, nil
+}
+
+// RevokeInvitation closes an invitation so that its link can no longer be used
+func (s *InvitationService) RevokeInvitation(ctx context.Context, tenantID, invitationID string) error {
+	if _, err := uuid.Parse(invitationID); err != nil {
+		return ErrInvitationNotFound
+	}
+
+	if err := s.store.Invitations().Revoke(ctx, tenantID, invitationID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvitationNotFound
+		}
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return nil
+}
+
+// storeInvitation saves a new invitation, replacing an expired one for the
+// same email. It must run inside a transaction.
+func storeInvitation(ctx context.Context, tx repository.Store, invitation *models.Invitation) error {
+	existing, err := tx.Invitations().GetOpenByEmail(ctx, invitation.TenantID, invitation.Email)
+	switch {
+	case err == nil:
+		if existing.CurrentStatus(time.Now()) != models.InvitationExpired {
+			return ErrInvitationExists
+		}
+		if err := tx.Invitations().Revoke(ctx, invitation.TenantID, existing.ID); err != nil {
+			return err
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return err
+	}
+
+	if err := tx.Invitations().Create(ctx, invitation); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrInvitationExists
+		}
+		return err
+	}
+	return nil
+}
+
+// sendInvitation emails the link. The tenant and inviter names are only used
+// to personalise the message, so lookup failures are logged.
+func (s *InvitationService) sendInvitation(ctx context.Context, invitation *models.Invitation, token, inviterID, lang string) {
+	tenantName := ""
+	if tenant, err := s.store.Tenants().GetByID(ctx, invitation.TenantID); err == nil {
+		tenantName = tenant.Name
+	} else {
+		log.Printf("failed to load tenant %s for invitation %s: %v", invitation.TenantID, invitation.ID, err)
+	}
+
+	inviterName := ""
+	if inviter, err := s.store.Users().GetByID(ctx, inviterID); err == nil {
+		inviterName = strings.TrimSpace(inviter.FirstName + " " + inviter.LastName)
+	}
+
+	sendMail(ctx, s.mailer, mailer.TemplateInvitation, lang, invitation.Email, map[string]any{
+		"Name":        invitation.FirstName,
+		"TenantName":  tenantName,
+		"InviterName": inviterName,
+		"Role":        invitation.Role,
+		"Link":        appLink("/accept-invitation", token),
+		"Days":        int(invitationTTL().Hours() / 24),
+	})
+}
+
+// AcceptInvitation creates the invited user in the inviter's tenant and signs them in
+func (s *AuthService) AcceptInvitation(ctx context.Context, req *models.InvitationAcceptRequest, client ClientInfo) (*models.AuthResponse, error) {
+	var result *models.AuthResponse
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		invitation, err := tx.Invitations().GetByTokenHash(ctx, hashToken(req.Token))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		if invitation.CurrentStatus(time.Now()) != models.InvitationPending {
+			return ErrInvalidInvitation
+		}
+
+		// The limits may have been lowered since the invitation was sent
+		if err := checkSeat(ctx, tx, invitation.TenantID, invitation.Role, false); err != nil {
+			return err
+		}
+
+		if err := s.policy.Validate(req.Password, invitation.Email); err != nil {
+			return fmt.Errorf("%w: %v", ErrWeakPassword, err)
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		// The manager may have changed role since the invitation was sent
+		managerID := invitation.ManagerID
+		if managerID != "" {
+			if err := checkManager(ctx, tx, NewRoleService(tx), invitation.TenantID, managerID); errors.Is(err, ErrInvalidManager) {
+				managerID = ""
+			} else if err != nil {
+				return err
+			}
+		}
+
+		user := &models.User{
+			ID:        uuid.New().String(),
+			Email:     invitation.Email,
+			Password:  string(hashedPassword),
+			Role:      invitation.Role,
+			TenantID:  invitation.TenantID,
+			ManagerID: managerID,
+			FirstName: firstNonEmpty(req.FirstName, invitation.FirstName),
+			LastName:  firstNonEmpty(req.LastName, invitation.LastName),
+			Phone:     req.Phone,
+			City:      strings.TrimSpace(req.City),
+			IsActive:  true,
+			// The link was delivered to this address
+			EmailVerified: true,
+		}
+
+		if err := tx.Users().Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return ErrEmailTaken
+			}
+			return err
+		}
+
+		if err := tx.Invitations().MarkAccepted(ctx, invitation.ID, user.ID); err != nil {
+			return err
+		}
+
+		result, err = s.authResponse(ctx, tx, user, client)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidInvitation) || errors.Is(err, ErrWeakPassword) || errors.Is(err, ErrEmailTaken) || errors.Is(err, ErrTenantSuspended) ||
+			errors.Is(err, ErrUserLimitReached) || errors.Is(err, ErrDealerLimitReached) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return result, nil
+}
+
+// checkManager returns ErrInvalidManager unless managerID is an active manager of the tenant
+func checkManager(ctx context.Context, store repository.Store, roles *RoleService, tenantID, managerID string) error {
+	if _, err := uuid.Parse(managerID); err != nil {
+		return ErrInvalidManager
+	}
+
+	manager, err := store.Users().GetInTenant(ctx, tenantID, managerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidManager
+		}
+		return fmt.Errorf("failed to get manager: %w", err)
+	}
+
+	if !manager.IsActive {
+		return ErrInvalidManager
+	}
+
+	supervises, err := roles.HasPermission(ctx, tenantID, manager.Role, models.PermissionViewAssignedDealers)
+	if err != nil {
+		return err
+	}
+	if !supervises {
+		return ErrInvalidManager
+	}
+	return nil
+}
+
+// invitationTTL returns how long an invitation link stays valid
+func invitationTTL() time.Duration {
+	days := viper.GetInt("invitation_expiration_days")
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
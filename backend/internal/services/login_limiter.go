@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/ratelimit"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// ErrTooManyAttempts is matched by ThrottledError when sign-in is temporarily blocked
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// ThrottledError is returned while an account or IP address is blocked
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// LoginLimiter throttles failed sign-ins per account and per IP address,
+// and password reset requests the same way
+type LoginLimiter struct {
+	account *ratelimit.Limiter
+	ip      *ratelimit.Limiter
+
+	resetAccount *ratelimit.Limiter
+	resetIP      *ratelimit.Limiter
+}
+
+// NewLoginLimiter creates a limiter with the login_* and password_reset_*
+// policies from the configuration
+func NewLoginLimiter(store ratelimit.Store) *LoginLimiter {
+	return &LoginLimiter{
+		account:      ratelimit.NewLimiter(store, "login:account", loginPolicy("login")),
+		ip:           ratelimit.NewLimiter(store, "login:ip", loginPolicy("login_ip")),
+		resetAccount: ratelimit.NewLimiter(store, "reset:account", loginPolicy("password_reset")),
+		resetIP:      ratelimit.NewLimiter(store, "reset:ip", loginPolicy("password_reset_ip")),
+	}
+}
+
+// loginPolicy reads the <prefix>_* settings
+func loginPolicy(prefix string) ratelimit.Policy {
+	return ratelimit.Policy{
+		FreeAttempts: viper.GetInt(prefix + "_free_attempts"),
+		BaseDelay:    time.Duration(viper.GetInt(prefix+"_base_delay_seconds")) * time.Second,
+		MaxAttempts:  viper.GetInt(prefix + "_max_attempts"),
+		Lockout:      time.Duration(viper.GetInt(prefix+"_lockout_minutes")) * time.Minute,
+		Window:       time.Duration(viper.GetInt(prefix+"_window_minutes")) * time.Minute,
+	}
+}
+
+// check returns a ThrottledError when the account or the IP address is blocked.
+// Store failures are logged and let the attempt through.
+func (l *LoginLimiter) check(ctx context.Context, email, ip string) error {
+	return checkLimiters(ctx, l.account, l.ip, email, ip)
+}
+
+// fail counts a failed attempt against the account and the IP address
+func (l *LoginLimiter) fail(ctx context.Context, email, ip string) {
+	failLimiters(ctx, l.account, l.ip, email, ip)
+}
+
+// requestPasswordReset counts a password reset request against the email and
+// the IP address, whether or not the email is registered, and returns a
+// ThrottledError while either is blocked
+func (l *LoginLimiter) requestPasswordReset(ctx context.Context, email, ip string) error {
+	if err := checkLimiters(ctx, l.resetAccount, l.resetIP, email, ip); err != nil {
+		return err
+	}
+
+	failLimiters(ctx, l.resetAccount, l.resetIP, email, ip)
+	return nil
+}
+
+// checkLimiters returns a ThrottledError when the account or the IP address
+// is blocked by its limiter
+func checkLimiters(ctx context.Context, account, byIP *ratelimit.Limiter, email, ip string) error {
+	wait, err := account.Blocked(ctx, email)
+	if err != nil {
+		log.Printf("login limiter: failed to check account: %v", err)
+	}
+
+	if ip != "" {
+		ipWait, err := byIP.Blocked(ctx, ip)
+		if err != nil {
+			log.Printf("login limiter: failed to check ip: %v", err)
+		}
+		wait = max(wait, ipWait)
+	}
+
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// failLimiters counts a failure against the account and the IP address
+func failLimiters(ctx context.Context, account, byIP *ratelimit.Limiter, email, ip string) {
+	if _, err := account.Fail(ctx, email); err != nil {
+		log.Printf("login limiter: failed to count account failure: %v", err)
+	}
+	if ip == "" {
+		return
+	}
+	if _, err := byIP.Fail(ctx, ip); err != nil {
+		log.Printf("login limiter: failed to count ip failure: %v", err)
+	}
+}
+
+// succeed forgets the failures of the account. IP failures are kept so that
+// one valid account does not unlock guessing against others.
+func (l *LoginLimiter) succeed(ctx context.Context, email string) {
+	if err := l.account.Reset(ctx, email); err != nil {
+		log.Printf("login limiter: failed to reset account: %v", err)
+	}
+}
+
+// recordLoginAttempt stores the attempt; failures are only logged so that
+// the audit trail never blocks signing in
+func (s *AuthService) recordLoginAttempt(ctx context.Context, email string, user *models.User, client ClientInfo, failureReason string) {
+	attempt := &models.LoginAttempt{
+		ID:            uuid.New().String(),
+		Email:         email,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		Success:       failureReason == "",
+		FailureReason: failureReason,
+	}
+	if user != nil {
+		attempt.UserID = user.ID
+	}
+
+	if err := s.store.LoginAttempts().Create(ctx, attempt); err != nil {
+		log.Printf("failed to record login attempt for %s: %v", email, err)
+	}
+}
+
+// loginFailed records a failed attempt and counts it towards throttling
+func (s *AuthService) loginFailed(ctx context.Context, email string, user *models.User, client ClientInfo, reason string) {
+	s.recordLoginAttempt(ctx, email, user, client, reason)
+	s.limiter.fail(ctx, email, client.IPAddress)
+}
+
+// loginSucceeded records a completed sign-in and clears the account failures
+func (s *AuthService) loginSucceeded(ctx context.Context, user *models.User, client ClientInfo) {
+	s.recordLoginAttempt(ctx, user.Email, user, client, "")
+	s.limiter.succeed(ctx, user.Email)
+}
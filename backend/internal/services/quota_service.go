@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/ratelimit"
+	"franchise-saas-backend/internal/repository"
+)
+
+var (
+	// ErrUserLimitReached is returned when a new user would exceed the tenant's user limit
+	ErrUserLimitReached = errors.New("the user limit of the plan is reached")
+	// ErrDealerLimitReached is returned when a new dealer would exceed the tenant's dealer limit
+	ErrDealerLimitReached = errors.New("the dealer limit of the plan is reached")
+)
+
+// apiCallWindow keeps the counter of a month until the month is over
+const apiCallWindow = 32 * 24 * time.Hour
+
+// QuotaService measures the consumption of tenants against the limits in
+// their settings. API calls are counted per calendar month (UTC) in the
+// counter store shared with the login limiter. Storage is reported but not
+// enforced: nothing uploads files yet.
+type QuotaService struct {
+	store    repository.Store
+	counters ratelimit.Store
+	limits   *ttlCache[models.TenantLimits] // limits of each tenant, read by every API call
+}
+
+func NewQuotaService(store repository.Store, counters ratelimit.Store) *QuotaService {
+	return &QuotaService{store: store, counters: counters, limits: newTTLCache[models.TenantLimits](cacheTTL("quota_cache_seconds"), cacheSize)}
+}
+
+// GetUsage returns the current consumption of the tenant against each limit
+func (s *QuotaService) GetUsage(ctx context.Context, tenantID string) (*models.TenantUsage, error) {
+	tenant, limits, err := tenantLimits(ctx, s.store, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	seats, err := countSeats(ctx, s.store, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := s.store.Tenants().GetStorageUsed(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	period := apiCallPeriod(time.Now())
+	calls, err := s.counters.Count(ctx, apiCallKey(tenantID, period))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API call count: %w", err)
+	}
+
+	return &models.TenantUsage{
+		Plan:     tenant.Plan,
+		Period:   period,
+		Dealers:  models.QuotaUsage{Used: seats.dealers, Pending: seats.pendingDealers, Limit: int64(limits.MaxDealers)},
+		Users:    models.QuotaUsage{Used: seats.users, Pending: seats.pendingUsers, Limit: int64(limits.MaxUsers)},
+		Storage:  models.QuotaUsage{Used: storage, Limit: storageLimit(limits)},
+		APICalls: models.QuotaUsage{Used: calls, Limit: int64(limits.APICallsPerMonth)},
+	}, nil
+}
+
+// CountAPICall records an API call of the tenant. It returns the calls made
+// this month, including this one, and the monthly limit.
+func (s *QuotaService) CountAPICall(ctx context.Context, tenantID string) (int64, int64, error) {
+	limits, err := s.cachedLimits(ctx, tenantID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	used, err := s.counters.Incr(ctx, apiCallKey(tenantID, apiCallPeriod(time.Now())), apiCallWindow)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count API call: %w", err)
+	}
+
+	return used, int64(limits.APICallsPerMonth), nil
+}
+
+// cachedLimits returns the limits of the tenant without loading the tenant on
+// every request
+func (s *QuotaService) cachedLimits(ctx context.Context, tenantID string) (models.TenantLimits, error) {
+	if limits, ok := s.limits.get(tenantID); ok {
+		return limits, nil
+	}
+
+	generation := s.limits.current()
+	_, limits, err := tenantLimits(ctx, s.store, tenantID)
+	if err != nil {
+		return limits, err
+	}
+
+	s.limits.put(tenantID, limits, generation)
+	return limits, nil
+}
+
+// forgetLimits drops the cached limits of a tenant whose settings changed
+func (s *QuotaService) forgetLimits(tenantID string) {
+	s.limits.invalidate(tenantID)
+}
+
+func tenantLimits(ctx context.Context, store repository.Store, tenantID string) (*models.Tenant, models.TenantLimits, error) {
+	tenant, err := store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, models.TenantLimits{}, ErrTenantNotFound
+		}
+		return nil, models.TenantLimits{}, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	limits, err := limitsOf(tenant)
+	return tenant, limits, err
+}
+
+func limitsOf(tenant *models.Tenant) (models.TenantLimits, error) {
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return models.TenantLimits{}, fmt.Errorf("failed to parse tenant settings: %w", err)
+	}
+
+	return settings.Limits, nil
+}
+
+// seatCounts is the number of active users of a tenant and of the pending
+// invitations that will add users
+type seatCounts struct {
+	users, dealers               int64
+	pendingUsers, pendingDealers int64
+}
+
+func countSeats(ctx context.Context, store repository.Store, tenantID string) (seatCounts, error) {
+	var seats seatCounts
+
+	// Custom roles count as dealers when their permissions make them dealers
+	dealerRoles, err := NewRoleService(store).DealerRoles(ctx, tenantID)
+	if err != nil {
+		return seats, err
+	}
+
+	counts, err := store.Users().CountActiveByRole(ctx, tenantID)
+	if err != nil {
+		return seats, fmt.Errorf("failed to count users: %w", err)
+	}
+	for role, count := range counts {
+		seats.users += int64(count)
+		if slices.Contains(dealerRoles, role) {
+			seats.dealers += int64(count)
+		}
+	}
+
+	invitations, err := store.Invitations().ListOpen(ctx, tenantID)
+	if err != nil {
+		return seats, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	now := time.Now()
+	for _, invitation := range invitations {
+		if invitation.CurrentStatus(now) != models.InvitationPending {
+			continue
+		}
+		seats.pendingUsers++
+		if slices.Contains(dealerRoles, invitation.Role) {
+			seats.pendingDealers++
+		}
+	}
+
+	return seats, nil
+}
+
+// checkSeat returns ErrUserLimitReached or ErrDealerLimitReached when one
+// more user with the role does not fit into the tenant's limits. Pending
+// invitations hold their seats when countPending is set. It must run inside
+// a transaction: the tenant is locked so that concurrent requests cannot
+// take the same seat.
+func checkSeat(ctx context.Context, tx repository.Store, tenantID, role string, countPending bool) error {
+	tenant, err := tx.Tenants().GetForUpdate(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	limits, err := limitsOf(tenant)
+	if err != nil {
+		return err
+	}
+
+	seats, err := countSeats(ctx, tx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	users, dealers := seats.users, seats.dealers
+	if countPending {
+		users += seats.pendingUsers
+		dealers += seats.pendingDealers
+	}
+
+	if users >= int64(limits.MaxUsers) {
+		return ErrUserLimitReached
+	}
+	if dealers < int64(limits.MaxDealers) {
+		return nil
+	}
+	isDealer, err := NewRoleService(tx).IsDealerRole(ctx, tenantID, role)
+	if err != nil {
+		return err
+	}
+	if isDealer {
+		return ErrDealerLimitReached
+	}
+
+	return nil
+}
+
+// apiCallPeriod returns the month API calls are counted for, YYYY-MM in UTC
+func apiCallPeriod(now time.Time) string {
+	return now.UTC().Format("2006-01")
+}
+
+func apiCallKey(tenantID, period string) string {
+	return "quota:api:" + tenantID + ":" + period
+}
+
+func storageLimit(limits models.TenantLimits) int64 {
+	return int64(limits.StorageGB) << 30
+}
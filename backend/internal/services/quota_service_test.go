@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/ratelimit"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestCountAPICallCachesLimits(t *testing.T) {
+	store := repository.NewMemoryStore()
+	quotas := NewQuotaService(store, ratelimit.NewMemoryStore())
+	tenants := NewTenantService(store, nil, nil, quotas)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{ID: uuid.NewString(), Name: "Network", Slug: "network", Plan: models.PlanStart}
+	if err := store.Tenants().Create(ctx, tenant); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	withLimit := func(calls int) json.RawMessage {
+		settings := models.DefaultTenantSettings(models.PlanStart, tenant.Name)
+		settings.Limits.APICallsPerMonth = calls
+		raw, err := json.Marshal(settings)
+		if err != nil {
+			t.Fatalf("encode settings: %v", err)
+		}
+		return raw
+	}
+	expectLimit := func(want int64) {
+		t.Helper()
+		if _, limit, err := quotas.CountAPICall(ctx, tenant.ID); err != nil || limit != want {
+			t.Fatalf("CountAPICall: limit %d, %v; want %d", limit, err, want)
+		}
+	}
+
+	expectLimit(int64(models.DefaultTenantSettings(models.PlanStart, tenant.Name).Limits.APICallsPerMonth))
+
+	// Writes that bypass the service are only seen once the entry expires
+	tenant.Settings = withLimit(5)
+	if err := store.Tenants().Update(ctx, tenant); err != nil {
+		t.Fatalf("update tenant: %v", err)
+	}
+	expectLimit(int64(models.DefaultTenantSettings(models.PlanStart, tenant.Name).Limits.APICallsPerMonth))
+
+	if _, err := tenants.AdminUpdateTenant(ctx, tenant.ID, models.TenantAdminUpdateRequest{Settings: withLimit(7)}); err != nil {
+		t.Fatalf("AdminUpdateTenant: %v", err)
+	}
+	expectLimit(7)
+}
+
+func TestCustomDealerRolesTakeDealerSeats(t *testing.T) {
+	store := repository.NewMemoryStore()
+	quotas := NewQuotaService(store, ratelimit.NewMemoryStore())
+	ctx := context.Background()
+
+	settings := models.DefaultTenantSettings(models.PlanStart, "Network")
+	settings.Limits.MaxDealers = 1
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		t.Fatalf("encode settings: %v", err)
+	}
+	tenant := &models.Tenant{ID: uuid.NewString(), Name: "Network", Slug: "network", Plan: models.PlanStart, Settings: raw}
+	if err := store.Tenants().Create(ctx, tenant); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	roles := append(models.DefaultRoles(tenant.ID), models.Role{TenantID: tenant.ID, Name: "outlet", Permissions: []string{models.PermissionManageChecklists}})
+	for i := range roles {
+		roles[i].ID = uuid.NewString()
+		if err := store.Roles().Create(ctx, &roles[i]); err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+	}
+	outlet := &models.User{ID: uuid.NewString(), Email: "outlet@example.com", Role: "outlet", TenantID: tenant.ID, IsActive: true}
+	if err := store.Users().Create(ctx, outlet); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	usage, err := quotas.GetUsage(ctx, tenant.ID)
+	if err != nil || usage.Dealers.Used != 1 {
+		t.Fatalf("GetUsage: dealers %+v, %v; want 1 used", usage, err)
+	}
+
+	err = store.WithTx(ctx, func(tx repository.Store) error {
+		for _, role := range []string{"outlet", models.RoleDealer} {
+			if err := checkSeat(ctx, tx, tenant.ID, role, true); !errors.Is(err, ErrDealerLimitReached) {
+				t.Errorf("checkSeat(%s): got %v, want ErrDealerLimitReached", role, err)
+			}
+		}
+		if err := checkSeat(ctx, tx, tenant.ID, models.RoleManager, true); err != nil {
+			t.Errorf("checkSeat(manager): %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+}
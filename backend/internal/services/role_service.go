@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrRoleNotFound is returned when the role does not exist in the caller's tenant
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when the tenant already has a role with the name
+	ErrRoleExists = errors.New("a role with this name already exists")
+	// ErrInvalidRole is returned for malformed or reserved role names, unknown
+	// permissions and roles that cannot be assigned
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrRoleBuiltIn is returned when deleting a built-in role or changing the franchiser role
+	ErrRoleBuiltIn = errors.New("built-in roles cannot be deleted and the franchiser role cannot be changed")
+	// ErrRoleInUse is returned when deleting a role that users or open invitations still have
+	ErrRoleInUse = errors.New("role is assigned to users or open invitations")
+	// ErrRoleNotHeld is returned when a role would be given to someone by a
+	// user whose own role lacks some of its permissions
+	ErrRoleNotHeld = errors.New("a role cannot be assigned with permissions the assigner does not hold")
+)
+
+// RoleService manages the roles of a tenant and resolves the permissions of users
+type RoleService struct {
+	store repository.Store
+}
+
+func NewRoleService(store repository.Store) *RoleService {
+	return &RoleService{store: store}
+}
+
+// ListPermissions returns the permission catalogue
+func (s *RoleService) ListPermissions() []models.Permission {
+	return models.PermissionCatalogue
+}
+
+// ListRoles returns the roles of the tenant, built-in roles first
+func (s *RoleService) ListRoles(ctx context.Context, tenantID string) ([]models.Role, error) {
+	roles, err := s.store.Roles().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// CreateRole defines a custom role in the tenant
+func (s *RoleService) CreateRole(ctx context.Context, tenantID string, req models.RoleCreateRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !models.IsValidRoleName(name) || name == models.RoleSuperadmin || name == models.RoleAPIKey {
+		return nil, fmt.Errorf("%w: name must be 2-50 lowercase latin letters, digits or underscores, starting with a letter", ErrInvalidRole)
+	}
+
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+	}
+
+	if err := s.store.Roles().Create(ctx, role); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrRoleExists
+		}
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return role, nil
+}
+
+// UpdateRole changes the description or permissions of a role. The manager
+// and dealer roles can be changed too; the franchiser role always keeps every
+// permission so that a tenant cannot lock itself out.
+func (s *RoleService) UpdateRole(ctx context.Context, tenantID, roleID string, req models.RoleUpdateRequest) (*models.Role, error) {
+	role, err := s.getRole(ctx, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Name == models.RoleFranchiser {
+		return nil, ErrRoleBuiltIn
+	}
+
+	if req.Description != nil {
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Permissions != nil {
+		if role.Permissions, err = normalizePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.store.Roles().Update(ctx, role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return role, nil
+}
+
+// DeleteRole removes a custom role that nobody has
+func (s *RoleService) DeleteRole(ctx context.Context, tenantID, roleID string) error {
+	role, err := s.getRole(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+
+	if role.BuiltIn {
+		return ErrRoleBuiltIn
+	}
+
+	inUse, err := s.store.Roles().IsInUse(ctx, tenantID, role.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check role usage: %w", err)
+	}
+	if inUse {
+		return ErrRoleInUse
+	}
+
+	if err := s.store.Roles().Delete(ctx, tenantID, role.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrRoleNotFound
+		}
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	return nil
+}
+
+// ResolvePermissions returns the effective permissions of a role in the
+// tenant. A superadmin holds every permission, the franchiser every tenant
+// permission; other roles get what their tenant granted them. An unknown role
+// has no permissions.
+func (s *RoleService) ResolvePermissions(ctx context.Context, tenantID, roleName string) ([]string, error) {
+	switch roleName {
+	case models.RoleSuperadmin:
+		return append(models.AllTenantPermissions(), models.PermissionManagePlatform), nil
+	case models.RoleFranchiser:
+		return models.AllTenantPermissions(), nil
+	}
+
+	role, err := s.store.Roles().GetByName(ctx, tenantID, roleName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return role.Permissions, nil
+}
+
+// HasPermission reports whether a role of the tenant holds the permission
+func (s *RoleService) HasPermission(ctx context.Context, tenantID, roleName, permission string) (bool, error) {
+	permissions, err := s.ResolvePermissions(ctx, tenantID, roleName)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
+
+// IsDealerRole reports whether users with the role are dealers: they fill in
+// checklists and see no dealers but themselves
+func (s *RoleService) IsDealerRole(ctx context.Context, tenantID, roleName string) (bool, error) {
+	permissions, err := s.ResolvePermissions(ctx, tenantID, roleName)
+	if err != nil {
+		return false, err
+	}
+
+	return dealerPermissions(permissions), nil
+}
+
+// DealerRoles returns the names of the tenant's roles whose users are dealers
+func (s *RoleService) DealerRoles(ctx context.Context, tenantID string) ([]string, error) {
+	roles, err := s.store.Roles().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	names := []string{}
+	for _, role := range roles {
+		if role.Name != models.RoleFranchiser && dealerPermissions(role.Permissions) {
+			names = append(names, role.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// dealerPermissions reports whether a role with the permissions is a dealer role
+func dealerPermissions(permissions []string) bool {
+	return slices.Contains(permissions, models.PermissionManageChecklists) &&
+		!slices.Contains(permissions, models.PermissionViewAssignedDealers) &&
+		!slices.Contains(permissions, models.PermissionViewAllDealers)
+}
+
+func (s *RoleService) getRole(ctx context.Context, tenantID, roleID string) (*models.Role, error) {
+	if _, err := uuid.Parse(roleID); err != nil {
+		return nil, ErrRoleNotFound
+	}
+
+	role, err := s.store.Roles().GetByID(ctx, tenantID, roleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return role, nil
+}
+
+// normalizePermissions rejects permissions outside the catalogue and drops duplicates
+func normalizePermissions(permissions []string) ([]string, error) {
+	normalized := []string{}
+	for _, permission := range permissions {
+		if !models.IsKnownPermission(permission) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidRole, permission)
+		}
+		if !slices.Contains(normalized, permission) {
+			normalized = append(normalized, permission)
+		}
+	}
+
+	return normalized, nil
+}
+
+// checkAssignableRole returns ErrInvalidRole unless the role exists in the
+// tenant and may be given through an invitation; franchisers are only
+// invited when a superadmin creates a tenant. The assigner's role must hold
+// every permission of the role, otherwise ErrRoleNotHeld is returned.
+func checkAssignableRole(ctx context.Context, roles *RoleService, tenantID, roleName, assignerRole string) error {
+	if roleName == models.RoleFranchiser || roleName == models.RoleSuperadmin {
+		return fmt.Errorf("%w: %s cannot be assigned by invitation", ErrInvalidRole, roleName)
+	}
+
+	role, err := roles.store.Roles().GetByName(ctx, tenantID, roleName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: the tenant has no role %q", ErrInvalidRole, roleName)
+		}
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+
+	held, err := roles.ResolvePermissions(ctx, tenantID, assignerRole)
+	if err != nil {
+		return err
+	}
+	for _, permission := range role.Permissions {
+		if !slices.Contains(held, permission) {
+			return fmt.Errorf("%w: %q", ErrRoleNotHeld, permission)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound is returned when the session does not exist or is no longer active
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrDealerNotFound is returned when the dealer does not exist in the caller's tenant
+var ErrDealerNotFound = errors.New("dealer not found")
+
+// SessionService manages the signed-in devices of users
+type SessionService struct {
+	store repository.Store
+	auth  *AuthService
+	roles *RoleService
+}
+
+func NewSessionService(store repository.Store, auth *AuthService, roles *RoleService) *SessionService {
+	return &SessionService{store: store, auth: auth, roles: roles}
+}
+
+// ListSessions returns the active logins of a user; currentSessionID marks
+// the one the request was made from
+func (s *SessionService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.ActiveSession, error) {
+	sessions, err := s.store.Sessions().ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	result := make([]models.ActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		lastUsedAt := session.CreatedAt
+		if session.LastUsedAt != nil {
+			lastUsedAt = *session.LastUsedAt
+		}
+
+		result = append(result, models.ActiveSession{
+			ID:         session.FamilyID,
+			Device:     describeDevice(session.UserAgent),
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.AuthenticatedAt,
+			LastUsedAt: lastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.FamilyID == currentSessionID,
+		})
+	}
+
+	return result, nil
+}
+
+// RevokeSession signs the user out of one device
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.revoke(ctx, userID, sessionID, models.SessionRevokedByUser)
+}
+
+// ListDealerSessions returns the active logins of a dealer in the tenant
+func (s *SessionService) ListDealerSessions(ctx context.Context, tenantID, dealerID string) ([]models.ActiveSession, error) {
+	if err := s.checkDealer(ctx, tenantID, dealerID); err != nil {
+		return nil, err
+	}
+
+	return s.ListSessions(ctx, dealerID, "")
+}
+
+// RevokeDealerSession signs a dealer of the tenant out of one device
+func (s *SessionService) RevokeDealerSession(ctx context.Context, tenantID, dealerID, sessionID string) error {
+	if err := s.checkDealer(ctx, tenantID, dealerID); err != nil {
+		return err
+	}
+
+	return s.revoke(ctx, dealerID, sessionID, models.SessionRevokedByFranchiser)
+}
+
+// RevokeAllDealerSessions signs a dealer of the tenant out of every device;
+// the dealer's access tokens stop working too
+func (s *SessionService) RevokeAllDealerSessions(ctx context.Context, tenantID, dealerID string) error {
+	if err := s.checkDealer(ctx, tenantID, dealerID); err != nil {
+		return err
+	}
+
+	return s.auth.revokeAllSessions(ctx, dealerID, models.SessionRevokedByFranchiser)
+}
+
+// revoke revokes one active login of the user together with its access tokens
+func (s *SessionService) revoke(ctx context.Context, userID, sessionID, reason string) error {
+	sessions, err := s.store.Sessions().ListActiveByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if session.FamilyID == sessionID {
+			return s.auth.revokeSession(ctx, userID, sessionID, reason)
+		}
+	}
+
+	return ErrSessionNotFound
+}
+
+// checkDealer makes sure the user is a dealer of the given tenant
+func (s *SessionService) checkDealer(ctx context.Context, tenantID, dealerID string) error {
+	if _, err := uuid.Parse(dealerID); err != nil {
+		return ErrDealerNotFound
+	}
+
+	dealer, err := s.store.Users().GetInTenant(ctx, tenantID, dealerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDealerNotFound
+		}
+		return fmt.Errorf("failed to get dealer: %w", err)
+	}
+
+	isDealer, err := s.roles.IsDealerRole(ctx, tenantID, dealer.Role)
+	if err != nil {
+		return err
+	}
+	if !isDealer {
+		return ErrDealerNotFound
+	}
+
+	return nil
+}
+
+// describeDevice turns a user agent into a short "Browser on OS" label
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "yabrowser"):
+		browser = "Yandex Browser"
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "cfnetwork") || strings.Contains(ua, "dart"):
+		browser = "Mobile app"
+	case strings.Contains(ua, "curl") || strings.Contains(ua, "postman") || strings.Contains(ua, "go-http-client"):
+		browser = "API client"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		os = "iOS"
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// FeatureEnabled reports whether the module works for the tenant: its plan
+// includes it or a superadmin granted it, and the tenant has not switched it off
+func (s *TenantService) FeatureEnabled(ctx context.Context, tenantID, feature string) (bool, error) {
+	if features, ok := s.features.get(tenantID); ok {
+		return features.Enabled(feature), nil
+	}
+
+	generation := s.features.current()
+	tenant, err := s.store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		return false, tenantError(err)
+	}
+
+	features, err := effectiveFeatures(ctx, s.store, tenant)
+	if err != nil {
+		return false, err
+	}
+
+	s.features.put(tenantID, features, generation)
+	return features.Enabled(feature), nil
+}
+
+// SetTenantFeatures lets a superadmin switch modules of a tenant on or off.
+// Modules its plan does not include are granted to the tenant when switched on.
+func (s *TenantService) SetTenantFeatures(ctx context.Context, tenantID string, changes models.FeatureToggleRequest) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	var updated *models.Tenant
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		tenant, err := tx.Tenants().GetForUpdate(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		settings, err := tenant.ParseSettings()
+		if err != nil {
+			return fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+		}
+
+		plan, err := effectivePlan(ctx, tx, tenant.Plan)
+		if err != nil && !errors.Is(err, ErrInvalidPlan) {
+			return err
+		}
+
+		if err := applyFeatureChanges(&settings.Features, changes); err != nil {
+			return err
+		}
+		for feature, enabled := range changes {
+			settings.GrantedFeatures.Set(feature, enabled && !plan.Features.Enabled(feature))
+		}
+
+		if err := saveTenant(ctx, tx, tenant, settings); err != nil {
+			return err
+		}
+
+		updated = tenant
+		return nil
+	})
+	s.features.invalidate(tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return updated, nil
+}
+
+// ListPlans returns the plan catalogue with the modules as superadmins set them
+func (s *TenantService) ListPlans(ctx context.Context) ([]models.PlanEntry, error) {
+	entries := []models.PlanEntry{}
+	for _, name := range models.PlanNames() {
+		plan, err := effectivePlan(ctx, s.store, name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.PlanEntry{Name: name, Plan: plan})
+	}
+
+	return entries, nil
+}
+
+// SetPlanFeatures switches modules of a plan on or off for every tenant on
+// it. Modules a tenant switched off stay off.
+func (s *TenantService) SetPlanFeatures(ctx context.Context, name string, changes models.FeatureToggleRequest) (*models.PlanEntry, error) {
+	if !models.IsValidPlan(name) {
+		return nil, ErrInvalidPlan
+	}
+
+	var entry *models.PlanEntry
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		plan, err := effectivePlan(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		if err := applyFeatureChanges(&plan.Features, changes); err != nil {
+			return err
+		}
+
+		if err := tx.Plans().SetFeatures(ctx, name, plan.Features); err != nil {
+			return fmt.Errorf("failed to save plan features: %w", err)
+		}
+
+		entry = &models.PlanEntry{Name: name, Plan: plan}
+		return nil
+	})
+	s.features.invalidateAll()
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return entry, nil
+}
+
+// effectivePlan returns the plan from the catalogue with the modules superadmins set for it
+func effectivePlan(ctx context.Context, store repository.Store, name string) (models.Plan, error) {
+	plan, ok := models.GetPlan(name)
+	if !ok {
+		return plan, ErrInvalidPlan
+	}
+
+	features, err := store.Plans().GetFeatures(ctx, name)
+	switch {
+	case err == nil:
+		plan.Features = *features
+	case !errors.Is(err, repository.ErrNotFound):
+		return plan, fmt.Errorf("failed to get plan features: %w", err)
+	}
+
+	return plan, nil
+}
+
+// effectiveFeatures returns the modules that work for the tenant on its plan
+// as superadmins set it
+func effectiveFeatures(ctx context.Context, store repository.Store, tenant *models.Tenant) (models.FeatureSettings, error) {
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return models.FeatureSettings{}, fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+	}
+
+	plan, err := effectivePlan(ctx, store, tenant.Plan)
+	if err != nil && !errors.Is(err, ErrInvalidPlan) {
+		return models.FeatureSettings{}, err
+	}
+
+	return settings.EffectiveFeatures(plan.Features), nil
+}
+
+// applyFeatureChanges switches the listed modules; unknown modules are rejected
+func applyFeatureChanges(features *models.FeatureSettings, changes models.FeatureToggleRequest) error {
+	if len(changes) == 0 {
+		return fmt.Errorf("%w: no features to change", ErrInvalidSettings)
+	}
+
+	for feature, enabled := range changes {
+		if !features.Set(feature, enabled) {
+			return fmt.Errorf("%w: unknown feature %q", ErrInvalidSettings, feature)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,654 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTenantNotFound is returned when the tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantSuspended is returned when users of a suspended tenant try to sign in
+	ErrTenantSuspended = errors.New("tenant is suspended")
+	// ErrInvalidSettings is returned when tenant settings do not match the settings schema
+	ErrInvalidSettings = errors.New("invalid tenant settings")
+	// ErrFeatureNotInPlan is returned when a franchiser enables a feature their plan does not include
+	ErrFeatureNotInPlan = errors.New("feature is not included in the tenant's plan")
+	// ErrOwnTenant is returned when a superadmin tries to suspend or delete their own tenant
+	ErrOwnTenant = errors.New("cannot suspend or delete your own tenant")
+	// ErrInvalidSlug is returned when a requested tenant slug is malformed or reserved
+	ErrInvalidSlug = errors.New("slug must be 3-63 lowercase latin letters, digits or dashes and must not be reserved")
+	// ErrSlugTaken is returned when another tenant already uses the slug
+	ErrSlugTaken = errors.New("slug is already taken")
+	// ErrInvalidPlan is returned for an unknown plan
+	ErrInvalidPlan = errors.New("unknown plan")
+)
+
+const (
+	slugMinLength = 3
+	slugMaxLength = 63
+	// slugBaseLength leaves room for a numeric suffix on generated slugs
+	slugBaseLength = 56
+)
+
+var (
+	slugRe  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	colorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+// reservedSlugs may later become subdomains of the app itself
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "app": true, "auth": true, "help": true,
+	"mail": true, "static": true, "support": true, "www": true,
+}
+
+// cyrillicToLatin transliterates Russian letters for generated slugs
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// TenantService manages franchise networks: franchisers edit their own
+// tenant, superadmins manage all of them
+type TenantService struct {
+	store       repository.Store
+	auth        *AuthService
+	invitations *InvitationService
+	quotas      *QuotaService
+	features    *ttlCache[models.FeatureSettings] // modules of each tenant
+}
+
+func NewTenantService(store repository.Store, auth *AuthService, invitations *InvitationService, quotas *QuotaService) *TenantService {
+	return &TenantService{store: store, auth: auth, invitations: invitations, quotas: quotas, features: newTTLCache[models.FeatureSettings](cacheTTL("feature_cache_seconds"), cacheSize)}
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *TenantService) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	tenant, err := s.store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return tenant, nil
+}
+
+// TenantExists reports whether the tenant exists; a superadmin may act in any
+// existing tenant, suspended or not
+func (s *TenantService) TenantExists(ctx context.Context, tenantID string) (bool, error) {
+	if _, err := s.store.Tenants().GetByID(ctx, tenantID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return true, nil
+}
+
+// UpdateTenant applies a franchiser's changes to their tenant. Features
+// neither the plan includes nor a superadmin granted can be switched off but not on.
+func (s *TenantService) UpdateTenant(ctx context.Context, tenantID string, req models.TenantUpdateRequest) (*models.Tenant, error) {
+	var updated *models.Tenant
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		tenant, err := tx.Tenants().GetForUpdate(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		settings, err := tenant.ParseSettings()
+		if err != nil {
+			return fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+		}
+
+		if name := strings.TrimSpace(req.Name); name != "" {
+			tenant.Name = name
+		}
+		if city := strings.TrimSpace(req.City); city != "" {
+			tenant.City = city
+		}
+		if req.Branding != nil {
+			settings.Branding = *req.Branding
+		}
+		if req.Features != nil {
+			plan, err := effectivePlan(ctx, tx, tenant.Plan)
+			if err != nil && !errors.Is(err, ErrInvalidPlan) {
+				return err
+			}
+			if err := checkPlanFeatures(plan.Features, settings, *req.Features); err != nil {
+				return err
+			}
+			settings.Features = *req.Features
+		}
+		if req.Security != nil {
+			if err := checkRoleNames(ctx, tx, tenant.ID, req.Security.MFARequiredRoles); err != nil {
+				return err
+			}
+			settings.Security = *req.Security
+		}
+		if timezone := strings.TrimSpace(req.Timezone); timezone != "" {
+			settings.Timezone = timezone
+		}
+
+		if err := saveTenant(ctx, tx, tenant, settings); err != nil {
+			return err
+		}
+
+		updated = tenant
+		return nil
+	})
+	s.features.invalidate(tenantID)
+	s.quotas.forgetLimits(tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return updated, nil
+}
+
+// ListTenants returns one page of tenants and the total number of matches
+func (s *TenantService) ListTenants(ctx context.Context, filter models.TenantFilter) ([]models.Tenant, int, error) {
+	tenants, err := s.store.Tenants().List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	total, err := s.store.Tenants().Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	return tenants, total, nil
+}
+
+// CreateTenant creates a tenant with the defaults of its plan. When an owner
+// is given they are invited as the tenant's franchiser.
+func (s *TenantService) CreateTenant(ctx context.Context, inviterID string, req models.TenantCreateRequest, lang string) (*models.Tenant, error) {
+	var invitation *models.Invitation
+	var token string
+
+	if req.Owner != nil {
+		email := normalizeEmail(req.Owner.Email)
+		if _, err := s.store.Users().GetByEmail(ctx, email); err == nil {
+			return nil, ErrEmailTaken
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("failed to check email: %w", err)
+		}
+
+		var tokenHash string
+		var err error
+		if token, tokenHash, err = newOpaqueToken(); err != nil {
+			return nil, err
+		}
+
+		invitation = &models.Invitation{
+			ID:        uuid.New().String(),
+			Email:     email,
+			Role:      "franchiser",
+			FirstName: req.Owner.FirstName,
+			LastName:  req.Owner.LastName,
+			InvitedBy: inviterID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(invitationTTL()),
+		}
+	}
+
+	var tenant *models.Tenant
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		if tenant, err = provisionTenant(ctx, tx, req.Name, req.Slug, req.City, req.Plan); err != nil {
+			return err
+		}
+
+		if invitation == nil {
+			return nil
+		}
+		invitation.TenantID = tenant.ID
+		return storeInvitation(ctx, tx, invitation)
+	})
+	if err != nil {
+		if errors.Is(err, ErrSlugTaken) || errors.Is(err, ErrInvalidSlug) || errors.Is(err, ErrInvalidPlan) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	if invitation != nil {
+		s.invitations.sendInvitation(ctx, invitation, token, inviterID, lang)
+	}
+
+	return tenant, nil
+}
+
+// AdminUpdateTenant applies a superadmin's changes to any tenant
+func (s *TenantService) AdminUpdateTenant(ctx context.Context, tenantID string, req models.TenantAdminUpdateRequest) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	var updated *models.Tenant
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		tenant, err := tx.Tenants().GetForUpdate(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		var settings models.TenantSettings
+		if len(req.Settings) > 0 {
+			if settings, err = decodeSettings(req.Settings); err != nil {
+				return err
+			}
+			if err := checkRoleNames(ctx, tx, tenant.ID, settings.Security.MFARequiredRoles); err != nil {
+				return err
+			}
+		} else if settings, err = tenant.ParseSettings(); err != nil {
+			return fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+		}
+
+		if name := strings.TrimSpace(req.Name); name != "" {
+			tenant.Name = name
+		}
+		if city := strings.TrimSpace(req.City); city != "" {
+			tenant.City = city
+		}
+		if req.Plan != "" && req.Plan != tenant.Plan {
+			plan, err := effectivePlan(ctx, tx, req.Plan)
+			if err != nil {
+				return err
+			}
+			tenant.Plan = req.Plan
+
+			// Explicit settings win over the limits of the new plan; the
+			// modules follow the plan anyway
+			if len(req.Settings) == 0 {
+				settings.Limits = plan.Limits
+			}
+		}
+
+		if err := saveTenant(ctx, tx, tenant, settings); err != nil {
+			return err
+		}
+
+		updated = tenant
+		return nil
+	})
+	s.features.invalidate(tenantID)
+	s.quotas.forgetLimits(tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return updated, nil
+}
+
+// SuspendTenant locks all users of the tenant out: their sessions and access
+// tokens are revoked and they cannot sign in until the tenant is resumed
+func (s *TenantService) SuspendTenant(ctx context.Context, actorTenantID, tenantID string) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+	if tenantID == actorTenantID {
+		return nil, ErrOwnTenant
+	}
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Tenants().SetSuspended(ctx, tenantID, true); err != nil {
+			return err
+		}
+		if err := tx.Users().BumpTokenVersions(ctx, tenantID); err != nil {
+			return err
+		}
+		return tx.Sessions().RevokeAllForTenant(ctx, tenantID, models.SessionRevokedTenantSuspended)
+	})
+	s.auth.forgetSessions()
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	return s.GetTenant(ctx, tenantID)
+}
+
+// ResumeTenant lifts a suspension; users have to sign in again
+func (s *TenantService) ResumeTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	if err := s.store.Tenants().SetSuspended(ctx, tenantID, false); err != nil {
+		return nil, tenantError(err)
+	}
+
+	return s.GetTenant(ctx, tenantID)
+}
+
+// DeleteTenant removes the tenant together with its users and all their data
+func (s *TenantService) DeleteTenant(ctx context.Context, actorTenantID, tenantID string) error {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return ErrTenantNotFound
+	}
+	if tenantID == actorTenantID {
+		return ErrOwnTenant
+	}
+
+	if err := s.store.Tenants().Delete(ctx, tenantID); err != nil {
+		return tenantError(err)
+	}
+
+	s.features.invalidate(tenantID)
+	s.quotas.forgetLimits(tenantID)
+	return nil
+}
+
+// activeTenant loads the tenant a user signs in to and rejects suspended ones
+func activeTenant(ctx context.Context, store repository.Store, tenantID string) (*models.Tenant, error) {
+	tenant, err := store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if tenant.IsSuspended() {
+		return nil, ErrTenantSuspended
+	}
+
+	return tenant, nil
+}
+
+// saveTenant validates the settings and stores them together with the tenant
+func saveTenant(ctx context.Context, tx repository.Store, tenant *models.Tenant, settings models.TenantSettings) error {
+	if err := validateSettings(settings); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	tenant.Settings = raw
+
+	return tx.Tenants().Update(ctx, tenant)
+}
+
+// decodeSettings parses settings sent by a client; unknown keys are rejected
+// so that typos do not silently disappear
+func decodeSettings(raw json.RawMessage) (models.TenantSettings, error) {
+	var settings models.TenantSettings
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return settings, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if decoder.More() {
+		return settings, fmt.Errorf("%w: unexpected data after the settings object", ErrInvalidSettings)
+	}
+
+	return settings, nil
+}
+
+// validateSettings checks the settings against the settings schema
+func validateSettings(settings models.TenantSettings) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSettings, fmt.Sprintf(format, args...))
+	}
+
+	branding := settings.Branding
+	if !colorRe.MatchString(branding.PrimaryColor) {
+		return invalid("branding.primary_color must be a hex color such as #1890ff")
+	}
+	if !colorRe.MatchString(branding.SecondaryColor) {
+		return invalid("branding.secondary_color must be a hex color such as #722ed1")
+	}
+	if name := strings.TrimSpace(branding.CompanyName); name == "" || len(name) > 255 {
+		return invalid("branding.company_name is required and must be at most 255 characters")
+	}
+	if branding.LogoURL != "" {
+		u, err := url.Parse(branding.LogoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(branding.LogoURL) > 2048 {
+			return invalid("branding.logo_url must be an absolute http(s) URL")
+		}
+	}
+
+	limits := settings.Limits
+	for name, value := range map[string]int{
+		"max_dealers":         limits.MaxDealers,
+		"max_users":           limits.MaxUsers,
+		"storage_gb":          limits.StorageGB,
+		"api_calls_per_month": limits.APICallsPerMonth,
+	} {
+		if value <= 0 {
+			return invalid("limits.%s must be positive", name)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, role := range settings.Security.MFARequiredRoles {
+		if !models.IsValidRoleName(role) || seen[role] {
+			return invalid("security.mfa_required_roles may only list role names, each once")
+		}
+		seen[role] = true
+	}
+
+	// An empty timezone stands for the default one
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil || settings.Timezone == "Local" {
+			return invalid("timezone must be an IANA timezone such as Europe/Moscow")
+		}
+	}
+
+	return nil
+}
+
+// tenantLocation returns the timezone of the tenant
+func tenantLocation(ctx context.Context, store repository.Store, tenantID string) (*time.Location, error) {
+	tenant, err := store.Tenants().GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, tenantError(err)
+	}
+
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+	}
+
+	return settingsLocation(settings), nil
+}
+
+// settingsLocation returns the timezone of the settings, falling back to
+// the default one when it is not set or not known on this server
+func settingsLocation(settings models.TenantSettings) *time.Location {
+	for _, name := range []string{settings.Timezone, models.DefaultTimezone} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// checkPlanFeatures rejects switching on a feature that neither the plan
+// includes nor a superadmin granted. Features that are on already may stay on.
+func checkPlanFeatures(plan models.FeatureSettings, settings models.TenantSettings, requested models.FeatureSettings) error {
+	for _, feature := range models.Features {
+		allowed := plan.Enabled(feature) || settings.GrantedFeatures.Enabled(feature) || settings.Features.Enabled(feature)
+		if requested.Enabled(feature) && !allowed {
+			return fmt.Errorf("%w: %s", ErrFeatureNotInPlan, feature)
+		}
+	}
+
+	return nil
+}
+
+// checkRoleNames rejects names that are not roles of the tenant
+func checkRoleNames(ctx context.Context, store repository.Store, tenantID string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	roles, err := store.Roles().ListByTenant(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	for _, name := range names {
+		if !slices.ContainsFunc(roles, func(role models.Role) bool { return role.Name == name }) {
+			return fmt.Errorf("%w: security.mfa_required_roles: %q is not a role of the network", ErrInvalidSettings, name)
+		}
+	}
+
+	return nil
+}
+
+// tenantError maps repository errors to the errors handlers understand
+func tenantError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrTenantNotFound
+	case errors.Is(err, ErrInvalidSettings), errors.Is(err, ErrFeatureNotInPlan), errors.Is(err, ErrInvalidPlan):
+		return err
+	default:
+		return fmt.Errorf("tenant storage error: %w", err)
+	}
+}
+
+// provisionTenant creates a tenant with the defaults of its plan and the
+// built-in roles. An empty slug is generated from the name; an explicit one must be free.
+func provisionTenant(ctx context.Context, store repository.Store, name, slug, city, plan string) (*models.Tenant, error) {
+	if plan == "" {
+		plan = models.PlanStart
+	}
+	if !models.IsValidPlan(plan) {
+		return nil, ErrInvalidPlan
+	}
+
+	name = strings.TrimSpace(name)
+
+	var err error
+	if slug != "" {
+		slug = strings.ToLower(strings.TrimSpace(slug))
+		if !validSlug(slug) {
+			return nil, ErrInvalidSlug
+		}
+	} else if slug, err = freeSlug(ctx, store.Tenants(), slugify(name)); err != nil {
+		return nil, err
+	}
+
+	settings, err := json.Marshal(models.DefaultTenantSettings(plan, name))
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := &models.Tenant{
+		ID:       uuid.New().String(),
+		Name:     name,
+		Slug:     slug,
+		City:     strings.TrimSpace(city),
+		Plan:     plan,
+		Settings: settings,
+	}
+
+	// The unique index decides between concurrent sign-ups with the same slug
+	if err := store.Tenants().Create(ctx, tenant); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrSlugTaken
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	for _, role := range models.DefaultRoles(tenant.ID) {
+		role.ID = uuid.New().String()
+		if err := store.Roles().Create(ctx, &role); err != nil {
+			return nil, fmt.Errorf("failed to create role %s: %w", role.Name, err)
+		}
+	}
+
+	return tenant, nil
+}
+
+// freeSlug returns base, or base with the first free numeric suffix
+func freeSlug(ctx context.Context, repo repository.TenantRepository, base string) (string, error) {
+	for i := 1; i <= 100; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = base + "-" + strconv.Itoa(i)
+		}
+
+		if _, err := repo.GetBySlug(ctx, candidate); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return candidate, nil
+			}
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+	}
+
+	// Extremely popular names fall back to a random suffix
+	return base + "-" + strings.ReplaceAll(uuid.New().String(), "-", "")[:6], nil
+}
+
+// slugify turns a tenant name into a slug base: lowercase latin letters and
+// digits separated by single dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			part = string(r)
+		default:
+			var ok bool
+			if part, ok = cyrillicToLatin[r]; !ok {
+				dash = b.Len() > 0
+				continue
+			}
+		}
+
+		if part == "" {
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(part)
+	}
+
+	slug := b.String()
+	if len(slug) > slugBaseLength {
+		slug = strings.TrimRight(slug[:slugBaseLength], "-")
+	}
+	if len(slug) < slugMinLength || reservedSlugs[slug] {
+		slug = "network-" + slug
+		slug = strings.TrimRight(slug, "-")
+	}
+
+	return slug
+}
+
+// validSlug reports whether slug can be requested explicitly
+func validSlug(slug string) bool {
+	return len(slug) >= slugMinLength && len(slug) <= slugMaxLength && slugRe.MatchString(slug) && !reservedSlugs[slug]
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+	"franchise-saas-backend/internal/tokens"
+
+	"github.com/google/uuid"
+)
+
+func TestSuspendTenantForgetsCachedSessions(t *testing.T) {
+	store := repository.NewMemoryStore()
+	auth := NewAuthService(store, nil, nil, nil, nil)
+	tenants := NewTenantService(store, auth, nil, nil)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{ID: uuid.NewString(), Name: "Network", Slug: "network", Plan: models.PlanStart}
+	if err := store.Tenants().Create(ctx, tenant); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	user := &models.User{ID: uuid.NewString(), TenantID: tenant.ID, Email: "dealer@example.com", Role: models.RoleDealer, IsActive: true}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	session := &models.Session{
+		ID:              uuid.NewString(),
+		UserID:          user.ID,
+		FamilyID:        uuid.NewString(),
+		RefreshToken:    "hash",
+		ExpiresAt:       time.Now().Add(time.Hour),
+		AuthenticatedAt: time.Now(),
+	}
+	if err := store.Sessions().Create(ctx, session); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	// The first check caches the login as active
+	if err := auth.ValidateSession(ctx, user.ID, session.FamilyID); err != nil {
+		t.Fatalf("validate session: %v", err)
+	}
+
+	if _, err := tenants.SuspendTenant(ctx, uuid.NewString(), tenant.ID); err != nil {
+		t.Fatalf("suspend tenant: %v", err)
+	}
+	if err := auth.ValidateSession(ctx, user.ID, session.FamilyID); !errors.Is(err, tokens.ErrTokenRevoked) {
+		t.Fatalf("validate session after suspension: got %v, want %v", err, tokens.ErrTokenRevoked)
+	}
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/repository"
+	"franchise-saas-backend/internal/totp"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// twoFactorChallengeTTL is how long the second login step may take
+	twoFactorChallengeTTL = 5 * time.Minute
+	// twoFactorMaxAttempts is how many wrong codes end a login challenge
+	twoFactorMaxAttempts = 5
+	// recoveryCodeCount is how many recovery codes are generated at once
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already uses 2FA
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is returned for operations that need 2FA to be enabled
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorNotStarted is returned when confirming before a secret was generated
+	ErrTwoFactorNotStarted = errors.New("two-factor enrollment has not been started")
+	// ErrTwoFactorMandatory is returned when disabling 2FA the tenant requires
+	ErrTwoFactorMandatory = errors.New("two-factor authentication is mandatory for this role")
+	// ErrInvalidTwoFactorCode is returned for a wrong, expired or already used code
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge is returned for an unknown, used or expired login challenge
+	ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+)
+
+// VerifyTwoFactor completes a two-step login. For users who still have to
+// enroll, a valid code also enables 2FA and the recovery codes are returned.
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, challengeToken, code string, client ClientInfo) (*models.TwoFactorLoginResponse, error) {
+	var result *models.TwoFactorLoginResponse
+	var failed bool
+	var account *models.User
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		challenge, user, err := loadChallenge(ctx, tx, challengeToken)
+		if err != nil {
+			return err
+		}
+		account = user
+
+		var recoveryCodes []string
+		if user.TwoFactorEnabled {
+			ok, err := checkSecondFactor(ctx, tx, user, code)
+			if err != nil {
+				return err
+			}
+			failed = !ok
+		} else {
+			if user.TOTPSecret == "" {
+				return ErrTwoFactorNotStarted
+			}
+
+			ok, err := checkTOTP(ctx, tx, user, code)
+			if err != nil {
+				return err
+			}
+			failed = !ok
+
+			if ok {
+				recoveryCodes, err = enableTwoFactor(ctx, tx, user)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// The failed attempt is committed, so the tx function must succeed
+		if failed {
+			attempts, err := tx.UserTokens().IncrementAttempts(ctx, challenge.ID)
+			if err != nil {
+				return err
+			}
+			if attempts >= twoFactorMaxAttempts {
+				return tx.UserTokens().MarkUsed(ctx, challenge.ID)
+			}
+			return nil
+		}
+
+		if err := tx.UserTokens().MarkUsed(ctx, challenge.ID); err != nil {
+			return err
+		}
+
+		auth, err := s.authResponse(ctx, tx, user, client)
+		if err != nil {
+			return err
+		}
+
+		result = &models.TwoFactorLoginResponse{
+			AuthResponse:  *auth,
+			RecoveryCodes: recoveryCodes,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	if failed {
+		s.loginFailed(ctx, account.Email, account, client, models.LoginFailedInvalidTwoFactor)
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	s.loginSucceeded(ctx, account, client)
+	return result, nil
+}
+
+// SetupTwoFactorChallenge starts the enrollment a tenant requires, using the
+// login challenge instead of an access token
+func (s *AuthService) SetupTwoFactorChallenge(ctx context.Context, challengeToken string) (*models.TwoFactorSetupResponse, error) {
+	var result *models.TwoFactorSetupResponse
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		_, user, err := loadChallenge(ctx, tx, challengeToken)
+		if err != nil {
+			return err
+		}
+
+		result, err = startEnrollment(ctx, tx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TwoFactorStatus reports whether the user has 2FA enabled or required
+func (s *AuthService) TwoFactorStatus(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := s.store.RecoveryCodes().CountUnused(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return &models.TwoFactorStatus{
+		Enabled:                user.TwoFactorEnabled,
+		Required:               required,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// SetupTwoFactor generates a new secret for the user. 2FA is enabled only
+// after the first code is confirmed.
+func (s *AuthService) SetupTwoFactor(ctx context.Context, userID string) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return startEnrollment(ctx, s.store, user)
+}
+
+// ConfirmTwoFactor enables 2FA once the user proves the authenticator works
+// and returns the recovery codes
+func (s *AuthService) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().GetByID(ctx, userID)
+		if err != nil {
+			return userError(err)
+		}
+
+		if user.TwoFactorEnabled {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		if user.TOTPSecret == "" {
+			return ErrTwoFactorNotStarted
+		}
+
+		ok, err := checkTOTP(ctx, tx, user, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		recoveryCodes, err = enableTwoFactor(ctx, tx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().GetByID(ctx, userID)
+		if err != nil {
+			return userError(err)
+		}
+
+		if !user.TwoFactorEnabled {
+			return ErrTwoFactorNotEnabled
+		}
+
+		ok, err := checkTOTP(ctx, tx, user, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(ctx, tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking the password and a TOTP or
+// recovery code, unless the tenant requires it for the user's role
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID, password, code string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorMandatory
+	}
+
+	return s.store.WithTx(ctx, func(tx repository.Store) error {
+		ok, err := checkSecondFactor(ctx, tx, user, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		user.TwoFactorEnabled = false
+		user.TOTPSecret = ""
+		if err := tx.Users().UpdateTwoFactor(ctx, user); err != nil {
+			return err
+		}
+
+		return tx.RecoveryCodes().DeleteForUser(ctx, user.ID)
+	})
+}
+
+// startChallenge creates the token for the second login step
+func (s *AuthService) startChallenge(ctx context.Context, user *models.User) (*models.TwoFactorChallenge, error) {
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &models.UserToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeTwoFactor,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	}
+	if err := s.store.UserTokens().Create(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to store login challenge: %w", err)
+	}
+
+	return &models.TwoFactorChallenge{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: !user.TwoFactorEnabled,
+		ChallengeToken:     token,
+		ExpiresAt:          challenge.ExpiresAt,
+	}, nil
+}
+
+// twoFactorRequired reports whether the tenant requires 2FA for the user's role
+func (s *AuthService) twoFactorRequired(ctx context.Context, user *models.User) (bool, error) {
+	tenant, err := s.store.Tenants().GetByID(ctx, user.TenantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	settings, err := tenant.ParseSettings()
+	if err != nil {
+		return false, fmt.Errorf("invalid settings of tenant %s: %w", tenant.ID, err)
+	}
+
+	return slices.Contains(settings.Security.MFARequiredRoles, user.Role), nil
+}
+
+// loadChallenge returns a valid, unused login challenge and its user
+func loadChallenge(ctx context.Context, tx repository.Store, challengeToken string) (*models.UserToken, *models.User, error) {
+	challenge, err := tx.UserTokens().GetByHash(ctx, hashToken(challengeToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	if challenge.Purpose != models.TokenPurposeTwoFactor || challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, nil, ErrInvalidChallenge
+	}
+
+	user, err := tx.Users().GetByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil, ErrUserInactive
+	}
+
+	return challenge, user, nil
+}
+
+// startEnrollment stores a new secret for a user who has not enabled 2FA yet
+func startEnrollment(ctx context.Context, store repository.Store, user *models.User) (*models.TwoFactorSetupResponse, error) {
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	if err := store.Users().UpdateTwoFactor(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to save totp secret: %w", err)
+	}
+
+	issuer := viper.GetString("totp_issuer")
+	if issuer == "" {
+		issuer = "Franchise SaaS"
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: totp.URI(issuer, user.Email, secret),
+	}, nil
+}
+
+// enableTwoFactor turns 2FA on and returns the first recovery codes
+func enableTwoFactor(ctx context.Context, tx repository.Store, user *models.User) ([]string, error) {
+	user.TwoFactorEnabled = true
+	if err := tx.Users().UpdateTwoFactor(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return replaceRecoveryCodes(ctx, tx, user.ID)
+}
+
+// checkTOTP validates a TOTP code and records its time step so the same code
+// cannot be used twice
+func checkTOTP(ctx context.Context, tx repository.Store, user *models.User, code string) (bool, error) {
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), 1)
+	if !ok {
+		return false, nil
+	}
+
+	if err := tx.Users().AdvanceTOTPCounter(ctx, user.ID, counter); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	user.TOTPLastCounter = counter
+	return true, nil
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code
+func checkSecondFactor(ctx context.Context, tx repository.Store, user *models.User, code string) (bool, error) {
+	ok, err := checkTOTP(ctx, tx, user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	if err := tx.RecoveryCodes().Use(ctx, user.ID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// replaceRecoveryCodes generates new recovery codes, invalidating the old ones
+func replaceRecoveryCodes(ctx context.Context, tx repository.Store, userID string) ([]string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		var code strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(alphabet[int(b)%len(alphabet)])
+		}
+
+		codes[i] = code.String()
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := tx.RecoveryCodes().ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// userError maps repository errors for user lookups
+func userError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return fmt.Errorf("failed to get user: %w", err)
+}
@@ -1,65 +1,66 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"time"
+	"fmt"
+	"slices"
+	"strings"
 
 	"franchise-saas-backend/internal/models"
+	"franchise-saas-backend/internal/password"
+	"franchise-saas-backend/internal/repository"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrUserNotFound is returned when the requested user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidCurrentPassword is returned when the current password given to change it is wrong
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	// ErrInvalidDealers is returned when a dealer to assign is not a dealer of the tenant
+	ErrInvalidDealers = errors.New("every assigned user must be a dealer of this tenant")
+)
+
 type UserService struct {
-	db interface{}
+	store  repository.Store
+	auth   *AuthService
+	policy *password.Policy
+	roles  *RoleService
 }
 
-func NewUserService(db interface{}) *UserService {
-	return &UserService{db: db}
+func NewUserService(store repository.Store, auth *AuthService, policy *password.Policy, roles *RoleService) *UserService {
+	return &UserService{store: store, auth: auth, policy: policy, roles: roles}
 }
 
 // GetUserByID retrieves a user by their ID
-func (s *UserService) GetUserByID(userID string) (*models.User, error) {
-	// In a real implementation, you would query the database
-	// For now, we'll simulate a lookup
-	
+func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(userID); err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	
-	// Simulate fetching from database
-	// In a real implementation, you would query the database here
-	return &models.User{
-		ID:        userID,
-		Email:     "user@example.com",
-		Password:  "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjdQcVrRzwwIWKXNw2vE.9YJdLQj3u", // bcrypt hash
-		Role:      "dealer",
-		TenantID:  "tenant-1",
-		FirstName: "John",
-		LastName:  "Doe",
-		Phone:     "+7 (999) 123-45-67",
-		CreatedAt: time.Now().Add(-24 * time.Hour), // Created yesterday
-		UpdatedAt: time.Now(),
-	}, nil
+
+	user, err := s.store.Users().GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
 }
 
 // UpdateUser updates a user's profile
-func (s *UserService) UpdateUser(userID string, req models.UserUpdateRequest) (*models.User, error) {
-	// In a real implementation, you would update the database
-	// For now, we'll simulate the update
-	
-	// Validate UUID format
-	if _, err := uuid.Parse(userID); err != nil {
-		return nil, errors.New("invalid user ID format")
-	}
-	
+func (s *UserService) UpdateUser(ctx context.Context, userID string, req models.UserUpdateRequest) (*models.User, error) {
 	// Fetch existing user
-	existingUser, err := s.GetUserByID(userID)
-	if err != nil || existingUser == nil {
-		return nil, errors.New("user not found")
+	existingUser, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	
+
 	// Update fields if provided in request
 	if req.FirstName != "" {
 		existingUser.FirstName = req.FirstName
@@ -73,86 +74,209 @@ func (s *UserService) UpdateUser(userID string, req models.UserUpdateRequest) (*
 	if req.Avatar != "" {
 		existingUser.Avatar = req.Avatar
 	}
-	
-	// Update timestamp
-	existingUser.UpdatedAt = time.Now()
-	
-	// In a real implementation, you would save to the database here
-	
+	if city := strings.TrimSpace(req.City); city != "" {
+		existingUser.City = city
+	}
+
+	if err := s.store.Users().Update(ctx, existingUser); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
 	return existingUser, nil
 }
 
-// GetDealersByTenant retrieves all dealers for a specific tenant
-func (s *UserService) GetDealersByTenant(tenantID, role string) ([]models.User, error) {
-	// In a real implementation, you would query the database
-	// For now, we'll simulate the retrieval
-	
+// GetDealer returns a dealer of the tenant. A non-empty managerID limits the
+// lookup to the dealers assigned to that manager. Users of other tenants, users
+// with other roles and dealers of other managers are reported as ErrDealerNotFound.
+func (s *UserService) GetDealer(ctx context.Context, tenantID, managerID, dealerID string) (*models.User, error) {
+	return visibleDealer(ctx, s.store, s.roles, tenantID, managerID, dealerID)
+}
+
+// GetDealersByTenant retrieves the dealers of a tenant, or only those
+// assigned to the manager when managerID is set. A non-empty role lists the
+// users with that role instead.
+func (s *UserService) GetDealersByTenant(ctx context.Context, tenantID, managerID, role string) ([]models.User, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(tenantID); err != nil {
 		return nil, errors.New("invalid tenant ID format")
 	}
-	
-	// Simulate fetching dealers from database
-	dealers := []models.User{
-		{
-			ID:        uuid.New().String(),
-			Email:     "dealer1@example.com",
-			Password:  "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjdQcVrRzwwIWKXNw2vE.9YJdLQj3u", // bcrypt hash
-			Role:      "dealer",
-			TenantID:  tenantID,
-			FirstName: "Alice",
-			LastName:  "Johnson",
-			Phone:     "+7 (999) 111-11-11",
-			CreatedAt: time.Now().Add(-7 * 24 * time.Hour), // Created a week ago
-			UpdatedAt: time.Now(),
-		},
-		{
-			ID:        uuid.New().String(),
-			Email:     "dealer2@example.com",
-			Password:  "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjdQcVrRzwwIWKXNw2vE.9YJdLQj3u", // bcrypt hash
-			Role:      "dealer",
-			TenantID:  tenantID,
-			FirstName: "Bob",
-			LastName:  "Smith",
-			Phone:     "+7 (999) 222-22-22",
-			CreatedAt: time.Now().Add(-5 * 24 * time.Hour), // Created 5 days ago
-			UpdatedAt: time.Now(),
-		},
-		{
-			ID:        uuid.New().String(),
-			Email:     "dealer3@example.com",
-			Password:  "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjdQcVrRzwwIWKXNw2vE.9YJdLQj3u", // bcrypt hash
-			Role:      "dealer",
-			TenantID:  tenantID,
-			FirstName: "Carol",
-			LastName:  "Williams",
-			Phone:     "+7 (999) 333-33-33",
-			CreatedAt: time.Now().Add(-3 * 24 * time.Hour), // Created 3 days ago
-			UpdatedAt: time.Now(),
-		},
-	}
-	
+
+	roles := []string{role}
+	if role == "" {
+		var err error
+		if roles, err = s.roles.DealerRoles(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	var users []models.User
+	var err error
+	if managerID == "" {
+		users, err = s.store.Users().ListByTenant(ctx, tenantID, "")
+	} else {
+		users, err = s.store.Users().ListByManager(ctx, tenantID, managerID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dealers: %w", err)
+	}
+
+	dealers := []models.User{}
+	for _, user := range users {
+		if slices.Contains(roles, user.Role) {
+			dealers = append(dealers, user)
+		}
+	}
+
 	return dealers, nil
 }
 
-// ChangeUserPassword changes a user's password
-func (s *UserService) ChangeUserPassword(userID, oldPassword, newPassword string) error {
-	// In a real implementation, you would verify the old password and update the new one
-	// For now, we'll just validate the new password
-	
-	// Validate new password strength
-	if len(newPassword) < 6 {
-		return errors.New("password must be at least 6 characters long")
-	}
-	
-	// Hash the new password
-	_, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+// GetAssignedDealers returns the dealers assigned to a manager of the tenant
+func (s *UserService) GetAssignedDealers(ctx context.Context, tenantID, managerID string) ([]models.User, error) {
+	if err := checkManager(ctx, s.store, s.roles, tenantID, managerID); err != nil {
+		return nil, err
+	}
+
+	dealers, err := s.store.Users().ListByManager(ctx, tenantID, managerID)
 	if err != nil {
-		return errors.New("failed to hash new password")
-	}
-	
-	// In a real implementation, you would update the password in the database
-	// along with updating the updated_at timestamp
-	
-	return nil
-}
\ No newline at end of file
+		return nil, fmt.Errorf("failed to list assigned dealers: %w", err)
+	}
+
+	return dealers, nil
+}
+
+// AssignDealers replaces the dealers assigned to a manager. Managers see the
+// change with their next request.
+func (s *UserService) AssignDealers(ctx context.Context, tenantID, managerID string, dealerIDs []string) ([]models.User, error) {
+	if err := checkManager(ctx, s.store, s.roles, tenantID, managerID); err != nil {
+		return nil, err
+	}
+
+	for _, dealerID := range dealerIDs {
+		if _, err := visibleDealer(ctx, s.store, s.roles, tenantID, "", dealerID); err != nil {
+			if errors.Is(err, ErrDealerNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidDealers, dealerID)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.store.Users().AssignDealers(ctx, tenantID, managerID, dealerIDs); err != nil {
+		return nil, fmt.Errorf("failed to assign dealers: %w", err)
+	}
+
+	return s.GetAssignedDealers(ctx, tenantID, managerID)
+}
+
+// visibleDealer returns a dealer of the tenant; a non-empty managerID limits
+// it to the dealers assigned to that manager
+func visibleDealer(ctx context.Context, store repository.Store, roles *RoleService, tenantID, managerID, dealerID string) (*models.User, error) {
+	if _, err := uuid.Parse(dealerID); err != nil {
+		return nil, ErrDealerNotFound
+	}
+
+	dealer, err := store.Users().GetInTenant(ctx, tenantID, dealerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrDealerNotFound
+		}
+		return nil, fmt.Errorf("failed to get dealer: %w", err)
+	}
+
+	isDealer, err := roles.IsDealerRole(ctx, tenantID, dealer.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !isDealer {
+		return nil, ErrDealerNotFound
+	}
+	if managerID != "" && dealer.ManagerID != managerID {
+		return nil, ErrDealerNotFound
+	}
+
+	return dealer, nil
+}
+
+// listDealers returns the users of the tenant whose role is a dealer role,
+// oldest first
+func listDealers(ctx context.Context, store repository.Store, roles *RoleService, tenantID string) ([]models.User, error) {
+	dealerRoles, err := roles.DealerRoles(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := store.Users().ListByTenant(ctx, tenantID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dealers: %w", err)
+	}
+
+	dealers := []models.User{}
+	for _, user := range users {
+		if slices.Contains(dealerRoles, user.Role) {
+			dealers = append(dealers, user)
+		}
+	}
+
+	return dealers, nil
+}
+
+// ChangeUserPassword checks the current password, stores the new one and
+// signs the user out of every other device. sessionID is the login the
+// request was made from; it stays signed in. The change revokes the user's
+// access tokens, so that login continues with the returned token pair. Nil is
+// returned for tokens without a login, which have to sign in again.
+func (s *UserService) ChangeUserPassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string, client ClientInfo) (*models.TokenResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, ErrInvalidCurrentPassword
+	}
+
+	if currentPassword == newPassword {
+		return nil, fmt.Errorf("%w: the new password must differ from the current one", ErrWeakPassword)
+	}
+
+	if err := s.policy.Validate(newPassword, user.Email); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var renewed *models.TokenResponse
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Users().UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		if sessionID == "" {
+			return tx.Sessions().RevokeAllForUser(ctx, user.ID, models.SessionRevokedPasswordChanged)
+		}
+		if err := tx.Sessions().RevokeAllExcept(ctx, user.ID, sessionID, models.SessionRevokedPasswordChanged); err != nil {
+			return err
+		}
+
+		// The new tokens carry the token version the password change set
+		updated, err := tx.Users().GetByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		renewed, err = s.auth.renewSession(ctx, tx, updated, sessionID, client)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return renewed, nil
+}
@@ -0,0 +1,161 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "franchise-saas"
+	testAudience = "franchise-saas-api"
+)
+
+func testIdentity() Claims {
+	return Claims{
+		UserID:       "user",
+		Email:        "user@example.com",
+		Role:         "dealer",
+		TenantID:     "tenant",
+		TokenVersion: 3,
+	}
+}
+
+func TestIssuerRoundTrip(t *testing.T) {
+	keys, err := NewKeySet(Config{Dir: t.TempDir(), Algorithm: AlgorithmEdDSA, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	issuer := NewIssuer(keys, testIssuer, testAudience, time.Hour)
+
+	signed, err := issuer.IssueAccess(testIdentity())
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	claims, err := issuer.ParseAccess(signed)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.Type != TypeAccess || claims.Subject != "user" || claims.TokenVersion != 3 || claims.Actor != nil {
+		t.Fatalf("claims: got %+v", claims)
+	}
+
+	if _, err := issuer.IssueImpersonation(testIdentity(), time.Minute); err == nil {
+		t.Fatal("impersonation token without an actor was issued")
+	}
+
+	identity := testIdentity()
+	identity.SessionID = "session"
+	identity.Actor = &Actor{UserID: "admin", Email: "admin@example.com", TokenVersion: 1}
+	signed, err = issuer.IssueImpersonation(identity, time.Minute)
+	if err != nil {
+		t.Fatalf("issue impersonation: %v", err)
+	}
+	claims, err = issuer.ParseAccess(signed)
+	if err != nil {
+		t.Fatalf("parse impersonation: %v", err)
+	}
+	if claims.Actor == nil || claims.Actor.UserID != "admin" || claims.SessionID != "" {
+		t.Fatalf("impersonation claims: got %+v", claims)
+	}
+}
+
+func TestIssuerRejectsForeignClaims(t *testing.T) {
+	keys, err := NewKeySet(Config{Dir: t.TempDir(), Algorithm: AlgorithmEdDSA, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	issuer := NewIssuer(keys, testIssuer, testAudience, time.Hour)
+
+	tests := []struct {
+		name    string
+		change  func(c *Claims)
+		wantErr error
+	}{
+		{name: "wrong issuer", change: func(c *Claims) { c.Issuer = "another-service" }, wantErr: ErrInvalidToken},
+		{name: "no issuer", change: func(c *Claims) { c.Issuer = "" }, wantErr: ErrInvalidToken},
+		{name: "wrong audience", change: func(c *Claims) { c.Audience = jwt.ClaimStrings{"another-api"} }, wantErr: ErrInvalidToken},
+		{name: "no audience", change: func(c *Claims) { c.Audience = nil }, wantErr: ErrInvalidToken},
+		{name: "refresh token", change: func(c *Claims) { c.Type = TypeRefresh }, wantErr: ErrWrongTokenType},
+		{name: "no token type", change: func(c *Claims) { c.Type = "" }, wantErr: ErrWrongTokenType},
+		{name: "expired", change: func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, wantErr: ErrInvalidToken},
+		{name: "no expiry", change: func(c *Claims) { c.ExpiresAt = nil }, wantErr: ErrInvalidToken},
+		{name: "no tenant", change: func(c *Claims) { c.TenantID = "" }, wantErr: ErrInvalidToken},
+		{name: "valid", change: func(c *Claims) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			claims := testIdentity()
+			claims.Type = TypeAccess
+			claims.RegisteredClaims = jwt.RegisteredClaims{
+				Issuer:    testIssuer,
+				Subject:   claims.UserID,
+				Audience:  jwt.ClaimStrings{testAudience},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			}
+			tt.change(&claims)
+
+			signed, err := keys.Sign(claims)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			_, err = issuer.ParseAccess(signed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parse: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssuerLegacyTokens(t *testing.T) {
+	const secret = "legacy-secret"
+
+	keys, err := NewKeySet(Config{
+		Dir:          t.TempDir(),
+		Algorithm:    AlgorithmEdDSA,
+		LegacySecret: secret,
+		LegacyUntil:  time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	issuer := NewIssuer(keys, testIssuer, testAudience, time.Hour)
+
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		wantErr  error
+	}{
+		// Legacy tokens carry no typ; their lifetime tells access from refresh tokens
+		{name: "access token", lifetime: time.Hour},
+		{name: "refresh token", lifetime: 7 * 24 * time.Hour, wantErr: ErrWrongTokenType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			claims := testIdentity()
+			claims.RegisteredClaims = jwt.RegisteredClaims{
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(tt.lifetime)),
+			}
+
+			signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			_, err = issuer.ParseAccess(signed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parse: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Package tokens signs and verifies JWTs with a set of asymmetric keys that
+// is loaded from a directory and rotated on a schedule. The public keys are
+// published as a JWKS so that other services can verify tokens.
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+var (
+	// ErrNoSigningKey is returned when the key directory holds no private key
+	ErrNoSigningKey = errors.New("no signing key available")
+	// ErrUnknownKey is returned for tokens whose kid is not in the key set
+	ErrUnknownKey = errors.New("token is signed with an unknown key")
+	// ErrLegacyToken is returned for HS256 tokens outside the migration window
+	ErrLegacyToken = errors.New("HS256 tokens are no longer accepted")
+)
+
+// Config describes where keys come from and how they are rotated
+type Config struct {
+	// Dir holds <kid>.pem private keys and <kid>.pub.pem verification-only public keys
+	Dir string
+	// Algorithm is used for generated keys: RS256 or EdDSA
+	Algorithm string
+	// Rotation is how old the newest key may get before a new one is generated; zero disables generation
+	Rotation time.Duration
+	// Activation delays signing with a new key so that verifiers can fetch it first
+	Activation time.Duration
+	// Retention is how long a replaced key keeps verifying tokens, at least the access token lifetime
+	Retention time.Duration
+	// LegacySecret verifies HS256 tokens until LegacyUntil
+	LegacySecret string
+	LegacyUntil  time.Time
+}
+
+// KeySet holds the signing keys ordered by creation time
+type KeySet struct {
+	cfg Config
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// Load creates a key set from the jwt_* settings
+func Load() (*KeySet, error) {
+	cfg := Config{
+		Dir:          viper.GetString("jwt_keys_dir"),
+		Algorithm:    viper.GetString("jwt_algorithm"),
+		Rotation:     time.Duration(viper.GetInt("jwt_key_rotation_days")) * 24 * time.Hour,
+		Activation:   time.Duration(viper.GetInt("jwt_key_activation_minutes")) * time.Minute,
+		Retention:    time.Duration(viper.GetInt("jwt_expiration_hours")) * time.Hour,
+		LegacySecret: viper.GetString("jwt_secret"),
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "./keys"
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmRS256
+	}
+
+	if until := viper.GetString("jwt_legacy_hs256_until"); until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt_legacy_hs256_until: %w", err)
+		}
+		cfg.LegacyUntil = parsed
+	}
+
+	return NewKeySet(cfg)
+}
+
+// NewKeySet loads the keys from cfg.Dir, generating the first one if needed
+func NewKeySet(cfg Config) (*KeySet, error) {
+	if cfg.Algorithm != AlgorithmRS256 && cfg.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+
+	s := &KeySet{cfg: cfg}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload re-reads the key directory. When the newest private key is older
+// than the rotation period, a new key is generated first.
+func (s *KeySet) Reload() error {
+	if err := os.MkdirAll(s.cfg.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	keys, err := readKeys(s.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	if s.rotationDue(keys, time.Now()) {
+		id, err := writeNewKey(s.cfg.Dir, s.cfg.Algorithm)
+		// Another replica sharing the directory may have just created the same key
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		if err == nil {
+			log.Printf("Generated signing key %s", id)
+		}
+
+		keys, err = readKeys(s.cfg.Dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Run reloads the key set every interval until ctx is done, so that keys
+// added by operators or other replicas are picked up
+func (s *KeySet) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// Sign signs the claims with the current key and sets the kid header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.private)
+}
+
+// Parse verifies the token against the key set and fills claims. HS256
+// tokens are accepted with the legacy secret until the migration window ends.
+func (s *KeySet) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA, jwt.SigningMethodHS256.Alg()}))
+	return jwt.ParseWithClaims(tokenString, claims, s.keyFunc, opts...)
+}
+
+func (s *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	if token.Method == jwt.SigningMethodHS256 {
+		if s.cfg.LegacySecret == "" || !time.Now().Before(s.cfg.LegacyUntil) {
+			return nil, ErrLegacyToken
+		}
+		return []byte(s.cfg.LegacySecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.verificationKeys() {
+		if key.ID != kid {
+			continue
+		}
+		if key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("key %s does not use %s", kid, token.Method.Alg())
+		}
+		return key.public, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// signingKey returns the newest private key whose activation delay has
+// passed, or the oldest one while no key is active yet
+func (s *KeySet) signingKey() (*Key, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var current *Key
+	for _, key := range s.keys {
+		if !key.CanSign() {
+			continue
+		}
+		if current == nil || !key.CreatedAt.Add(s.cfg.Activation).After(now) {
+			current = key
+		}
+	}
+
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// verificationKeys returns the keys tokens are accepted from: all public
+// keys, and private keys until their successor has been signing for longer
+// than the retention period
+func (s *KeySet) verificationKeys() []*Key {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var private []*Key
+	result := make([]*Key, 0, len(s.keys))
+
+	for _, key := range s.keys {
+		if key.CanSign() {
+			private = append(private, key)
+		} else {
+			result = append(result, key)
+		}
+	}
+
+	for i, key := range private {
+		if i+1 < len(private) {
+			replacedAt := private[i+1].CreatedAt.Add(s.cfg.Activation)
+			if now.After(replacedAt.Add(s.cfg.Retention)) {
+				continue
+			}
+		}
+		result = append(result, key)
+	}
+
+	return result
+}
+
+func (s *KeySet) rotationDue(keys []*Key, now time.Time) bool {
+	var newest *Key
+	for _, key := range keys {
+		if key.CanSign() {
+			newest = key
+		}
+	}
+
+	if newest == nil {
+		return true
+	}
+	return s.cfg.Rotation > 0 && now.Sub(newest.CreatedAt) >= s.cfg.Rotation
+}
+
+// readKeys parses every *.pem file in dir. The kid is the file name without
+// the .pem or .pub.pem extension and the creation time is its modification time.
+func readKeys(dir string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	seen := map[string]bool{}
+	keys := make([]*Key, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+
+		id := strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")
+		if seen[id] {
+			return nil, fmt.Errorf("key %s is present more than once", id)
+		}
+		seen[id] = true
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKey(id, data, info.ModTime())
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", name, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// writeNewKey generates a key named after the current time. The file is
+// written aside and linked into place, so that other replicas never read a
+// partial key and replicas racing to rotate do not overwrite each other.
+func writeNewKey(dir, algorithm string) (string, error) {
+	data, err := generateKey(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".key-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	id := "key-" + time.Now().UTC().Format("20060102T150405Z")
+	if err := os.Link(tmp.Name(), filepath.Join(dir, id+".pem")); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,314 @@
+package tokens
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey stores a freshly generated private key as <id>.pem, or only its
+// public half as <id>.pub.pem, and backdates the file to createdAt. It returns
+// a key set that signs with the private key.
+func writeKey(t *testing.T, dir, id, algorithm string, createdAt time.Time, publicOnly bool) *KeySet {
+	t.Helper()
+
+	data, err := generateKey(algorithm)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	signerDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(signerDir, id+".pem"), data, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	signer, err := NewKeySet(Config{Dir: signerDir, Algorithm: algorithm})
+	if err != nil {
+		t.Fatalf("signer key set: %v", err)
+	}
+
+	name := filepath.Join(dir, id+".pem")
+	if publicOnly {
+		key, err := parseKey(id, data, createdAt)
+		if err != nil {
+			t.Fatalf("parse key: %v", err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(key.public)
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		name = filepath.Join(dir, id+".pub.pem")
+	}
+
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	if err := os.Chtimes(name, createdAt, createdAt); err != nil {
+		t.Fatalf("backdate key: %v", err)
+	}
+
+	return signer
+}
+
+func testClaims() jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Subject:   "user",
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			set, err := NewKeySet(Config{Dir: t.TempDir(), Algorithm: algorithm, Retention: time.Hour})
+			if err != nil {
+				t.Fatalf("key set: %v", err)
+			}
+
+			signed, err := set.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			token, err := set.Parse(signed, &jwt.RegisteredClaims{})
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if token.Method.Alg() != algorithm {
+				t.Fatalf("algorithm: got %s, want %s", token.Method.Alg(), algorithm)
+			}
+			if kid, _ := token.Header["kid"].(string); kid == "" {
+				t.Fatal("token has no kid")
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		oldCreated time.Time
+		newCreated time.Time
+		publicOnly bool
+		wantErr    error
+	}{
+		{
+			name:       "replaced key within retention",
+			oldCreated: now.Add(-48 * time.Hour),
+			newCreated: now.Add(-30 * time.Minute),
+		},
+		{
+			name:       "replaced key after retention",
+			oldCreated: now.Add(-48 * time.Hour),
+			newCreated: now.Add(-2 * time.Hour),
+			wantErr:    ErrUnknownKey,
+		},
+		{
+			name:       "published public key",
+			oldCreated: now.Add(-48 * time.Hour),
+			newCreated: now.Add(-2 * time.Hour),
+			publicOnly: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			oldSigner := writeKey(t, dir, "old", AlgorithmEdDSA, tt.oldCreated, tt.publicOnly)
+			writeKey(t, dir, "new", AlgorithmEdDSA, tt.newCreated, false)
+
+			set, err := NewKeySet(Config{Dir: dir, Algorithm: AlgorithmEdDSA, Retention: time.Hour})
+			if err != nil {
+				t.Fatalf("key set: %v", err)
+			}
+
+			// The newest key signs
+			signed, err := set.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			token, err := set.Parse(signed, &jwt.RegisteredClaims{})
+			if err != nil {
+				t.Fatalf("parse new token: %v", err)
+			}
+			if kid := token.Header["kid"]; kid != "new" {
+				t.Fatalf("signing kid: got %v, want new", kid)
+			}
+
+			old, err := oldSigner.Sign(testClaims())
+			if err != nil {
+				t.Fatalf("sign with old key: %v", err)
+			}
+			_, err = set.Parse(old, &jwt.RegisteredClaims{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parse old token: got %v, want %v", err, tt.wantErr)
+			}
+
+			published := false
+			for _, jwk := range set.JWKS().Keys {
+				published = published || jwk.KeyID == "old"
+			}
+			if published != (tt.wantErr == nil) {
+				t.Fatalf("old key published: got %v, want %v", published, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestKeySetActivationDelay(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "old", AlgorithmEdDSA, time.Now().Add(-48*time.Hour), false)
+	writeKey(t, dir, "new", AlgorithmEdDSA, time.Now().Add(-time.Minute), false)
+
+	set, err := NewKeySet(Config{Dir: dir, Algorithm: AlgorithmEdDSA, Activation: time.Hour, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+
+	signed, err := set.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	token, err := set.Parse(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// The new key is published but does not sign before it is activated
+	if kid := token.Header["kid"]; kid != "old" {
+		t.Fatalf("signing kid: got %v, want old", kid)
+	}
+	if len(set.JWKS().Keys) != 2 {
+		t.Fatalf("published keys: got %d, want 2", len(set.JWKS().Keys))
+	}
+}
+
+func TestKeySetRejectsUnknownKey(t *testing.T) {
+	set, err := NewKeySet(Config{Dir: t.TempDir(), Algorithm: AlgorithmEdDSA, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	other := writeKey(t, t.TempDir(), "stranger", AlgorithmEdDSA, time.Now(), false)
+
+	signed, err := other.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := set.Parse(signed, &jwt.RegisteredClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("parse: got %v, want %v", err, ErrUnknownKey)
+	}
+
+	// A token without a kid is no better
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+	key, err := other.signingKey()
+	if err != nil {
+		t.Fatalf("signing key: %v", err)
+	}
+	signed, err = unsigned.SignedString(key.private)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := set.Parse(signed, &jwt.RegisteredClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("parse without kid: got %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestKeySetLegacyHS256(t *testing.T) {
+	const secret = "legacy-secret"
+
+	tests := []struct {
+		name    string
+		secret  string
+		until   time.Time
+		wantErr error
+	}{
+		{name: "inside the migration window", secret: secret, until: time.Now().Add(time.Hour)},
+		{name: "after the migration window", secret: secret, until: time.Now().Add(-time.Second), wantErr: ErrLegacyToken},
+		{name: "without a legacy secret", until: time.Now().Add(time.Hour), wantErr: ErrLegacyToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewKeySet(Config{
+				Dir:          t.TempDir(),
+				Algorithm:    AlgorithmEdDSA,
+				LegacySecret: tt.secret,
+				LegacyUntil:  tt.until,
+			})
+			if err != nil {
+				t.Fatalf("key set: %v", err)
+			}
+
+			signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte(secret))
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			_, err = set.Parse(signed, &jwt.RegisteredClaims{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parse: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		check     func(t *testing.T, jwk JWK)
+	}{
+		{
+			algorithm: AlgorithmRS256,
+			check: func(t *testing.T, jwk JWK) {
+				if jwk.KeyType != "RSA" || jwk.N == "" || jwk.E != "AQAB" {
+					t.Fatalf("RSA key: got %+v", jwk)
+				}
+				if jwk.Curve != "" || jwk.X != "" {
+					t.Fatalf("RSA key has OKP members: %+v", jwk)
+				}
+			},
+		},
+		{
+			algorithm: AlgorithmEdDSA,
+			check: func(t *testing.T, jwk JWK) {
+				if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || len(jwk.X) != 43 {
+					t.Fatalf("Ed25519 key: got %+v", jwk)
+				}
+				if jwk.N != "" || jwk.E != "" {
+					t.Fatalf("Ed25519 key has RSA members: %+v", jwk)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			writeKey(t, dir, "signing", tt.algorithm, time.Now(), false)
+
+			set, err := NewKeySet(Config{Dir: dir, Algorithm: tt.algorithm})
+			if err != nil {
+				t.Fatalf("key set: %v", err)
+			}
+
+			jwks := set.JWKS()
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("keys: got %d, want 1", len(jwks.Keys))
+			}
+			jwk := jwks.Keys[0]
+			if jwk.KeyID != "signing" || jwk.Algorithm != tt.algorithm || jwk.Use != "sig" {
+				t.Fatalf("key: got %+v", jwk)
+			}
+			tt.check(t, jwk)
+		})
+	}
+}
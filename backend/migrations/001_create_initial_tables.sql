@@ -134,7 +134,6 @@ CREATE TRIGGER update_checklists_updated_at BEFORE UPDATE ON checklists FOR EACH
 CREATE TRIGGER update_checklist_tasks_updated_at BEFORE UPDATE ON checklist_tasks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_leads_updated_at BEFORE UPDATE ON leads FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_marketing_posts_updated_at BEFORE UPDATE ON marketing_posts FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_sessions_updated_at BEFORE UPDATE ON sessions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 -- +goose Down
 -- Удаление таблиц и связанных объектов
@@ -145,7 +144,6 @@ DROP TRIGGER IF EXISTS update_checklists_updated_at ON checklists;
 DROP TRIGGER IF EXISTS update_checklist_tasks_updated_at ON checklist_tasks;
 DROP TRIGGER IF EXISTS update_leads_updated_at ON leads;
 DROP TRIGGER IF EXISTS update_marketing_posts_updated_at ON marketing_posts;
-DROP TRIGGER IF EXISTS update_sessions_updated_at ON sessions;
 
 DROP FUNCTION IF EXISTS update_updated_at_column();
 
@@ -1,102 +0,0 @@
--- +goose Up
--- Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    email VARCHAR(255) UNIQUE NOT NULL,
-    password VARCHAR(255) NOT NULL,
-    role VARCHAR(50) NOT NULL,
-    tenant_id UUID NOT NULL,
-    first_name VARCHAR(100),
-    last_name VARCHAR(100),
-    phone VARCHAR(50),
-    avatar TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create indexes for users table
-CREATE INDEX idx_users_email ON users(email);
-CREATE INDEX idx_users_tenant_id ON users(tenant_id);
-CREATE INDEX idx_users_role ON users(role);
-
--- Create checklists table
-CREATE TABLE IF NOT EXISTS checklists (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    title VARCHAR(255) NOT NULL,
-    description TEXT,
-    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
-    tenant_id UUID NOT NULL,
-    status VARCHAR(50) DEFAULT 'pending',
-    kpi_score DECIMAL(5,2) DEFAULT 0.00,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create indexes for checklists table
-CREATE INDEX idx_checklists_user_id ON checklists(user_id);
-CREATE INDEX idx_checklists_tenant_id ON checklists(tenant_id);
-CREATE INDEX idx_checklists_status ON checklists(status);
-CREATE INDEX idx_checklists_created_at ON checklists(created_at);
-
--- Create tasks table
-CREATE TABLE IF NOT EXISTS tasks (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    title VARCHAR(255) NOT NULL,
-    description TEXT,
-    checklist_id UUID REFERENCES checklists(id) ON DELETE CASCADE,
-    status VARCHAR(50) DEFAULT 'pending',
-    order_num INTEGER DEFAULT 0,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create indexes for tasks table
-CREATE INDEX idx_tasks_checklist_id ON tasks(checklist_id);
-CREATE INDEX idx_tasks_status ON tasks(status);
-
--- Create tenants table
-CREATE TABLE IF NOT EXISTS tenants (
-    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    name VARCHAR(255) NOT NULL,
-    city VARCHAR(100),
-    plan VARCHAR(50) DEFAULT 'start', -- start, business, enterprise
-    settings JSONB,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
--- Create indexes for tenants table
-CREATE INDEX idx_tenants_city ON tenants(city);
-CREATE INDEX idx_tenants_plan ON tenants(plan);
-
--- Create triggers to update the 'updated_at' column
-CREATE OR REPLACE FUNCTION update_updated_at_column()
-RETURNS TRIGGER AS $$
-BEGIN
-    NEW.updated_at = CURRENT_TIMESTAMP;
-    RETURN NEW;
-END;
-$$ language 'plpgsql';
-
-CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_checklists_updated_at BEFORE UPDATE ON checklists FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_tasks_updated_at BEFORE UPDATE ON tasks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_tenants_updated_at BEFORE UPDATE ON tenants FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-
--- +goose StatementBegin
-INSERT INTO tenants (name, city) VALUES ('Main Franchise Network', 'Moscow') ON CONFLICT DO NOTHING;
--- +goose StatementEnd
-
-
--- +goose Down
-DROP TRIGGER IF EXISTS update_users_updated_at ON users;
-DROP TRIGGER IF EXISTS update_checklists_updated_at ON checklists;
-DROP TRIGGER IF EXISTS update_tasks_updated_at ON tasks;
-DROP TRIGGER IF EXISTS update_tenants_updated_at ON tenants;
-
-DROP FUNCTION IF EXISTS update_updated_at_column();
-
-DROP TABLE IF EXISTS tasks;
-DROP TABLE IF EXISTS checklists;
-DROP TABLE IF EXISTS users;
-DROP TABLE IF EXISTS tenants;
\ No newline at end of file
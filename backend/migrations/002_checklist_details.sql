@@ -0,0 +1,20 @@
+-- +goose Up
+-- Поля чек-листа и порядок задач, которые использует ChecklistService
+
+ALTER TABLE checklists ADD COLUMN title VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE checklists ADD COLUMN description TEXT;
+ALTER TABLE checklists ALTER COLUMN kpi_score TYPE NUMERIC(5, 2);
+ALTER TABLE checklists ALTER COLUMN kpi_score SET DEFAULT 0;
+
+ALTER TABLE checklist_tasks ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_checklists_status ON checklists(status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_checklists_status;
+
+ALTER TABLE checklist_tasks DROP COLUMN IF EXISTS position;
+
+ALTER TABLE checklists ALTER COLUMN kpi_score TYPE INTEGER USING ROUND(kpi_score)::INTEGER;
+ALTER TABLE checklists DROP COLUMN IF EXISTS description;
+ALTER TABLE checklists DROP COLUMN IF EXISTS title;
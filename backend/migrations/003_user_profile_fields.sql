@@ -0,0 +1,13 @@
+-- +goose Up
+-- Поля профиля пользователя, которые возвращает API
+
+ALTER TABLE users ADD COLUMN phone VARCHAR(50);
+ALTER TABLE users ADD COLUMN avatar TEXT;
+
+CREATE INDEX idx_users_role ON users(role);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_role;
+
+ALTER TABLE users DROP COLUMN IF EXISTS avatar;
+ALTER TABLE users DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Email уникален без учёта регистра: сервис приводит его к нижнему регистру
+
+UPDATE users SET email = LOWER(email) WHERE email <> LOWER(email);
+CREATE UNIQUE INDEX idx_users_email_lower ON users(LOWER(email));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_email_lower;
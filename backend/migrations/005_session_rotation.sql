@@ -0,0 +1,26 @@
+-- +goose Up
+-- Refresh токены хранятся в виде SHA-256 хеша, каждая ротация создаёт новую
+-- строку в том же семействе (family_id = идентификатор входа)
+
+DELETE FROM sessions;
+
+ALTER TABLE sessions ADD COLUMN family_id UUID NOT NULL;
+ALTER TABLE sessions ADD COLUMN authenticated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE sessions ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN rotated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN revoked_reason VARCHAR(50);
+
+CREATE UNIQUE INDEX idx_sessions_refresh_token ON sessions(refresh_token);
+CREATE INDEX idx_sessions_family_id ON sessions(family_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_family_id;
+DROP INDEX IF EXISTS idx_sessions_refresh_token;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS revoked_reason;
+ALTER TABLE sessions DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS authenticated_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Версия токенов пользователя: увеличивается при смене пароля или роли,
+-- и выданные ранее access токены перестают приниматься
+
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
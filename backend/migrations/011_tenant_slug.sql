@@ -0,0 +1,21 @@
+-- +goose Up
+-- Слаг тенанта (уникальный короткий адрес сети) и допустимые тарифы
+
+ALTER TABLE tenants ADD COLUMN slug VARCHAR(63);
+
+-- Существующим тенантам слаг строится из id, чтобы он был уникальным
+UPDATE tenants SET slug = 't-' || REPLACE(id::text, '-', '') WHERE slug IS NULL;
+
+ALTER TABLE tenants ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX idx_tenants_slug ON tenants(slug);
+
+UPDATE tenants SET settings = '{}' WHERE settings IS NULL;
+ALTER TABLE tenants ALTER COLUMN settings SET NOT NULL;
+
+ALTER TABLE tenants ADD CONSTRAINT tenants_plan_check CHECK (plan IN ('start', 'business', 'enterprise'));
+
+-- +goose Down
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_plan_check;
+ALTER TABLE tenants ALTER COLUMN settings DROP NOT NULL;
+DROP INDEX IF EXISTS idx_tenants_slug;
+ALTER TABLE tenants DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Блокировка тенанта суперадминистратором: пользователи заблокированной сети не могут войти
+
+ALTER TABLE tenants ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE tenants DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,117 @@
+-- +goose Up
+-- Изоляция тенантов на уровне строк. Приложение задаёт app.tenant_id в каждой
+-- транзакции запроса пользователя; без него (вход, обновление токена, операции
+-- суперадмина) политики пропускают все строки. Суперпользователь PostgreSQL
+-- обходит RLS, поэтому приложение должно подключаться обычной ролью.
+
+-- +goose StatementBegin
+CREATE FUNCTION app_current_tenant() RETURNS UUID
+LANGUAGE sql STABLE AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::uuid
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE tenants ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenants FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON tenants
+    USING (app_current_tenant() IS NULL OR id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR id = app_current_tenant());
+
+-- Таблицы со столбцом tenant_id
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON users
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE checklists ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checklists FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checklists
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE invitations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invitations FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invitations
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE leads ENABLE ROW LEVEL SECURITY;
+ALTER TABLE leads FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON leads
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE marketing_posts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE marketing_posts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON marketing_posts
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+-- Дочерние таблицы видны, если видна родительская строка (подзапрос сам
+-- проходит через политику родителя)
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sessions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON sessions
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id));
+
+ALTER TABLE user_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_tokens FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON user_tokens
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id));
+
+ALTER TABLE user_recovery_codes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_recovery_codes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON user_recovery_codes
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = user_id));
+
+ALTER TABLE checklist_tasks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checklist_tasks FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checklist_tasks
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM checklists c WHERE c.id = checklist_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM checklists c WHERE c.id = checklist_id));
+
+ALTER TABLE lead_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE lead_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON lead_events
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM leads l WHERE l.id = lead_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM leads l WHERE l.id = lead_id));
+
+-- +goose Down
+DROP POLICY IF EXISTS tenant_isolation ON lead_events;
+ALTER TABLE lead_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE lead_events DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON checklist_tasks;
+ALTER TABLE checklist_tasks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE checklist_tasks DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON user_recovery_codes;
+ALTER TABLE user_recovery_codes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_recovery_codes DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON user_tokens;
+ALTER TABLE user_tokens NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_tokens DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON sessions;
+ALTER TABLE sessions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE sessions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON marketing_posts;
+ALTER TABLE marketing_posts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE marketing_posts DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON leads;
+ALTER TABLE leads NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE leads DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON invitations;
+ALTER TABLE invitations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE invitations DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON checklists;
+ALTER TABLE checklists NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE checklists DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON users;
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON tenants;
+ALTER TABLE tenants NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tenants DISABLE ROW LEVEL SECURITY;
+DROP FUNCTION IF EXISTS app_current_tenant();
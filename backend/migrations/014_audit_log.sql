@@ -0,0 +1,25 @@
+-- +goose Up
+-- Журнал действий суперадмина от имени других пользователей и в чужих тенантах.
+-- Хранит и реального (actor_id), и действующего (user_id, tenant_id) пользователя.
+-- Внешних ключей нет, чтобы записи переживали удаление пользователей и тенантов.
+
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    method VARCHAR(10),
+    path TEXT,
+    status INTEGER,
+    reason TEXT,
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id, created_at);
+CREATE INDEX idx_audit_log_tenant_id ON audit_log(tenant_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
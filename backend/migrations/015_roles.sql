@@ -0,0 +1,41 @@
+-- +goose Up
+-- Роли тенанта: встроенные (franchiser, manager, dealer) и созданные франчайзером.
+-- Пользователь ссылается на роль по имени (users.role).
+
+CREATE TABLE roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    description VARCHAR(255),
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, name)
+);
+
+CREATE TRIGGER update_roles_updated_at BEFORE UPDATE ON roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE roles FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON roles
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+-- Встроенные роли существующих тенантов с правами, которые раньше были зашиты в коде
+INSERT INTO roles (tenant_id, name, description, permissions, built_in)
+SELECT id, 'franchiser', 'Owner of the franchise network',
+       ARRAY['manage_tenant', 'manage_roles', 'manage_invitations', 'view_all_dealers', 'manage_dealer_sessions', 'manage_checklists'], TRUE
+FROM tenants;
+
+INSERT INTO roles (tenant_id, name, description, permissions, built_in)
+SELECT id, 'manager', 'Manages a group of dealers', ARRAY['manage_checklists'], TRUE
+FROM tenants;
+
+INSERT INTO roles (tenant_id, name, description, permissions, built_in)
+SELECT id, 'dealer', 'Runs a franchise outlet', ARRAY['manage_checklists'], TRUE
+FROM tenants;
+
+-- +goose Down
+DROP TABLE IF EXISTS roles;
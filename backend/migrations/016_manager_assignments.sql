@@ -0,0 +1,36 @@
+-- +goose Up
+-- Менеджер видит дилеров, у которых users.manager_id указывает на него, и
+-- проверяет их задачи
+CREATE INDEX idx_users_manager_id ON users(manager_id);
+
+ALTER TABLE checklist_tasks ADD COLUMN verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE checklist_tasks ADD COLUMN verified_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE checklist_tasks ADD COLUMN review_comment TEXT;
+
+-- Новые разрешения встроенных ролей
+UPDATE roles
+SET permissions = permissions || ARRAY['view_assigned_dealers', 'assign_dealers', 'review_checklists']
+WHERE built_in AND name = 'franchiser';
+
+UPDATE roles
+SET permissions = permissions || ARRAY['view_assigned_dealers', 'review_checklists']
+WHERE built_in AND name = 'manager';
+
+-- +goose Down
+-- Разрешения снимаются только со встроенных ролей, которым их выдала миграция;
+-- пользовательские роли сети не трогаются
+UPDATE roles
+SET permissions = array_remove(array_remove(array_remove(permissions,
+    'view_assigned_dealers'), 'assign_dealers'), 'review_checklists')
+WHERE built_in AND name = 'franchiser';
+
+UPDATE roles
+SET permissions = array_remove(array_remove(permissions,
+    'view_assigned_dealers'), 'review_checklists')
+WHERE built_in AND name = 'manager';
+
+ALTER TABLE checklist_tasks DROP COLUMN IF EXISTS review_comment;
+ALTER TABLE checklist_tasks DROP COLUMN IF EXISTS verified_by;
+ALTER TABLE checklist_tasks DROP COLUMN IF EXISTS verified_at;
+
+DROP INDEX IF EXISTS idx_users_manager_id;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Ключи API для интеграций (сайт, 1С/ERP). Хранится только хеш ключа; сам ключ
+-- показывается один раз при создании.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_tenant_id ON api_keys(tenant_id);
+
+-- Ключ ищется по хешу до того, как тенант известен; без app.tenant_id политика
+-- пропускает все строки
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON api_keys
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+UPDATE roles
+SET permissions = permissions || ARRAY['manage_api_keys']
+WHERE built_in AND name = 'franchiser';
+
+-- +goose Down
+UPDATE roles
+SET permissions = array_remove(permissions, 'manage_api_keys')
+WHERE built_in AND name = 'franchiser';
+
+DROP TABLE IF EXISTS api_keys;
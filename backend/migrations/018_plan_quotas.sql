@@ -0,0 +1,12 @@
+-- +goose Up
+-- Тарифы задаются каталогом в конфигурации, поэтому список допустимых тарифов
+-- в базе больше не фиксируется. Для квоты хранилища учитывается объём
+-- загруженных файлов тенанта.
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_plan_check;
+
+ALTER TABLE tenants ADD COLUMN storage_used_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE tenants DROP COLUMN IF EXISTS storage_used_bytes;
+
+ALTER TABLE tenants ADD CONSTRAINT tenants_plan_check CHECK (plan IN ('start', 'business', 'enterprise'));
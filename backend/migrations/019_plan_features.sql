@@ -0,0 +1,18 @@
+-- +goose Up
+-- Модули тарифов, переключённые суперадмином. Они заменяют модули из каталога
+-- тарифов в конфигурации; лимиты по-прежнему берутся из каталога.
+CREATE TABLE plan_features (
+    plan VARCHAR(50) PRIMARY KEY,
+    features JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_plan_features_updated_at BEFORE UPDATE ON plan_features
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE INDEX idx_tenants_plan ON tenants(plan);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tenants_plan;
+DROP TABLE IF EXISTS plan_features;
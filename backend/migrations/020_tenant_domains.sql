@@ -0,0 +1,26 @@
+-- +goose Up
+-- Собственные домены сетей. Домен определяет сеть по заголовку Host только
+-- после подтверждения TXT-записью; неподтверждённый домен могут добавить
+-- несколько сетей, подтвердить — только одна.
+CREATE TABLE tenant_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, domain)
+);
+
+CREATE UNIQUE INDEX idx_tenant_domains_verified ON tenant_domains(domain) WHERE verified_at IS NOT NULL;
+
+-- Домен ищется до входа, когда тенант ещё неизвестен; без app.tenant_id
+-- политика пропускает все строки
+ALTER TABLE tenant_domains ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenant_domains FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON tenant_domains
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+-- +goose Down
+DROP TABLE IF EXISTS tenant_domains;
@@ -0,0 +1,69 @@
+-- +goose Up
+-- Шаблоны чек-листов франчайзера. Название, описание и задачи хранятся в
+-- версиях: каждое изменение содержимого создаёт новую версию, а чек-листы
+-- ссылаются на версию, из которой созданы. Назначение (вся сеть, город или
+-- список дилеров) меняется без новой версии.
+CREATE TABLE checklist_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL DEFAULT 1,
+    assignment JSONB NOT NULL DEFAULT '{"scope": "all"}',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_checklist_templates_tenant_id ON checklist_templates(tenant_id);
+
+CREATE TRIGGER update_checklist_templates_updated_at BEFORE UPDATE ON checklist_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE checklist_template_versions (
+    template_id UUID NOT NULL REFERENCES checklist_templates(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    tasks JSONB NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (template_id, version)
+);
+
+ALTER TABLE checklists ADD COLUMN template_id UUID REFERENCES checklist_templates(id) ON DELETE SET NULL;
+ALTER TABLE checklists ADD COLUMN template_version INTEGER;
+
+-- Подтверждение, которого требует задача шаблона: photo, comment, manager
+ALTER TABLE checklist_tasks ADD COLUMN verification VARCHAR(20);
+
+-- Город дилера для назначения шаблонов по городу
+ALTER TABLE users ADD COLUMN city VARCHAR(255);
+
+ALTER TABLE checklist_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checklist_templates FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checklist_templates
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE checklist_template_versions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checklist_template_versions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checklist_template_versions
+    USING (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM checklist_templates t WHERE t.id = template_id))
+    WITH CHECK (app_current_tenant() IS NULL OR EXISTS (SELECT 1 FROM checklist_templates t WHERE t.id = template_id));
+
+-- Новое разрешение встроенной роли франчайзера
+UPDATE roles
+SET permissions = permissions || ARRAY['manage_checklist_templates']
+WHERE built_in AND name = 'franchiser';
+
+-- +goose Down
+UPDATE roles
+SET permissions = array_remove(permissions, 'manage_checklist_templates')
+WHERE built_in AND name = 'franchiser';
+
+ALTER TABLE users DROP COLUMN IF EXISTS city;
+ALTER TABLE checklist_tasks DROP COLUMN IF EXISTS verification;
+ALTER TABLE checklists DROP COLUMN IF EXISTS template_version;
+ALTER TABLE checklists DROP COLUMN IF EXISTS template_id;
+
+DROP TABLE IF EXISTS checklist_template_versions;
+DROP TABLE IF EXISTS checklist_templates;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Расписание шаблона: daily, weekdays, weekly (weekday 1-7) или monthly
+-- (month_day 1-31). Шаблоны без расписания используются только вручную.
+ALTER TABLE checklist_templates ADD COLUMN recurrence JSONB;
+
+-- Нерабочие дни сети; в них планировщик не создаёт чек-листы
+CREATE TABLE tenant_holidays (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    name VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, date)
+);
+
+-- Запуски планировщика: строка вставляется в той же транзакции, что и
+-- чек-листы дня. Реплика, которая вставила строку, создаёт чек-листы;
+-- остальные ждут её транзакцию и пропускают день. Если запуск упал,
+-- транзакция откатывается вместе со строкой и день повторяется.
+CREATE TABLE checklist_generation_runs (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    checklists_created INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, date)
+);
+
+ALTER TABLE tenant_holidays ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenant_holidays FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON tenant_holidays
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+ALTER TABLE checklist_generation_runs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checklist_generation_runs FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checklist_generation_runs
+    USING (app_current_tenant() IS NULL OR tenant_id = app_current_tenant())
+    WITH CHECK (app_current_tenant() IS NULL OR tenant_id = app_current_tenant());
+
+-- +goose Down
+DROP TABLE IF EXISTS checklist_generation_runs;
+DROP TABLE IF EXISTS tenant_holidays;
+ALTER TABLE checklist_templates DROP COLUMN IF EXISTS recurrence;
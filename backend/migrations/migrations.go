@@ -0,0 +1,13 @@
+// Package migrations embeds the SQL schema migrations applied by the server.
+//
+// Files are named <version>_<name>.sql and use goose-style annotations:
+// statements after "-- +goose Up" are applied, statements after
+// "-- +goose Down" revert them.
+package migrations
+
+import "embed"
+
+// FS contains all migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,13 @@
+#!/bin/sh
+# Создаёт роль, которой подключается бэкенд. Она не суперпользователь и без
+# BYPASSRLS, поэтому политики row-level security (миграция 013) действуют на
+# все её запросы. Роль владеет базой и сама создаёт таблицы миграциями;
+# FORCE ROW LEVEL SECURITY распространяет политики и на владельца таблиц.
+# Скрипт выполняется только при первом запуске с пустым томом данных.
+set -e
+
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" \
+	-v app_user="$APP_DB_USER" -v app_password="$APP_DB_PASSWORD" -v db="$POSTGRES_DB" <<-'EOSQL'
+	CREATE ROLE :"app_user" LOGIN PASSWORD :'app_password' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+	ALTER DATABASE :"db" OWNER TO :"app_user";
+EOSQL